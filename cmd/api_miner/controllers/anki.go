@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/anki"
+	"github.com/antonve/language-learning-tools/internal/pkg/blobstore"
+	"github.com/antonve/language-learning-tools/internal/pkg/cardimage"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+// ankiCardStore provides pending cards with their image to the Anki sync.
+type ankiCardStore struct {
+	psql    *sql.DB
+	queries *postgres.Queries
+	images  *cardimage.Store
+}
+
+func NewAnkiCardStore(psql *sql.DB, images *cardimage.Store) anki.CardStore {
+	return &ankiCardStore{
+		psql:    psql,
+		queries: postgres.New(psql),
+		images:  images,
+	}
+}
+
+func (s *ankiCardStore) PendingCards(ctx context.Context, languageCode string) ([]anki.Card, error) {
+	rows, err := s.queries.ListPendingCards(ctx, languageCode)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make([]anki.Card, len(rows))
+	for i, row := range rows {
+		image, err := s.queries.GetImageFromPendingCard(ctx, row.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// A card without its image is still worth syncing
+		img, err := s.images.Get(ctx, image.ImageKey, image.SourceImage)
+		if errors.Cause(err) == blobstore.ErrNotFound {
+			log.Printf("image of card %d is missing, syncing it without: %v", row.ID, err)
+		} else if err != nil {
+			return nil, err
+		}
+
+		cards[i] = anki.Card{
+			ID:           row.ID,
+			LanguageCode: row.LanguageCode,
+			Token:        row.Token,
+			Meta:         row.Meta,
+			Image:        img,
+		}
+	}
+
+	return cards, nil
+}
+
+// MarkExported marks a card as exported and records it like an export from
+// the API.
+func (s *ankiCardStore) MarkExported(ctx context.Context, id int64) error {
+	tx, err := s.psql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateCardState(ctx, s.queries.WithTx(tx), id, cardEventExported, markCardAsExported); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
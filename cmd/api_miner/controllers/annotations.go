@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/anchor"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+const (
+	annotationKindHighlight   = "highlight"
+	annotationKindNote        = "note"
+	annotationKindTranslation = "translation"
+	annotationKindBookmark    = "bookmark"
+)
+
+// AnnotationsAPI manages highlights, notes, translations and bookmarks that
+// are anchored to a range of characters in a text.
+type AnnotationsAPI interface {
+	ListAnnotations(c echo.Context) error
+	CreateAnnotation(c echo.Context) error
+	UpdateAnnotation(c echo.Context) error
+	DeleteAnnotation(c echo.Context) error
+}
+
+type annotationsAPI struct {
+	psql    *sql.DB
+	queries *postgres.Queries
+}
+
+func NewAnnotationsAPI(psql *sql.DB) AnnotationsAPI {
+	return &annotationsAPI{
+		psql:    psql,
+		queries: postgres.New(psql),
+	}
+}
+
+type Annotation struct {
+	ID     int64  `json:"id"`
+	TextID int64  `json:"text_id"`
+	Kind   string `json:"kind"`
+	Start  int32  `json:"start"`
+	End    int32  `json:"end"`
+	Quote  string `json:"quote"`
+	Body   string `json:"body"`
+	// The quote was edited out of the text, the offsets are a best guess
+	Orphaned  bool            `json:"orphaned"`
+	CardIDs   json.RawMessage `json:"card_ids"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type ListAnnotationsResponse struct {
+	Annotations []Annotation `json:"annotations"`
+}
+
+// ListAnnotations lists the annotations of a text in the order they appear,
+// with the cards that were mined from them.
+func (api *annotationsAPI) ListAnnotations(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	if _, err := api.queries.GetText(ctx, id); err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	rows, err := api.queries.ListAnnotations(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	kind := c.QueryParam("kind")
+	res := &ListAnnotationsResponse{Annotations: []Annotation{}}
+
+	for _, row := range rows {
+		if kind != "" && row.Kind != kind {
+			continue
+		}
+
+		res.Annotations = append(res.Annotations, Annotation{
+			ID:        row.ID,
+			TextID:    row.TextID,
+			Kind:      row.Kind,
+			Start:     row.StartOffset,
+			End:       row.EndOffset,
+			Quote:     row.Quote,
+			Body:      row.Body,
+			Orphaned:  row.Orphaned,
+			CardIDs:   row.CardIds,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// CreateAnnotation anchors an annotation to a range of characters in the
+// content of a text, the annotated text is stored to attach it again after
+// the content is edited.
+func (api *annotationsAPI) CreateAnnotation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &AnnotationRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	text, err := api.queries.GetText(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if !anchor.Valid(req.Start, req.End, utf8.RuneCountInString(text.Content)) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	annotationID, err := api.queries.CreateAnnotation(ctx, postgres.CreateAnnotationParams{
+		TextID:      id,
+		Kind:        req.Kind,
+		StartOffset: int32(req.Start),
+		EndOffset:   int32(req.End),
+		Quote:       anchor.Quote(text.Content, req.Start, req.End),
+		Body:        req.Body,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, annotationID)
+}
+
+type AnnotationRequest struct {
+	Kind  string `json:"kind"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Body  string `json:"body"`
+}
+
+func (req *AnnotationRequest) Validate() error {
+	switch req.Kind {
+	case annotationKindHighlight, annotationKindNote, annotationKindTranslation:
+		if req.Start >= req.End {
+			return errors.Errorf("%s needs a range of characters", req.Kind)
+		}
+	case annotationKindBookmark:
+		if req.Start > req.End {
+			return errors.Errorf("start can't be after end")
+		}
+	default:
+		return errors.Errorf("invalid kind %s", req.Kind)
+	}
+
+	if (req.Kind == annotationKindNote || req.Kind == annotationKindTranslation) && req.Body == "" {
+		return errors.Errorf("body is required for a %s", req.Kind)
+	}
+
+	return nil
+}
+
+// UpdateAnnotation replaces an annotation, moving it to a new range attaches
+// an orphaned annotation again.
+func (api *annotationsAPI) UpdateAnnotation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	annotationID, err := strconv.ParseInt(c.Param("annotation_id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &AnnotationRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	text, err := api.queries.GetText(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if !anchor.Valid(req.Start, req.End, utf8.RuneCountInString(text.Content)) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	updated, err := api.queries.UpdateAnnotation(ctx, postgres.UpdateAnnotationParams{
+		Kind:        req.Kind,
+		StartOffset: int32(req.Start),
+		EndOffset:   int32(req.End),
+		Quote:       anchor.Quote(text.Content, req.Start, req.End),
+		Body:        req.Body,
+		ID:          annotationID,
+		TextID:      id,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if updated == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// DeleteAnnotation deletes an annotation, cards that were mined from it keep
+// their position in the text.
+func (api *annotationsAPI) DeleteAnnotation(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	annotationID, err := strconv.ParseInt(c.Param("annotation_id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	deleted, err := api.queries.DeleteAnnotation(c.Request().Context(), postgres.DeleteAnnotationParams{
+		ID:     annotationID,
+		TextID: id,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if deleted == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// reattachAnnotations moves the annotations and mined cards of a text along
+// with its content when the content is edited. Annotations whose quote is
+// gone are marked as orphaned.
+func reattachAnnotations(ctx context.Context, q *postgres.Queries, textID int64, before, after string) error {
+	if before == after {
+		return nil
+	}
+
+	edit := anchor.Diff(before, after)
+
+	annotations, err := q.ListAnnotations(ctx, textID)
+	if err != nil {
+		return errors.Wrap(err, "could not list annotations")
+	}
+
+	for _, a := range annotations {
+		moved := anchor.Reattach(anchor.Anchor{
+			Start:    int(a.StartOffset),
+			End:      int(a.EndOffset),
+			Quote:    a.Quote,
+			Orphaned: a.Orphaned,
+		}, edit, after)
+
+		if moved.Start == int(a.StartOffset) && moved.End == int(a.EndOffset) && moved.Orphaned == a.Orphaned {
+			continue
+		}
+
+		if err := q.SetAnnotationAnchor(ctx, postgres.SetAnnotationAnchorParams{
+			StartOffset: int32(moved.Start),
+			EndOffset:   int32(moved.End),
+			Orphaned:    moved.Orphaned,
+			ID:          a.ID,
+		}); err != nil {
+			return errors.Wrapf(err, "could not move annotation %d", a.ID)
+		}
+	}
+
+	cards, err := q.ListCardTextOffsets(ctx, sql.NullInt64{Int64: textID, Valid: true})
+	if err != nil {
+		return errors.Wrap(err, "could not list cards")
+	}
+
+	// Offsets point at the start of the mined word and move with it
+	for _, card := range cards {
+		offset := edit.Offset(int(card.TextOffset.Int32))
+		if offset == int(card.TextOffset.Int32) {
+			continue
+		}
+
+		if err := q.SetCardTextOffset(ctx, postgres.SetCardTextOffsetParams{
+			TextOffset: sql.NullInt32{Int32: int32(offset), Valid: true},
+			ID:         card.ID,
+		}); err != nil {
+			return errors.Wrapf(err, "could not move card %d", card.ID)
+		}
+	}
+
+	return nil
+}
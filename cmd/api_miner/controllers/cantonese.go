@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/cantodict"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/segmenter"
+)
+
+type CantoneseAPI interface {
+	CantoDict(c echo.Context) error
+	TextAnalyse(c echo.Context) error
+}
+
+type cantoneseAPI struct {
+	dictionary cantodict.Dictionary
+	segmenter  segmenter.Segmenter
+}
+
+func NewCantoneseAPI(dictionary cantodict.Dictionary, segmenter segmenter.Segmenter) CantoneseAPI {
+	return &cantoneseAPI{
+		dictionary: dictionary,
+		segmenter:  segmenter,
+	}
+}
+
+func (api *cantoneseAPI) CantoDict(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process cantodict request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &CantoDictRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process cantodict request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	res := map[string]*CantoDictResponse{}
+	for _, token := range req.Words {
+		if _, ok := res[token]; ok {
+			continue
+		}
+
+		c.Echo().Logger.Infof("cantodict: %s", token)
+
+		res[token] = &CantoDictResponse{
+			Source:  token,
+			Results: []CantoDictResultResponse{},
+		}
+
+		for _, d := range api.dictionary.Lookup(token) {
+			res[token].Results = append(res[token].Results, CantoDictResultResponse{
+				Jyutping:         d.Jyutping,
+				Pinyin:           d.Pinyin,
+				HanziSimplified:  d.Simplified,
+				HanziTraditional: d.Traditional,
+				Meanings:         d.Meanings,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type CantoDictRequest struct {
+	Words []string `json:"words"`
+}
+
+type CantoDictResultResponse struct {
+	Jyutping         string   `json:"jyutping"`
+	Pinyin           string   `json:"pinyin"`
+	HanziSimplified  string   `json:"hanzi_simplified"`
+	HanziTraditional string   `json:"hanzi_traditional"`
+	Meanings         []string `json:"meanings"`
+}
+
+type CantoDictResponse struct {
+	Source  string                    `json:"source"`
+	Results []CantoDictResultResponse `json:"results"`
+}
+
+func (api *cantoneseAPI) TextAnalyse(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &TextAnalyseRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	res := &CantoneseTextAnalyseResponse{
+		Lines: analyseText(api.segmenter, req.Text, func(token *TextAnalyseToken) {
+			for _, d := range api.dictionary.Lookup(token.Traditional) {
+				token.Jyutping = append(token.Jyutping, d.Jyutping)
+			}
+		}),
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type CantoneseTextAnalyseResponse struct {
+	Lines []TextAnalyseLine `json:"lines"`
+}
@@ -2,17 +2,24 @@ package controllers
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/jcramb/cedict"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/siongui/gojianfan"
-	"github.com/yanyiwu/gojieba"
 
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/hanzi"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/levels"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/segmenter"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
 	"github.com/antonve/language-learning-tools/internal/pkg/zdic"
 )
 
@@ -20,19 +27,36 @@ type ChineseAPI interface {
 	Cedict(c echo.Context) error
 	Zdic(c echo.Context) error
 	TextAnalyse(e echo.Context) error
+	Level(c echo.Context) error
+	TextLevels(c echo.Context) error
+	Hanzi(c echo.Context) error
+	HanziContaining(c echo.Context) error
+	ListSegmentationEntries(c echo.Context) error
+	AddSegmentationEntry(c echo.Context) error
+	RemoveSegmentationEntry(c echo.Context) error
+	ReloadSegmentation(c echo.Context) error
 }
 
+// chineseLanguageCode is the language code used for mined Chinese words
+const chineseLanguageCode = "zho"
+
 type chineseAPI struct {
-	cedict *cedict.Dict
-	zdic   zdic.Zdic
-	jieba  *gojieba.Jieba
+	cedict    *cedict.Dict
+	zdic      zdic.Zdic
+	segmenter segmenter.Segmenter
+	levels    levels.Levels
+	hanzi     hanzi.Dictionary
+	queries   *postgres.Queries
 }
 
-func NewChineseAPI() ChineseAPI {
+func NewChineseAPI(psql *sql.DB, dict *cedict.Dict, segmenter segmenter.Segmenter, levels levels.Levels, hanzi hanzi.Dictionary) ChineseAPI {
 	return &chineseAPI{
-		cedict: cedict.New(),
-		zdic:   zdic.New(),
-		jieba:  gojieba.NewJieba(),
+		cedict:    dict,
+		zdic:      zdic.New(),
+		segmenter: segmenter,
+		levels:    levels,
+		hanzi:     hanzi,
+		queries:   postgres.New(psql),
 	}
 }
 
@@ -62,6 +86,7 @@ func (api *chineseAPI) Cedict(c echo.Context) error {
 		res[token] = &CedictResponse{
 			Source:  token,
 			Results: []CedictResultResponse{},
+			Levels:  newLevelsResponse(api.levels.Lookup(token, "")),
 		}
 
 		for _, d := range defs {
@@ -71,6 +96,7 @@ func (api *chineseAPI) Cedict(c echo.Context) error {
 				HanziSimplified:  d.Simplified,
 				HanziTraditional: d.Traditional,
 				Meanings:         d.Meanings,
+				Levels:           newLevelsResponse(api.levels.Lookup(d.Traditional, d.Simplified)),
 			})
 		}
 	}
@@ -83,16 +109,18 @@ type CedictRequest struct {
 }
 
 type CedictResultResponse struct {
-	PinyinTones      string   `json:"pinyin_tones"`
-	Pinyin           string   `json:"pinyin"`
-	HanziSimplified  string   `json:"hanzi_simplified"`
-	HanziTraditional string   `json:"hanzi_traditional"`
-	Meanings         []string `json:"meanings"`
+	PinyinTones      string         `json:"pinyin_tones"`
+	Pinyin           string         `json:"pinyin"`
+	HanziSimplified  string         `json:"hanzi_simplified"`
+	HanziTraditional string         `json:"hanzi_traditional"`
+	Meanings         []string       `json:"meanings"`
+	Levels           LevelsResponse `json:"levels"`
 }
 
 type CedictResponse struct {
 	Source  string                 `json:"source"`
 	Results []CedictResultResponse `json:"results"`
+	Levels  LevelsResponse         `json:"levels"`
 }
 
 func (api *chineseAPI) Zdic(c echo.Context) error {
@@ -103,29 +131,54 @@ func (api *chineseAPI) Zdic(c echo.Context) error {
 	res, err := api.zdic.Search(token)
 	if err != nil {
 		c.Echo().Logger.Error(err)
-		return c.NoContent(http.StatusInternalServerError)
+		switch errors.Cause(err) {
+		case zdic.ErrNotFound:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
 	}
 
-	b := new(bytes.Buffer)
-	enc := json.NewEncoder(b)
-	enc.SetEscapeHTML(false)
-	enc.Encode(&ZdicResponse{
+	response := &ZdicResponse{
 		Source:     res.Word,
 		Pinyin:     res.Pinyin,
 		Zhuyin:     res.Zhuyin,
 		AudioURL:   res.AudioURL,
 		Definition: res.Definition,
-	})
+		Readings:   make([]ZdicReadingResponse, len(res.Readings)),
+	}
+
+	for i, r := range res.Readings {
+		response.Readings[i] = ZdicReadingResponse{
+			Pinyin:   r.Pinyin,
+			Zhuyin:   r.Zhuyin,
+			AudioURL: r.AudioURL,
+			Senses:   r.Senses,
+		}
+	}
+
+	b := new(bytes.Buffer)
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(response)
 
 	return c.JSONBlob(http.StatusOK, b.Bytes())
 }
 
 type ZdicResponse struct {
-	Source     string `json:"source"`
-	Pinyin     string `json:"pinyin"`
-	Zhuyin     string `json:"zhuyin"`
-	AudioURL   string `json:"audio_url"`
-	Definition string `json:"definition"`
+	Source     string                `json:"source"`
+	Pinyin     string                `json:"pinyin"`
+	Zhuyin     string                `json:"zhuyin"`
+	AudioURL   string                `json:"audio_url"`
+	Definition string                `json:"definition"`
+	Readings   []ZdicReadingResponse `json:"readings"`
+}
+
+type ZdicReadingResponse struct {
+	Pinyin   string   `json:"pinyin"`
+	Zhuyin   string   `json:"zhuyin"`
+	AudioURL string   `json:"audio_url"`
+	Senses   []string `json:"senses"`
 }
 
 func (api *chineseAPI) TextAnalyse(c echo.Context) error {
@@ -141,19 +194,39 @@ func (api *chineseAPI) TextAnalyse(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	lines := strings.FieldsFunc(req.Text, func(c rune) bool {
-		return c == '\n'
-	})
+	histogram := levels.NewHistogram()
 
 	res := &ChineseTextAnalyseResponse{
-		Lines: make([]TextAnalyseLine, len(lines)),
+		Lines: analyseText(api.segmenter, req.Text, func(token *TextAnalyseToken) {
+			lvl := api.levels.Lookup(token.Traditional, token.Simplified)
+			levelsResponse := newLevelsResponse(lvl)
+			token.Levels = &levelsResponse
+
+			if containsHanzi(token.Traditional) {
+				histogram.Add(token.Traditional, lvl)
+			}
+		}),
 	}
 
-	useHMM := true
+	res.LevelHistogram = newLevelHistogramResponse(histogram)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// analyseText segments every line of a traditional Chinese text. Jieba works
+// best on simplified text, so the segmentation runs on the simplified line and
+// its offsets are used to slice the original line. The annotate callback can add
+// extra data to every token.
+func analyseText(seg segmenter.Segmenter, text string, annotate func(token *TextAnalyseToken)) []TextAnalyseLine {
+	lines := strings.FieldsFunc(text, func(c rune) bool {
+		return c == '\n'
+	})
+
+	res := make([]TextAnalyseLine, len(lines))
 
 	for i, line := range lines {
 		simplified := gojianfan.T2S(line)
-		words := api.jieba.Tokenize(simplified, gojieba.DefaultMode, useHMM)
+		words := seg.Tokenize(simplified)
 		tokens := make([]TextAnalyseToken, len(words))
 
 		for j, w := range words {
@@ -163,16 +236,28 @@ func (api *chineseAPI) TextAnalyse(c echo.Context) error {
 				Start:       w.Start,
 				End:         w.End,
 			}
+
+			annotate(&tokens[j])
 		}
 
-		res.Lines[i] = TextAnalyseLine{
+		res[i] = TextAnalyseLine{
 			Simplified:  simplified,
 			Traditional: line,
 			Tokens:      tokens,
 		}
+	}
+
+	return res
+}
 
+func containsHanzi(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
 	}
-	return c.JSON(http.StatusOK, res)
+
+	return false
 }
 
 type TextAnalyseRequest struct {
@@ -186,12 +271,305 @@ type TextAnalyseLine struct {
 }
 
 type TextAnalyseToken struct {
-	Traditional string `json:"hanzi_traditional"`
-	Simplified  string `json:"hanzi_simplified"`
-	Start       int    `json:"start"`
-	End         int    `json:"end"`
+	Traditional string          `json:"hanzi_traditional"`
+	Simplified  string          `json:"hanzi_simplified"`
+	Start       int             `json:"start"`
+	End         int             `json:"end"`
+	Levels      *LevelsResponse `json:"levels,omitempty"`
+	Jyutping    []string        `json:"jyutping,omitempty"`
 }
 
 type ChineseTextAnalyseResponse struct {
-	Lines []TextAnalyseLine `json:"lines"`
+	Lines          []TextAnalyseLine      `json:"lines"`
+	LevelHistogram LevelHistogramResponse `json:"level_histogram"`
+}
+
+type LevelHistogramResponse struct {
+	TOCFL map[string]int `json:"tocfl"`
+	HSK   map[string]int `json:"hsk"`
+	Total int            `json:"total"`
+}
+
+func newLevelHistogramResponse(h *levels.Histogram) LevelHistogramResponse {
+	return LevelHistogramResponse{
+		TOCFL: h.TOCFL,
+		HSK:   h.HSK,
+		Total: h.Total,
+	}
+}
+
+// TextLevels returns the level histogram of a saved Chinese text, so texts can
+// be compared without sending their content to the analyser.
+func (api *chineseAPI) TextLevels(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	text, err := api.queries.GetText(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if text.LanguageCode != chineseLanguageCode {
+		return c.NoContent(http.StatusUnprocessableEntity)
+	}
+
+	histogram := levels.NewHistogram()
+
+	analyseText(api.segmenter, text.Content, func(token *TextAnalyseToken) {
+		if containsHanzi(token.Traditional) {
+			histogram.Add(token.Traditional, api.levels.Lookup(token.Traditional, token.Simplified))
+		}
+	})
+
+	return c.JSON(http.StatusOK, &TextLevelsResponse{
+		TextID:         text.ID,
+		LevelHistogram: newLevelHistogramResponse(histogram),
+	})
+}
+
+type TextLevelsResponse struct {
+	TextID         int64                  `json:"text_id"`
+	LevelHistogram LevelHistogramResponse `json:"level_histogram"`
+}
+
+func (api *chineseAPI) Level(c echo.Context) error {
+	token := c.Param("token")
+
+	c.Echo().Logger.Infof("level: %s", token)
+
+	return c.JSON(http.StatusOK, &LevelResponse{
+		Source: token,
+		Levels: newLevelsResponse(api.levels.Lookup(token, "")),
+	})
+}
+
+type LevelResponse struct {
+	Source string         `json:"source"`
+	Levels LevelsResponse `json:"levels"`
+}
+
+type LevelsResponse struct {
+	TOCFL *LevelResponseEntry `json:"tocfl"`
+	HSK   *LevelResponseEntry `json:"hsk"`
+}
+
+type LevelResponseEntry struct {
+	Level string `json:"level"`
+	Band  string `json:"band,omitempty"`
+}
+
+func newLevelsResponse(r *levels.Result) LevelsResponse {
+	res := LevelsResponse{}
+
+	if r.TOCFL != nil {
+		res.TOCFL = &LevelResponseEntry{Level: r.TOCFL.Level, Band: r.TOCFL.Band}
+	}
+
+	if r.HSK != nil {
+		res.HSK = &LevelResponseEntry{Level: r.HSK.Level}
+	}
+
+	return res
+}
+
+func (api *chineseAPI) Hanzi(c echo.Context) error {
+	char := c.Param("char")
+
+	c.Echo().Logger.Infof("hanzi: %s", char)
+
+	res, err := api.hanzi.Lookup(char)
+	if err != nil {
+		switch errors.Cause(err) {
+		case hanzi.ErrNotFound:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	response := &HanziResponse{
+		Character:      res.Character,
+		Decompositions: res.Decompositions,
+		Components:     res.Components,
+		StrokeCount:    res.StrokeCount,
+		Definition:     res.Definition,
+		PhoneticSeries: res.PhoneticSeries,
+	}
+
+	if res.Radical != nil {
+		response.Radical = &HanziRadicalResponse{
+			Number:       res.Radical.Number,
+			Character:    res.Radical.Character,
+			ExtraStrokes: res.Radical.ExtraStrokes,
+		}
+	}
+
+	b := new(bytes.Buffer)
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(response)
+
+	return c.JSONBlob(http.StatusOK, b.Bytes())
+}
+
+type HanziResponse struct {
+	Character      string                `json:"character"`
+	Decompositions []string              `json:"decompositions"`
+	Components     []string              `json:"components"`
+	Radical        *HanziRadicalResponse `json:"radical"`
+	StrokeCount    int                   `json:"stroke_count"`
+	Definition     string                `json:"definition"`
+	PhoneticSeries []string              `json:"phonetic_series"`
+}
+
+type HanziRadicalResponse struct {
+	Number       int    `json:"number"`
+	Character    string `json:"character"`
+	ExtraStrokes int    `json:"extra_strokes"`
+}
+
+// HanziContaining lists the characters that contain the given component. By
+// default only characters that occur in known words are returned, pass
+// `all=true` to search the full dataset.
+func (api *chineseAPI) HanziContaining(c echo.Context) error {
+	component := c.Param("char")
+
+	c.Echo().Logger.Infof("hanzi containing: %s", component)
+
+	chars := api.hanzi.Containing(component)
+
+	if c.QueryParam("all") != "true" {
+		tokens, err := api.queries.ListTokensForLanguage(c.Request().Context(), chineseLanguageCode)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		chars = filterKnownCharacters(chars, tokens)
+	}
+
+	return c.JSON(http.StatusOK, &HanziContainingResponse{
+		Component:  component,
+		Characters: chars,
+	})
+}
+
+func filterKnownCharacters(chars []string, words []string) []string {
+	known := map[string]bool{}
+	for _, w := range words {
+		for _, r := range w {
+			known[string(r)] = true
+			known[gojianfan.S2T(string(r))] = true
+		}
+	}
+
+	res := []string{}
+	for _, c := range chars {
+		if known[c] {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+type HanziContainingResponse struct {
+	Component  string   `json:"component"`
+	Characters []string `json:"characters"`
+}
+
+func (api *chineseAPI) ListSegmentationEntries(c echo.Context) error {
+	entries := api.segmenter.Entries()
+
+	res := &ListSegmentationEntriesResponse{Entries: make([]SegmentationEntry, len(entries))}
+	for i, e := range entries {
+		res.Entries[i] = SegmentationEntry{
+			Word:      e.Word,
+			Frequency: e.Frequency,
+			Tag:       e.Tag,
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type SegmentationEntry struct {
+	Word      string `json:"word"`
+	Frequency int    `json:"frequency,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+}
+
+type ListSegmentationEntriesResponse struct {
+	Entries []SegmentationEntry `json:"entries"`
+}
+
+func (api *chineseAPI) AddSegmentationEntry(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &SegmentationEntry{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	err = api.segmenter.AddEntry(segmenter.Entry{
+		Word:      req.Word,
+		Frequency: req.Frequency,
+		Tag:       req.Tag,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		switch errors.Cause(err) {
+		case segmenter.ErrInvalidEntry:
+			return c.NoContent(http.StatusBadRequest)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	return c.NoContent(http.StatusCreated)
+}
+
+func (api *chineseAPI) RemoveSegmentationEntry(c echo.Context) error {
+	word := c.Param("word")
+	if word == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := api.segmenter.RemoveEntry(word); err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case segmenter.ErrEntryNotFound:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// ReloadSegmentation reloads the user dictionary from disk and picks up all
+// words that were mined since the last reload.
+func (api *chineseAPI) ReloadSegmentation(c echo.Context) error {
+	if err := api.segmenter.Reload(c.Request().Context()); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
 }
@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+// CollectionsAPI groups texts in an order, e.g. the chapters of a book.
+type CollectionsAPI interface {
+	ListCollections(c echo.Context) error
+	CreateCollection(c echo.Context) error
+	GetCollection(c echo.Context) error
+	UpdateCollection(c echo.Context) error
+	DeleteCollection(c echo.Context) error
+	OrderCollection(c echo.Context) error
+}
+
+type collectionsAPI struct {
+	psql    *sql.DB
+	queries *postgres.Queries
+}
+
+func NewCollectionsAPI(psql *sql.DB) CollectionsAPI {
+	return &collectionsAPI{
+		psql:    psql,
+		queries: postgres.New(psql),
+	}
+}
+
+type Collection struct {
+	ID           int64            `json:"id"`
+	LanguageCode string           `json:"language_code"`
+	Title        string           `json:"title"`
+	Author       string           `json:"author,omitempty"`
+	TextCount    int64            `json:"text_count"`
+	Texts        []CollectionText `json:"texts,omitempty"`
+}
+
+type CollectionText struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Order        int32  `json:"order"`
+	LastPosition int32  `json:"last_position"`
+}
+
+type ListCollectionsResponse struct {
+	Collections []Collection `json:"collections"`
+}
+
+func (api *collectionsAPI) ListCollections(c echo.Context) error {
+	languageCode := c.QueryParam("language_code")
+	if languageCode == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := api.queries.ListCollections(c.Request().Context(), languageCode)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &ListCollectionsResponse{Collections: []Collection{}}
+
+	for _, row := range rows {
+		res.Collections = append(res.Collections, Collection{
+			ID:           row.ID,
+			LanguageCode: row.LanguageCode,
+			Title:        row.Title,
+			Author:       row.Author,
+			TextCount:    row.TextCount,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (api *collectionsAPI) CreateCollection(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &CreateCollectionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	id, err := api.queries.CreateCollection(c.Request().Context(), postgres.CreateCollectionParams{
+		LanguageCode: req.LanguageCode,
+		Title:        req.Title,
+		Author:       req.Author,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, id)
+}
+
+type CreateCollectionRequest struct {
+	LanguageCode string `json:"language_code"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+}
+
+func (req *CreateCollectionRequest) Validate() error {
+	if req.LanguageCode == "" {
+		return errors.Errorf("language_code is required")
+	}
+
+	if req.Title == "" {
+		return errors.Errorf("title is required")
+	}
+
+	return nil
+}
+
+// GetCollection returns a collection with its texts in order.
+func (api *collectionsAPI) GetCollection(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	collection, err := api.queries.GetCollection(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	rows, err := api.queries.ListTextsInCollection(ctx, sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &Collection{
+		ID:           collection.ID,
+		LanguageCode: collection.LanguageCode,
+		Title:        collection.Title,
+		Author:       collection.Author,
+		TextCount:    int64(len(rows)),
+		Texts:        []CollectionText{},
+	}
+
+	for _, row := range rows {
+		res.Texts = append(res.Texts, CollectionText{
+			ID:           row.ID,
+			Title:        row.Title,
+			Order:        row.CollectionOrder.Int32,
+			LastPosition: row.LastPosition,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (api *collectionsAPI) UpdateCollection(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &UpdateCollectionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if req.Title == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	updated, err := api.queries.UpdateCollection(c.Request().Context(), postgres.UpdateCollectionParams{
+		Title:  req.Title,
+		Author: req.Author,
+		ID:     id,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if updated == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+type UpdateCollectionRequest struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// DeleteCollection deletes a collection, its texts are kept.
+func (api *collectionsAPI) DeleteCollection(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	deleted, err := api.queries.DeleteCollection(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if deleted == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// OrderCollection replaces the texts of a collection with the given texts in
+// that order. Texts that are left out are removed from the collection.
+func (api *collectionsAPI) OrderCollection(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &OrderCollectionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	collection, err := qtx.GetCollection(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	current, err := qtx.ListTextsInCollection(ctx, sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	keep := map[int64]bool{}
+	for _, textID := range req.TextIDs {
+		if keep[textID] {
+			return c.NoContent(http.StatusBadRequest)
+		}
+		keep[textID] = true
+	}
+
+	for _, text := range current {
+		if keep[text.ID] {
+			continue
+		}
+
+		if err := qtx.SetTextCollection(ctx, postgres.SetTextCollectionParams{ID: text.ID}); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	for i, textID := range req.TextIDs {
+		moved, err := qtx.MoveTextToCollection(ctx, postgres.MoveTextToCollectionParams{
+			CollectionID:    sql.NullInt64{Int64: id, Valid: true},
+			CollectionOrder: sql.NullInt32{Int32: int32(i + 1), Valid: true},
+			ID:              textID,
+			LanguageCode:    collection.LanguageCode,
+		})
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		// The text doesn't exist or is written in another language
+		if moved == 0 {
+			return c.NoContent(http.StatusBadRequest)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+type OrderCollectionRequest struct {
+	TextIDs []int64 `json:"text_ids"`
+}
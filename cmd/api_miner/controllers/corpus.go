@@ -16,23 +16,19 @@ type CorpusAPI interface {
 }
 
 type corpusAPI struct {
-	jpCorpus corpus.Corpus
-	zhCorpus corpus.Corpus
+	// Corpora by language, e.g. jp, zh or yue
+	corpora map[string]corpus.Corpus
 }
 
-func NewCorpusAPI(jpc, zhc corpus.Corpus) CorpusAPI {
+func NewCorpusAPI(corpora map[string]corpus.Corpus) CorpusAPI {
 	return &corpusAPI{
-		jpCorpus: jpc,
-		zhCorpus: zhc,
+		corpora: corpora,
 	}
 }
 
 func (api *corpusAPI) getCorpus(lang string) (corpus.Corpus, error) {
-	switch lang {
-	case "jp":
-		return api.jpCorpus, nil
-	case "zh":
-		return api.zhCorpus, nil
+	if cor, ok := api.corpora[lang]; ok {
+		return cor, nil
 	}
 
 	return nil, fmt.Errorf("no corpus found for language %s", lang)
@@ -1,31 +1,394 @@
 package controllers
 
 import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
 	"net/http"
 
-	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/german/analyser"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/compound"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/dictionary"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
 )
 
 type GermanAPI interface {
 	Lemma(e echo.Context) error
+	SentenceLemmas(e echo.Context) error
+	Split(e echo.Context) error
+	Word(e echo.Context) error
+	TextAnalyse(e echo.Context) error
 }
 
 type germanAPI struct {
 	lemmatizer *lemmatizer.GermanLemmatizer
+	splitter   *compound.Splitter
+	analyser   *analyser.Analyser
+	queries    *postgres.Queries
 }
 
-func NewGermanAPI() GermanAPI {
-	return &germanAPI{lemmatizer: lemmatizer.NewGermanLemmatizer()}
+func NewGermanAPI(psql *sql.DB, l *lemmatizer.GermanLemmatizer, s *compound.Splitter, a *analyser.Analyser) GermanAPI {
+	return &germanAPI{
+		lemmatizer: l,
+		splitter:   s,
+		analyser:   a,
+		queries:    postgres.New(psql),
+	}
 }
 
+// Lemma returns the lemmas of a word. Compounds without lemma candidates are
+// split instead, their lemma is built from the lemma of the head.
 func (api *germanAPI) Lemma(c echo.Context) error {
 	token := c.Param("token")
-	lemmas := api.lemmatizer.Lemmas(token)
 
-	return c.JSON(http.StatusOK, GermanLemmaResponse{Lemmas: lemmas})
+	if candidates := api.lemmatizer.Lemmas(token); len(candidates) > 0 {
+		return c.JSON(http.StatusOK, GermanLemmaResponse{
+			Lemmas: newGermanLemmaCandidates(candidates),
+		})
+	}
+
+	res := GermanLemmaResponse{Lemmas: []GermanLemmaCandidate{}}
+
+	if splits := api.splitter.Split(token); len(splits) > 0 {
+		best := newGermanSplitResponse(splits[0])
+		res.Lemmas = append(res.Lemmas, GermanLemmaCandidate{Lemma: best.Lemma, Score: best.Score})
+		res.Compound = &best
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func newGermanLemmaCandidates(candidates []lemmatizer.Candidate) []GermanLemmaCandidate {
+	res := make([]GermanLemmaCandidate, len(candidates))
+	for i, c := range candidates {
+		res[i] = GermanLemmaCandidate{
+			Lemma: c.Lemma,
+			POS:   c.POS,
+			Score: c.Score,
+		}
+	}
+
+	return res
 }
 
 type GermanLemmaResponse struct {
-	Lemmas []string `json:"lemmas"`
+	Lemmas   []GermanLemmaCandidate `json:"lemmas"`
+	Compound *GermanSplitResponse   `json:"compound,omitempty"`
+}
+
+type GermanLemmaCandidate struct {
+	Lemma string  `json:"lemma"`
+	POS   string  `json:"pos,omitempty"`
+	Score float64 `json:"score"`
+}
+
+// SentenceLemmas lemmatises every word of a sentence in one request. The
+// position of a word in the sentence is taken into account when using its
+// capitalisation as a hint.
+func (api *germanAPI) SentenceLemmas(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &GermanSentenceLemmasRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	res := &GermanSentenceLemmasResponse{
+		Tokens: []GermanSentenceLemmasToken{},
+	}
+
+	for _, s := range api.analyser.Analyse(req.Sentence) {
+		for _, t := range s.Tokens {
+			if t.POS == analyser.POSPunctuation {
+				continue
+			}
+
+			res.Tokens = append(res.Tokens, GermanSentenceLemmasToken{
+				Text:   t.Text,
+				Start:  t.Start,
+				End:    t.End,
+				Lemmas: newGermanLemmaCandidates(t.Candidates),
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type GermanSentenceLemmasRequest struct {
+	Sentence string `json:"sentence"`
+}
+
+func (req *GermanSentenceLemmasRequest) Validate() error {
+	if req.Sentence == "" {
+		return errors.Errorf("sentence is required")
+	}
+
+	return nil
+}
+
+type GermanSentenceLemmasResponse struct {
+	Tokens []GermanSentenceLemmasToken `json:"tokens"`
+}
+
+type GermanSentenceLemmasToken struct {
+	Text   string                 `json:"text"`
+	Start  int                    `json:"start"`
+	End    int                    `json:"end"`
+	Lemmas []GermanLemmaCandidate `json:"lemmas"`
+}
+
+// Split proposes ranked decompositions of a compound word.
+func (api *germanAPI) Split(c echo.Context) error {
+	token := c.Param("token")
+
+	res := GermanSplitsResponse{
+		Source: token,
+		Splits: []GermanSplitResponse{},
+	}
+
+	for _, s := range api.splitter.Split(token) {
+		res.Splits = append(res.Splits, newGermanSplitResponse(s))
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func newGermanSplitResponse(s compound.Split) GermanSplitResponse {
+	res := GermanSplitResponse{
+		Lemma:      s.Lemma(),
+		Head:       s.Head().Lemma,
+		Components: make([]GermanSplitComponent, len(s.Parts)),
+		Score:      s.Score,
+	}
+
+	for i, p := range s.Parts {
+		res.Components[i] = GermanSplitComponent{
+			Surface:        p.Surface,
+			Lemma:          p.Lemma,
+			LinkingElement: p.LinkingElement,
+		}
+	}
+
+	return res
+}
+
+type GermanSplitsResponse struct {
+	Source string                `json:"source"`
+	Splits []GermanSplitResponse `json:"splits"`
+}
+
+type GermanSplitResponse struct {
+	Lemma      string                 `json:"lemma"`
+	Head       string                 `json:"head"`
+	Components []GermanSplitComponent `json:"components"`
+	Score      float64                `json:"score"`
+}
+
+type GermanSplitComponent struct {
+	Surface        string `json:"surface"`
+	Lemma          string `json:"lemma"`
+	LinkingElement string `json:"linking_element,omitempty"`
+}
+
+// Word looks up the grammatical information of a word. When the word itself
+// isn't in the dictionary, its lemmas are looked up instead.
+func (api *germanAPI) Word(c echo.Context) error {
+	token := c.Param("lemma")
+	ctx := c.Request().Context()
+
+	c.Echo().Logger.Infof("german word: %s", token)
+
+	res := &GermanWordResponse{
+		Source:  token,
+		Entries: []GermanWordEntry{},
+	}
+
+	candidates := []string{token}
+	for _, c := range api.lemmatizer.Lemmas(token) {
+		candidates = append(candidates, c.Lemma)
+	}
+	seen := map[string]bool{}
+
+	for _, lemma := range candidates {
+		if seen[lemma] {
+			continue
+		}
+		seen[lemma] = true
+
+		rows, err := api.queries.FindGermanWords(ctx, lemma)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		for _, row := range rows {
+			entry, err := newGermanWordEntry(row)
+			if err != nil {
+				log.Println("could not process request:", err)
+				return c.NoContent(http.StatusInternalServerError)
+			}
+
+			res.Entries = append(res.Entries, entry)
+		}
+
+		// Stop at the first match, the other lemmas are less likely
+		if len(res.Entries) > 0 {
+			break
+		}
+	}
+
+	if len(res.Entries) == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func newGermanWordEntry(row postgres.FindGermanWordsRow) (GermanWordEntry, error) {
+	entry := GermanWordEntry{
+		Lemma:           row.Lemma,
+		POS:             row.Pos,
+		Gender:          row.Gender,
+		Article:         dictionary.Article(row.Gender),
+		Plural:          row.Plural,
+		Genitive:        row.Genitive,
+		Auxiliary:       row.Auxiliary,
+		SeparablePrefix: row.SeparablePrefix,
+	}
+
+	forms := []dictionary.Form{}
+	if err := json.Unmarshal(row.Forms, &forms); err != nil {
+		return entry, err
+	}
+
+	if err := json.Unmarshal(row.Senses, &entry.Senses); err != nil {
+		return entry, err
+	}
+
+	switch row.Pos {
+	case "noun", "name":
+		entry.Declension = dictionary.Declension(forms)
+	case "verb":
+		entry.PrincipalParts = dictionary.PrincipalParts(forms)
+		entry.Conjugation = make([]GermanWordForm, len(forms))
+		for i, f := range forms {
+			entry.Conjugation[i] = GermanWordForm{Form: f.Form, Tags: f.Tags}
+		}
+	}
+
+	return entry, nil
+}
+
+type GermanWordResponse struct {
+	Source  string            `json:"source"`
+	Entries []GermanWordEntry `json:"entries"`
+}
+
+type GermanWordEntry struct {
+	Lemma           string                       `json:"lemma"`
+	POS             string                       `json:"pos"`
+	Gender          string                       `json:"gender,omitempty"`
+	Article         string                       `json:"article,omitempty"`
+	Plural          string                       `json:"plural,omitempty"`
+	Genitive        string                       `json:"genitive,omitempty"`
+	Declension      map[string]map[string]string `json:"declension,omitempty"`
+	Auxiliary       string                       `json:"auxiliary,omitempty"`
+	SeparablePrefix string                       `json:"separable_prefix,omitempty"`
+	PrincipalParts  map[string]string            `json:"principal_parts,omitempty"`
+	Conjugation     []GermanWordForm             `json:"conjugation,omitempty"`
+	Senses          []string                     `json:"senses"`
+}
+
+type GermanWordForm struct {
+	Form string   `json:"form"`
+	Tags []string `json:"tags"`
+}
+
+// TextAnalyse splits a text into sentences and lemmatises every token. Both
+// parts of a separable verb get the lemma of the full verb.
+func (api *germanAPI) TextAnalyse(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &TextAnalyseRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	sentences := api.analyser.Analyse(req.Text)
+	res := &GermanTextAnalyseResponse{
+		Sentences: make([]GermanTextAnalyseSentence, len(sentences)),
+	}
+
+	for i, s := range sentences {
+		tokens := make([]GermanTextAnalyseToken, len(s.Tokens))
+
+		for j, t := range s.Tokens {
+			tokens[j] = GermanTextAnalyseToken{
+				Text:   t.Text,
+				Start:  t.Start,
+				End:    t.End,
+				Lemma:  t.Lemma,
+				Lemmas: newGermanLemmaCandidates(t.Candidates),
+				POS:    t.POS,
+			}
+
+			if t.Partner >= 0 {
+				partner := t.Partner
+				tokens[j].Partner = &partner
+			}
+		}
+
+		res.Sentences[i] = GermanTextAnalyseSentence{
+			Text:   s.Text,
+			Start:  s.Start,
+			End:    s.End,
+			Tokens: tokens,
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type GermanTextAnalyseResponse struct {
+	Sentences []GermanTextAnalyseSentence `json:"sentences"`
+}
+
+type GermanTextAnalyseSentence struct {
+	Text   string                   `json:"text"`
+	Start  int                      `json:"start"`
+	End    int                      `json:"end"`
+	Tokens []GermanTextAnalyseToken `json:"tokens"`
+}
+
+type GermanTextAnalyseToken struct {
+	Text   string                 `json:"text"`
+	Start  int                    `json:"start"`
+	End    int                    `json:"end"`
+	Lemma  string                 `json:"lemma"`
+	Lemmas []GermanLemmaCandidate `json:"lemmas,omitempty"`
+	POS    string                 `json:"pos"`
+
+	// Index of the other part of a separable verb in the same sentence
+	Partner *int `json:"partner,omitempty"`
 }
@@ -1,16 +1,33 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/antonve/language-learning-tools/internal/pkg/anchor"
+	"github.com/antonve/language-learning-tools/internal/pkg/anki"
+	"github.com/antonve/language-learning-tools/internal/pkg/blobstore"
+	"github.com/antonve/language-learning-tools/internal/pkg/cardfile"
+	"github.com/antonve/language-learning-tools/internal/pkg/cardimage"
+	"github.com/antonve/language-learning-tools/internal/pkg/cardschema"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/segmenter"
+	"github.com/antonve/language-learning-tools/internal/pkg/duplicates"
+	"github.com/antonve/language-learning-tools/internal/pkg/enrich"
+	"github.com/antonve/language-learning-tools/internal/pkg/publichttp"
 	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
 )
 
@@ -19,57 +36,265 @@ type MiningAPI interface {
 	CreatePendingCard(c echo.Context) error
 	UpdateCard(c echo.Context) error
 	CardImage(c echo.Context) error
+	CardThumbnail(c echo.Context) error
 	MarkCardAsExported(c echo.Context) error
+	UnmarkCardAsExported(c echo.Context) error
+	ArchiveCard(c echo.Context) error
+	UnarchiveCard(c echo.Context) error
+	DeleteCard(c echo.Context) error
+	CardEvents(c echo.Context) error
+	ExportPackage(c echo.Context) error
+	ExportCards(c echo.Context) error
+	ImportCards(c echo.Context) error
+	CardSchema(c echo.Context) error
+	CardEnrichment(c echo.Context) error
+	EnrichCard(c echo.Context) error
 }
 
 type miningAPI struct {
 	psql    *sql.DB
 	queries *postgres.Queries
+
+	// Segmenters by language code, mined words are added to them so they
+	// are recognized as a single token from now on
+	segmenters map[string]segmenter.Segmenter
+
+	images     *cardimage.Store
+	schemas    *cardschema.Validator
+	enricher   *enrich.Enricher
+	duplicates *duplicates.Normalizer
 }
 
-func NewMiningAPI(psql *sql.DB) MiningAPI {
+func NewMiningAPI(psql *sql.DB, segmenters map[string]segmenter.Segmenter, images *cardimage.Store, schemas *cardschema.Validator, enricher *enrich.Enricher, duplicates *duplicates.Normalizer) MiningAPI {
 	return &miningAPI{
-		psql:    psql,
-		queries: postgres.New(psql),
+		psql:       psql,
+		queries:    postgres.New(psql),
+		segmenters: segmenters,
+		images:     images,
+		schemas:    schemas,
+		enricher:   enricher,
+		duplicates: duplicates,
 	}
 }
 
+// ListPendingCards lists the cards of a language by state, pending cards are
+// listed by default. Cards can be filtered by creation date with `from` and `to`,
+// and searched by token and meta with `q`. Results are paginated with a
+// cursor and sorted by `created_at`, `updated_at` or `token`.
 func (api *miningAPI) ListPendingCards(c echo.Context) error {
+	list, err := parseListParams(c, []string{"created_at", "updated_at", "token"}, "created_at")
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
 	languageCode := c.QueryParam("language_code")
 	if languageCode == "" {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	rows, err := api.queries.ListPendingCards(c.Request().Context(), languageCode)
+	filters := postgres.CountCardsParams{
+		LanguageCode: languageCode,
+		Pattern:      list.Pattern,
+	}
+
+	var ok bool
+	if filters.Exported, filters.Archived, ok = cardStateFilter(c.QueryParam("state")); !ok {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if filters.CreatedAfter, err = parseDateParam(c.QueryParam("from")); err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	if filters.CreatedBefore, err = parseDateParam(c.QueryParam("to")); err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	rows, err := searchCards(ctx, api.queries, list, filters)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	total, err := api.queries.CountCards(ctx, filters)
 	if err != nil {
 		log.Println("could not process request:", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
-	res := &ListPendingCardsResponse{Cards: []Card{}}
+	res := &ListPendingCardsResponse{Cards: []Card{}, Total: total}
+
+	if list.hasNextPage(len(rows)) {
+		rows = rows[:list.Limit.Int32]
+		last := rows[len(rows)-1]
+		res.NextCursor = list.nextCursor(last.ID, last.CreatedAt, last.UpdatedAt, last.Token)
+	}
 
 	for _, row := range rows {
-		res.Cards = append(res.Cards, Card{
-			ID:           row.ID,
-			LanguageCode: row.LanguageCode,
-			Token:        row.Token,
-			Meta:         row.Meta,
-		})
+		res.Cards = append(res.Cards, *newCard(row))
 	}
 
 	return c.JSON(http.StatusOK, res)
 }
 
+// searchCards lists the cards matching the filters in the order of the
+// listing. Every order has its own query so it can use its index.
+func searchCards(ctx context.Context, q *postgres.Queries, list *listParams, f postgres.CountCardsParams) ([]postgres.ListCardsRow, error) {
+	byTime := postgres.SearchCardsByCreatedAtParams{
+		LanguageCode:  f.LanguageCode,
+		Exported:      f.Exported,
+		Archived:      f.Archived,
+		CreatedAfter:  f.CreatedAfter,
+		CreatedBefore: f.CreatedBefore,
+		Pattern:       f.Pattern,
+		AfterID:       list.afterID(),
+		AfterTime:     list.afterTime(),
+		Limit:         list.fetchLimit(),
+	}
+	byToken := postgres.SearchCardsByTokenParams{
+		LanguageCode:  f.LanguageCode,
+		Exported:      f.Exported,
+		Archived:      f.Archived,
+		CreatedAfter:  f.CreatedAfter,
+		CreatedBefore: f.CreatedBefore,
+		Pattern:       f.Pattern,
+		AfterID:       list.afterID(),
+		AfterText:     list.afterText(),
+		Limit:         list.fetchLimit(),
+	}
+
+	res := []postgres.ListCardsRow{}
+
+	switch list.Sort {
+	case "created_at":
+		rows, err := q.SearchCardsByCreatedAt(ctx, byTime)
+		for _, row := range rows {
+			res = append(res, postgres.ListCardsRow(row))
+		}
+		return res, err
+	case "-created_at":
+		rows, err := q.SearchCardsByCreatedAtDesc(ctx, postgres.SearchCardsByCreatedAtDescParams(byTime))
+		for _, row := range rows {
+			res = append(res, postgres.ListCardsRow(row))
+		}
+		return res, err
+	case "updated_at":
+		rows, err := q.SearchCardsByUpdatedAt(ctx, postgres.SearchCardsByUpdatedAtParams(byTime))
+		for _, row := range rows {
+			res = append(res, postgres.ListCardsRow(row))
+		}
+		return res, err
+	case "-updated_at":
+		rows, err := q.SearchCardsByUpdatedAtDesc(ctx, postgres.SearchCardsByUpdatedAtDescParams(byTime))
+		for _, row := range rows {
+			res = append(res, postgres.ListCardsRow(row))
+		}
+		return res, err
+	case "token":
+		rows, err := q.SearchCardsByToken(ctx, byToken)
+		for _, row := range rows {
+			res = append(res, postgres.ListCardsRow(row))
+		}
+		return res, err
+	case "-token":
+		rows, err := q.SearchCardsByTokenDesc(ctx, postgres.SearchCardsByTokenDescParams(byToken))
+		for _, row := range rows {
+			res = append(res, postgres.ListCardsRow(row))
+		}
+		return res, err
+	default:
+		return nil, errors.Errorf("unsupported sort %s", list.Sort)
+	}
+}
+
+const (
+	cardStatePending  = "pending"
+	cardStateExported = "exported"
+	cardStateArchived = "archived"
+	cardStateAll      = "all"
+)
+
+// cardStateFilter returns the exported and archived filters for a state.
+// Archived cards are excluded from the other states.
+func cardStateFilter(state string) (exported sql.NullBool, archived sql.NullBool, ok bool) {
+	switch state {
+	case "", cardStatePending:
+		return sql.NullBool{Bool: false, Valid: true}, sql.NullBool{Bool: false, Valid: true}, true
+	case cardStateExported:
+		return sql.NullBool{Bool: true, Valid: true}, sql.NullBool{Bool: false, Valid: true}, true
+	case cardStateArchived:
+		return sql.NullBool{}, sql.NullBool{Bool: true, Valid: true}, true
+	case cardStateAll:
+		return sql.NullBool{}, sql.NullBool{}, true
+	default:
+		return sql.NullBool{}, sql.NullBool{}, false
+	}
+}
+
+func cardState(exportedAt, archivedAt sql.NullTime) string {
+	switch {
+	case archivedAt.Valid:
+		return cardStateArchived
+	case exportedAt.Valid:
+		return cardStateExported
+	default:
+		return cardStatePending
+	}
+}
+
+// parseDateParam accepts a date or a timestamp in RFC 3339 format.
+func parseDateParam(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return sql.NullTime{Time: t, Valid: true}, nil
+		}
+	}
+
+	return sql.NullTime{}, errors.Errorf("invalid date %s", value)
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
 type Card struct {
 	ID           int64           `json:"id"`
 	LanguageCode string          `json:"language_code"`
 	Token        string          `json:"token"`
 	SourceImage  string          `json:"source_image,omitempty"`
 	Meta         json.RawMessage `json:"meta"`
+	State        string          `json:"state,omitempty"`
+	CreatedAt    *time.Time      `json:"created_at,omitempty"`
+	ExportedAt   *time.Time      `json:"exported_at,omitempty"`
+	ArchivedAt   *time.Time      `json:"archived_at,omitempty"`
+	// Where in a text the card was mined
+	TextID       *int64 `json:"text_id,omitempty"`
+	TextOffset   *int32 `json:"text_offset,omitempty"`
+	AnnotationID *int64 `json:"annotation_id,omitempty"`
 }
 
 type ListPendingCardsResponse struct {
-	Cards []Card `json:"cards"`
+	Cards      []Card `json:"cards"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func (api *miningAPI) CreatePendingCard(c echo.Context) error {
@@ -90,17 +315,100 @@ func (api *miningAPI) CreatePendingCard(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	_, err = api.queries.CreatePendingCard(c.Request().Context(), postgres.CreatePendingCardParams{
-		LanguageCode: req.LanguageCode,
-		Token:        req.Token,
-		SourceImage:  req.SourceImage,
-		Meta:         req.Meta,
-	})
+	if errs := api.schemas.Validate(req.LanguageCode, req.Meta); len(errs) > 0 {
+		return c.JSON(http.StatusBadRequest, newValidationErrorResponse(errs, ""))
+	}
+
+	img, err := base64.StdEncoding.DecodeString(req.SourceImage)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	merge := c.QueryParam("merge") == "true"
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	card, wordToken, err := findDuplicate(ctx, qtx, api.duplicates, req.LanguageCode, req.Token, req.Meta)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	switch {
+	case card != nil && merge:
+		if err := mergeIntoCard(ctx, qtx, api.duplicates, card, req.Meta); err != nil {
+			log.Println("could not process request:", err)
+
+			switch errors.Cause(err) {
+			case errInvalidCardMeta:
+				return c.NoContent(http.StatusBadRequest)
+			default:
+				return c.NoContent(http.StatusInternalServerError)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		return c.JSON(http.StatusOK, newCard(*card))
+	case card != nil:
+		return c.JSON(http.StatusConflict, &DuplicateCardResponse{Card: newCard(*card)})
+	case wordToken != "" && !merge:
+		return c.JSON(http.StatusConflict, &DuplicateCardResponse{WordToken: wordToken})
+	}
+
+	source, err := cardSource(ctx, qtx, req)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case errInvalidCardSource:
+			return c.NoContent(http.StatusBadRequest)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	keys, err := api.images.Put(ctx, img)
 	if err != nil {
 		log.Println("could not process request:", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
+	source.LanguageCode = req.LanguageCode
+	source.Token = req.Token
+	source.ImageKey = keys.Image
+	source.ThumbnailKey = keys.Thumbnail
+	source.Meta = req.Meta
+
+	if _, err := createCard(ctx, qtx, api.duplicates, api.enricher, source); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	api.enricher.Notify()
+
+	if seg, ok := api.segmenters[req.LanguageCode]; ok {
+		seg.AddWord(req.Token)
+	}
+
 	return c.NoContent(http.StatusCreated)
 }
 
@@ -109,6 +417,11 @@ type CreatePendingCardRequest struct {
 	Token        string          `json:"token"`
 	SourceImage  string          `json:"source_image"`
 	Meta         json.RawMessage `json:"meta"`
+	// Optionally links the card to where it was mined in a text, the text and
+	// offset default to those of the annotation
+	TextID       *int64 `json:"text_id"`
+	TextOffset   *int32 `json:"text_offset"`
+	AnnotationID *int64 `json:"annotation_id"`
 }
 
 func (req *CreatePendingCardRequest) Validate() error {
@@ -120,10 +433,233 @@ func (req *CreatePendingCardRequest) Validate() error {
 		return errors.Errorf("token is required")
 	}
 
+	if req.TextOffset != nil && *req.TextOffset < 0 {
+		return errors.Errorf("text_offset can't be negative")
+	}
+
+	if req.TextOffset != nil && req.TextID == nil && req.AnnotationID == nil {
+		return errors.Errorf("text_offset requires text_id")
+	}
+
+	return nil
+}
+
+var errInvalidCardSource = errors.New("invalid card source")
+
+// cardSource checks the text and annotation a card was mined from. The
+// annotation has to belong to the text, the text has to be written in the
+// language of the card and the offset has to be within the text.
+func cardSource(ctx context.Context, q *postgres.Queries, req *CreatePendingCardRequest) (postgres.CreatePendingCardParams, error) {
+	source := postgres.CreatePendingCardParams{}
+	textID := req.TextID
+	offset := req.TextOffset
+
+	if req.AnnotationID != nil {
+		annotation, err := q.GetAnnotation(ctx, *req.AnnotationID)
+		if errors.Cause(err) == sql.ErrNoRows {
+			return source, errors.Wrapf(errInvalidCardSource, "annotation %d doesn't exist", *req.AnnotationID)
+		}
+		if err != nil {
+			return source, errors.Wrap(err, "could not get annotation")
+		}
+
+		if textID != nil && *textID != annotation.TextID {
+			return source, errors.Wrapf(errInvalidCardSource, "annotation %d belongs to text %d", annotation.ID, annotation.TextID)
+		}
+
+		textID = &annotation.TextID
+		if offset == nil {
+			offset = &annotation.StartOffset
+		}
+
+		source.AnnotationID = sql.NullInt64{Int64: annotation.ID, Valid: true}
+	}
+
+	if textID == nil {
+		return source, nil
+	}
+
+	text, err := q.GetText(ctx, *textID)
+	if errors.Cause(err) == sql.ErrNoRows {
+		return source, errors.Wrapf(errInvalidCardSource, "text %d doesn't exist", *textID)
+	}
+	if err != nil {
+		return source, errors.Wrap(err, "could not get text")
+	}
+
+	if text.LanguageCode != req.LanguageCode {
+		return source, errors.Wrapf(errInvalidCardSource, "text %d is written in %s", text.ID, text.LanguageCode)
+	}
+
+	source.TextID = sql.NullInt64{Int64: text.ID, Valid: true}
+	if offset != nil {
+		if !anchor.Valid(int(*offset), int(*offset), utf8.RuneCountInString(text.Content)) {
+			return source, errors.Wrapf(errInvalidCardSource, "text_offset %d is outside of text %d", *offset, text.ID)
+		}
+
+		source.TextOffset = sql.NullInt32{Int32: *offset, Valid: true}
+	}
+
+	return source, nil
+}
+
+// DuplicateCardResponse is returned when a card is created for a word that
+// was mined before, either as a card or as a known word token.
+type DuplicateCardResponse struct {
+	Card      *Card  `json:"card,omitempty"`
+	WordToken string `json:"word_token,omitempty"`
+}
+
+func newCard(row postgres.ListCardsRow) *Card {
+	createdAt := row.CreatedAt
+
+	return &Card{
+		ID:           row.ID,
+		LanguageCode: row.LanguageCode,
+		Token:        row.Token,
+		Meta:         row.Meta,
+		State:        cardState(row.ExportedAt, row.ArchivedAt),
+		CreatedAt:    &createdAt,
+		ExportedAt:   nullTime(row.ExportedAt),
+		ArchivedAt:   nullTime(row.ArchivedAt),
+		TextID:       nullInt64(row.TextID),
+		TextOffset:   nullInt32(row.TextOffset),
+		AnnotationID: nullInt64(row.AnnotationID),
+	}
+}
+
+// findDuplicate looks for a pending or exported card, or otherwise a word
+// token, of the same language that is likely the same word as the token. The
+// language stays locked until q's transaction ends, so the card has to be
+// created in the same transaction.
+func findDuplicate(ctx context.Context, q *postgres.Queries, dups *duplicates.Normalizer, languageCode, token string, meta json.RawMessage) (*postgres.ListCardsRow, string, error) {
+	w := duplicates.Word{Token: token, Reading: duplicates.Reading(meta)}
+
+	card, err := dups.FindCard(ctx, q, languageCode, w)
+	if err != nil || card != nil {
+		return card, "", err
+	}
+
+	wordToken, err := dups.FindWordToken(ctx, q, languageCode, w)
+	return nil, wordToken, err
+}
+
+// createCard creates a pending card, stores its forms so it's found as a
+// duplicate and schedules its enrichment. Call Notify on the enricher after
+// committing the transaction of q.
+func createCard(ctx context.Context, q *postgres.Queries, dups *duplicates.Normalizer, enricher *enrich.Enricher, params postgres.CreatePendingCardParams) (int64, error) {
+	id, err := q.CreatePendingCard(ctx, params)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not create card")
+	}
+
+	if err := dups.SaveCard(ctx, q, id, params.LanguageCode, params.Token, params.Meta); err != nil {
+		return 0, err
+	}
+
+	if err := recordCardEvent(ctx, q, id, cardEventCreated, struct{}{}, params.Meta); err != nil {
+		return 0, err
+	}
+
+	if err := enricher.Schedule(ctx, q, id, params.LanguageCode); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+var errInvalidCardMeta = errors.New("invalid card meta")
+
+// mergeIntoCard adds the sentence of a new card to the examples of an
+// existing card instead of creating a second card for the same word. The
+// meta of the card is updated to the merged meta.
+func mergeIntoCard(ctx context.Context, q *postgres.Queries, dups *duplicates.Normalizer, card *postgres.ListCardsRow, meta json.RawMessage) error {
+	merged, err := mergeExample(card.Meta, meta)
+	if err != nil {
+		return errors.Wrap(errInvalidCardMeta, err.Error())
+	}
+
+	if err := q.UpdateCard(ctx, postgres.UpdateCardParams{
+		Meta: merged,
+		ID:   card.ID,
+	}); err != nil {
+		return errors.Wrap(err, "could not update card")
+	}
+
+	if err := dups.SaveCard(ctx, q, card.ID, card.LanguageCode, card.Token, merged); err != nil {
+		return err
+	}
+
+	if err := recordCardEvent(ctx, q, card.ID, cardEventMetaUpdated, card.Meta, merged); err != nil {
+		return err
+	}
+
+	card.Meta = merged
+
 	return nil
 }
 
+// mergeExample appends the sentence of meta to the examples of existing.
+// Sentences that are already on the card aren't added again.
+func mergeExample(existing, meta json.RawMessage) (json.RawMessage, error) {
+	var newMeta map[string]json.RawMessage
+	if err := json.Unmarshal(meta, &newMeta); err != nil {
+		return nil, errors.Wrap(err, "could not parse meta")
+	}
+
+	sentence, ok := newMeta["sentence"]
+	if !ok {
+		return existing, nil
+	}
+
+	var card map[string]json.RawMessage
+	if err := json.Unmarshal(existing, &card); err != nil || card == nil {
+		card = map[string]json.RawMessage{}
+	}
+
+	examples := []json.RawMessage{}
+	if raw, ok := card["examples"]; ok {
+		if err := json.Unmarshal(raw, &examples); err != nil {
+			return nil, errors.Wrap(err, "could not parse examples")
+		}
+	}
+
+	line := cardschema.SentenceLine(sentence)
+	if line == "" {
+		return existing, nil
+	}
+
+	if cardschema.SentenceLine(card["sentence"]) == line {
+		return existing, nil
+	}
+	for _, example := range examples {
+		if cardschema.SentenceLine(example) == line {
+			return existing, nil
+		}
+	}
+
+	examples = append(examples, sentence)
+
+	raw, err := json.Marshal(examples)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode examples")
+	}
+	card["examples"] = raw
+
+	return json.Marshal(card)
+}
+
 func (api *miningAPI) CardImage(c echo.Context) error {
+	return api.serveCardImage(c, false)
+}
+
+// CardThumbnail returns a downscaled version of the image of a card. The full
+// image is returned when no thumbnail could be made.
+func (api *miningAPI) CardThumbnail(c echo.Context) error {
+	return api.serveCardImage(c, true)
+}
+
+func (api *miningAPI) serveCardImage(c echo.Context, thumbnail bool) error {
 	id := c.Param("id")
 	if id == "" {
 		return c.NoContent(http.StatusBadRequest)
@@ -134,15 +670,55 @@ func (api *miningAPI) CardImage(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	img, err := api.queries.GetImageFromPendingCard(c.Request().Context(), int64(intId))
+	ctx := c.Request().Context()
+
+	row, err := api.queries.GetImageFromPendingCard(ctx, int64(intId))
 	if err != nil {
 		log.Println("could not process request:", err)
-		return c.NoContent(http.StatusInternalServerError)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	key := row.ImageKey
+	if thumbnail && row.ThumbnailKey.Valid {
+		key = row.ThumbnailKey
+	}
+
+	img, err := api.images.Get(ctx, key, row.SourceImage)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case blobstore.ErrNotFound:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if len(img) == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	etag := key.String
+	if !key.Valid {
+		etag = blobstore.Key(img)
 	}
 
-	c.Response().Writer.Write(img)
+	// Blobs never change, so the key doubles as ETag
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, http.DetectContentType(img))
+	res.Header().Set("ETag", `"`+etag+`"`)
+	res.Header().Set("Cache-Control", "private, max-age=86400")
 
-	return c.NoContent(http.StatusOK)
+	http.ServeContent(res, c.Request(), "", time.Time{}, bytes.NewReader(img))
+
+	return nil
 }
 
 func (api *miningAPI) UpdateCard(c echo.Context) error {
@@ -168,7 +744,34 @@ func (api *miningAPI) UpdateCard(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	if err := api.queries.UpdateCard(c.Request().Context(), postgres.UpdateCardParams{
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	card, err := qtx.GetCardForUpdate(ctx, int64(intId))
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if errs := api.schemas.Validate(card.LanguageCode, req.Meta); len(errs) > 0 {
+		return c.JSON(http.StatusBadRequest, newValidationErrorResponse(errs, ""))
+	}
+
+	if err := qtx.UpdateCard(ctx, postgres.UpdateCardParams{
 		Meta: req.Meta,
 		ID:   int64(intId),
 	}); err != nil {
@@ -176,6 +779,21 @@ func (api *miningAPI) UpdateCard(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
+	if err := api.duplicates.SaveCard(ctx, qtx, card.ID, card.LanguageCode, card.Token, req.Meta); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := recordCardEvent(ctx, qtx, card.ID, cardEventMetaUpdated, card.Meta, req.Meta); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
 	return nil
 }
 
@@ -184,20 +802,694 @@ type UpdateCardRequest struct {
 }
 
 func (api *miningAPI) MarkCardAsExported(c echo.Context) error {
-	id := c.Param("id")
-	if id == "" {
+	return api.changeCardState(c, cardEventExported, markCardAsExported)
+}
+
+func markCardAsExported(ctx context.Context, q *postgres.Queries, id int64) error {
+	return q.MarkCardAsExported(ctx, id)
+}
+
+// UnmarkCardAsExported makes a card pending again, e.g. when it was exported
+// by mistake.
+func (api *miningAPI) UnmarkCardAsExported(c echo.Context) error {
+	return api.changeCardState(c, cardEventUnexported, func(ctx context.Context, q *postgres.Queries, id int64) error {
+		_, err := q.UnmarkCardAsExported(ctx, id)
+		return err
+	})
+}
+
+// ArchiveCard hides a card from the pending and exported cards without
+// deleting it.
+func (api *miningAPI) ArchiveCard(c echo.Context) error {
+	return api.changeCardState(c, cardEventArchived, func(ctx context.Context, q *postgres.Queries, id int64) error {
+		_, err := q.ArchiveCard(ctx, id)
+		return err
+	})
+}
+
+func (api *miningAPI) UnarchiveCard(c echo.Context) error {
+	return api.changeCardState(c, cardEventUnarchived, func(ctx context.Context, q *postgres.Queries, id int64) error {
+		_, err := q.UnarchiveCard(ctx, id)
+		return err
+	})
+}
+
+// changeCardState runs a state change and records it as an event in the
+// same transaction.
+func (api *miningAPI) changeCardState(c echo.Context, eventType string, change func(ctx context.Context, q *postgres.Queries, id int64) error) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	intId, err := strconv.Atoi(id)
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
 	if err != nil {
-		return c.NoContent(http.StatusBadRequest)
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	if err := updateCardState(ctx, api.queries.WithTx(tx), id, eventType, change); err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
 	}
 
-	if err := api.queries.MarkCardAsExported(c.Request().Context(), int64(intId)); err != nil {
+	if err := tx.Commit(); err != nil {
 		log.Println("could not process request:", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
 	return c.NoContent(http.StatusCreated)
 }
+
+// updateCardState runs a state change and records it as an event. Changes
+// that leave the state as it was, e.g. archiving an archived card, aren't
+// recorded.
+func updateCardState(ctx context.Context, q *postgres.Queries, id int64, eventType string, change func(ctx context.Context, q *postgres.Queries, id int64) error) error {
+	before, err := q.GetCardForUpdate(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "could not find card %d", id)
+	}
+
+	if err := change(ctx, q, id); err != nil {
+		return err
+	}
+
+	after, err := q.GetCardForUpdate(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "could not find card %d", id)
+	}
+
+	if cardStateSnapshot(before).State == cardStateSnapshot(after).State {
+		return nil
+	}
+
+	return recordCardEvent(ctx, q, id, eventType, cardStateSnapshot(before), cardStateSnapshot(after))
+}
+
+// DeleteCard deletes a card permanently. Its events are kept, the last event
+// contains the deleted card.
+func (api *miningAPI) DeleteCard(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	card, err := qtx.GetCardForUpdate(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if _, err := qtx.DeleteCard(ctx, id); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	before := cardStateSnapshot(card)
+	before.Token = card.Token
+	before.LanguageCode = card.LanguageCode
+	before.Meta = card.Meta
+
+	if err := recordCardEvent(ctx, qtx, id, cardEventDeleted, before, struct{}{}); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CardEvents returns the history of a card.
+func (api *miningAPI) CardEvents(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := api.queries.ListCardEvents(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &CardEventsResponse{Events: []CardEvent{}}
+	for _, row := range rows {
+		res.Events = append(res.Events, CardEvent{
+			ID:        row.ID,
+			EventType: row.EventType,
+			Before:    row.Before,
+			After:     row.After,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type CardEventsResponse struct {
+	Events []CardEvent `json:"events"`
+}
+
+type CardEvent struct {
+	ID        int64           `json:"id"`
+	EventType string          `json:"event_type"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+const (
+	cardEventCreated     = "created"
+	cardEventMetaUpdated = "meta_updated"
+	cardEventExported    = "exported"
+	cardEventUnexported  = "unexported"
+	cardEventArchived    = "archived"
+	cardEventUnarchived  = "unarchived"
+	cardEventDeleted     = "deleted"
+)
+
+type cardSnapshot struct {
+	State        string          `json:"state"`
+	LanguageCode string          `json:"language_code,omitempty"`
+	Token        string          `json:"token,omitempty"`
+	Meta         json.RawMessage `json:"meta,omitempty"`
+}
+
+func cardStateSnapshot(card postgres.GetCardForUpdateRow) cardSnapshot {
+	return cardSnapshot{State: cardState(card.ExportedAt, card.ArchivedAt)}
+}
+
+func recordCardEvent(ctx context.Context, q *postgres.Queries, id int64, eventType string, before, after interface{}) error {
+	b, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+
+	a, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateCardEvent(ctx, postgres.CreateCardEventParams{
+		CardID:    id,
+		EventType: eventType,
+		Before:    b,
+		After:     a,
+	})
+}
+
+// mediaClient downloads the audio of cards, its URL is part of the meta users
+// can edit
+var mediaClient = publichttp.Client(10 * time.Second)
+
+// ExportPackage builds an Anki package with all pending cards of a language.
+// Audio is downloaded before the cards are locked, the cards are marked as
+// exported in one transaction so they're only marked when the package could
+// be built. Cards that were added or changed in the meantime are left for the
+// next export. Audio that couldn't be downloaded is listed in the
+// `X-Missing-Media` header.
+func (api *miningAPI) ExportPackage(c echo.Context) error {
+	languageCode := c.QueryParam("language_code")
+	if languageCode == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	format, err := anki.FormatForLanguage(languageCode)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	rows, err := api.queries.ListPendingCardsForExport(ctx, languageCode)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if len(rows) == 0 {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	prepared := &anki.Package{
+		Deck:     format.Deck,
+		NoteType: format.NoteType,
+		Notes:    make([]anki.Note, len(rows)),
+	}
+
+	for i, row := range rows {
+		img, err := api.images.Get(ctx, row.ImageKey, row.SourceImage)
+		if errors.Cause(err) == blobstore.ErrNotFound {
+			log.Printf("image of card %d is missing, exporting it without: %v", row.ID, err)
+		} else if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		note, err := format.Note(anki.Card{
+			ID:           row.ID,
+			LanguageCode: row.LanguageCode,
+			Token:        row.Token,
+			Meta:         row.Meta,
+			Image:        img,
+		})
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		prepared.Notes[i] = note
+	}
+
+	missing := prepared.FetchMedia(mediaClient)
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	locked, err := qtx.LockPendingCardsForExport(ctx, languageCode)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	updatedAt := make(map[int64]time.Time, len(locked))
+	for _, row := range locked {
+		updatedAt[row.ID] = row.UpdatedAt
+	}
+
+	pkg := &anki.Package{
+		Deck:     format.Deck,
+		NoteType: format.NoteType,
+		Notes:    []anki.Note{},
+	}
+	exported := []int64{}
+
+	for i, row := range rows {
+		if t, ok := updatedAt[row.ID]; !ok || !t.Equal(row.UpdatedAt) {
+			continue
+		}
+
+		pkg.Notes = append(pkg.Notes, prepared.Notes[i])
+		exported = append(exported, row.ID)
+	}
+
+	if len(exported) == 0 {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := pkg.Write(buf); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	for _, id := range exported {
+		if err := updateCardState(ctx, qtx, id, cardEventExported, markCardAsExported); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if len(missing) > 0 {
+		filenames := make([]string, len(missing))
+		for i, m := range missing {
+			filenames[i] = m.Filename
+		}
+
+		c.Response().Header().Set("X-Missing-Media", strings.Join(filenames, ","))
+	}
+
+	filename := fmt.Sprintf("ankiminer_%s_%s.apkg", languageCode, time.Now().Format("20060102_150405"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	return c.Blob(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
+
+// ExportCards writes cards to a CSV, TSV or JSON lines file. Images are
+// embedded as base64, or the file is put in a zip together with the images.
+func (api *miningAPI) ExportCards(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = cardfile.FormatCSV
+	}
+
+	images := c.QueryParam("images")
+	if images == "" {
+		images = cardfile.ImagesBase64
+	}
+
+	if !cardfile.ValidFormat(format) || (images != cardfile.ImagesBase64 && images != cardfile.ImagesZip) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	params := postgres.ListCardsWithImagesParams{}
+
+	if languageCode := c.QueryParam("language_code"); languageCode != "" {
+		params.LanguageCode = sql.NullString{String: languageCode, Valid: true}
+	}
+
+	var ok bool
+	if params.Exported, params.Archived, ok = cardStateFilter(c.QueryParam("state")); !ok {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	rows, err := api.queries.ListCardsWithImages(ctx, params)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	cards := make([]cardfile.Card, len(rows))
+	for i, row := range rows {
+		img, err := api.images.Get(ctx, row.ImageKey, row.SourceImage)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		createdAt := row.CreatedAt
+
+		cards[i] = cardfile.Card{
+			ID:           row.ID,
+			LanguageCode: row.LanguageCode,
+			Token:        row.Token,
+			Meta:         row.Meta,
+			Image:        img,
+			CreatedAt:    &createdAt,
+		}
+
+		if row.ExportedAt.Valid {
+			cards[i].ExportedAt = &row.ExportedAt.Time
+		}
+		if row.ArchivedAt.Valid {
+			cards[i].ArchivedAt = &row.ArchivedAt.Time
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := cardfile.Write(buf, format, images, cards); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	ext := format
+	if images == cardfile.ImagesZip {
+		ext = "zip"
+	}
+
+	filename := fmt.Sprintf("cards_%s.%s", time.Now().Format("20060102_150405"), ext)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	return c.Blob(http.StatusOK, cardfile.ContentType(format, images), buf.Bytes())
+}
+
+// Largest file that can be imported as cards, images included
+const maxCardFileSize = 100 << 20
+
+// ImportCards creates cards from a file in one of the export formats. Cards
+// keep when they were created, exported and archived. Cards of words that
+// already have a card are skipped, like when they're mined.
+func (api *miningAPI) ImportCards(c echo.Context) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxCardFileSize))
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return c.NoContent(http.StatusRequestEntityTooLarge)
+		}
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = cardfile.FormatCSV
+	}
+
+	cards, err := cardfile.Read(body, format, api.schemas.FieldTypes)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	invalid := &ValidationErrorResponse{Errors: []ValidationError{}}
+	for i, card := range cards {
+		if card.LanguageCode == "" || card.Token == "" {
+			log.Println("could not process request: language_code and token are required")
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		errs := api.schemas.Validate(card.LanguageCode, card.Meta)
+		invalid.Errors = append(invalid.Errors, newValidationErrorResponse(errs, fmt.Sprintf("cards[%d].", i)).Errors...)
+	}
+
+	if len(invalid.Errors) > 0 {
+		return c.JSON(http.StatusBadRequest, invalid)
+	}
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	res := ImportCardsResponse{Skipped: []ImportedCard{}}
+	imported := []cardfile.Card{}
+
+	for i, card := range cards {
+		dup, wordToken, err := findDuplicate(ctx, qtx, api.duplicates, card.LanguageCode, card.Token, card.Meta)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		if dup != nil || wordToken != "" {
+			skipped := ImportedCard{Index: i, Token: card.Token, WordToken: wordToken}
+			if dup != nil {
+				skipped.DuplicateID = dup.ID
+			}
+
+			res.Skipped = append(res.Skipped, skipped)
+			continue
+		}
+
+		keys, err := api.images.Put(ctx, card.Image)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		id, err := createCard(ctx, qtx, api.duplicates, api.enricher, postgres.CreatePendingCardParams{
+			LanguageCode: card.LanguageCode,
+			Token:        card.Token,
+			ImageKey:     keys.Image,
+			ThumbnailKey: keys.Thumbnail,
+			Meta:         card.Meta,
+		})
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		if err := qtx.SetCardTimestamps(ctx, postgres.SetCardTimestampsParams{
+			ID:         id,
+			CreatedAt:  toNullTime(card.CreatedAt),
+			ExportedAt: toNullTime(card.ExportedAt),
+			ArchivedAt: toNullTime(card.ArchivedAt),
+		}); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		imported = append(imported, card)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	api.enricher.Notify()
+
+	for _, card := range imported {
+		if seg, ok := api.segmenters[card.LanguageCode]; ok {
+			seg.AddWord(card.Token)
+		}
+	}
+
+	res.Imported = len(imported)
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+type ImportCardsResponse struct {
+	Imported int            `json:"imported"`
+	Skipped  []ImportedCard `json:"skipped"`
+}
+
+// ImportedCard is a card of an import that wasn't created because its word
+// already has a card or is known.
+type ImportedCard struct {
+	// Index of the card in the file
+	Index       int    `json:"index"`
+	Token       string `json:"token"`
+	DuplicateID int64  `json:"duplicate_id,omitempty"`
+	WordToken   string `json:"word_token,omitempty"`
+}
+
+// CardSchema returns the JSON Schema the meta of cards of a language has to
+// match.
+func (api *miningAPI) CardSchema(c echo.Context) error {
+	schema, err := api.schemas.Schema(c.Param("lang"))
+	if err != nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.Blob(http.StatusOK, "application/schema+json", schema)
+}
+
+func newValidationErrorResponse(errs []cardschema.FieldError, prefix string) *ValidationErrorResponse {
+	res := &ValidationErrorResponse{
+		Errors: make([]ValidationError, len(errs)),
+	}
+
+	for i, e := range errs {
+		res.Errors[i] = ValidationError{
+			Field:   prefix + e.Field,
+			Message: e.Message,
+		}
+	}
+
+	return res
+}
+
+type ValidationErrorResponse struct {
+	Errors []ValidationError `json:"errors"`
+}
+
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// CardEnrichment returns the status of every lookup provider for a card.
+func (api *miningAPI) CardEnrichment(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := api.queries.ListCardEnrichments(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &CardEnrichmentResponse{
+		Providers: make([]CardEnrichmentProvider, len(rows)),
+	}
+
+	for i, row := range rows {
+		fields := []string{}
+		if err := json.Unmarshal(row.Fields, &fields); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		res.Providers[i] = CardEnrichmentProvider{
+			Provider:  row.Provider,
+			Status:    row.Status,
+			Error:     row.Error,
+			Fields:    fields,
+			Attempts:  int(row.Attempts),
+			UpdatedAt: row.UpdatedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type CardEnrichmentResponse struct {
+	Providers []CardEnrichmentProvider `json:"providers"`
+}
+
+type CardEnrichmentProvider struct {
+	Provider  string    `json:"provider"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Fields    []string  `json:"fields"`
+	Attempts  int       `json:"attempts"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EnrichCard retries the providers that failed or didn't find anything for a
+// card.
+func (api *miningAPI) EnrichCard(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	count, err := api.queries.RetryCardEnrichments(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if count == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	api.enricher.Notify()
+
+	return c.NoContent(http.StatusAccepted)
+}
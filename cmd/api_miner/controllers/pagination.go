@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const maxPageSize = 500
+
+// listParams are the search, sort and pagination options shared by listings.
+// A sort field prefixed with `-` sorts in descending order. Listings without
+// a limit aren't paginated.
+type listParams struct {
+	// The search query as a pattern for ilike
+	Pattern sql.NullString
+	Sort    string
+	Limit   sql.NullInt32
+	Cursor  *cursor
+}
+
+// cursor points at the last row of a page, the next page starts after it.
+// The sort is included so a cursor can't be used with a different order.
+type cursor struct {
+	Sort string    `json:"s"`
+	Time time.Time `json:"t,omitempty"`
+	Text string    `json:"x,omitempty"`
+	ID   int64     `json:"id"`
+}
+
+func parseListParams(c echo.Context, sorts []string, defaultSort string) (*listParams, error) {
+	params := &listParams{
+		Sort: defaultSort,
+	}
+
+	if q := strings.TrimSpace(c.QueryParam("q")); q != "" {
+		params.Pattern = sql.NullString{String: likePattern(q), Valid: true}
+	}
+
+	if sort := c.QueryParam("sort"); sort != "" {
+		if !validSort(sort, sorts) {
+			return nil, errors.Errorf("invalid sort %s", sort)
+		}
+		params.Sort = sort
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageSize {
+			return nil, errors.Errorf("limit should be between 1 and %d", maxPageSize)
+		}
+		params.Limit = sql.NullInt32{Int32: int32(n), Valid: true}
+	}
+
+	if value := c.QueryParam("cursor"); value != "" {
+		cur, err := decodeCursor(value)
+		if err != nil {
+			return nil, err
+		}
+		if cur.Sort != params.Sort {
+			return nil, errors.Errorf("cursor was made for sort %s", cur.Sort)
+		}
+		params.Cursor = cur
+	}
+
+	return params, nil
+}
+
+// likePattern returns a pattern that matches text containing q, wildcards in
+// q are matched literally.
+func likePattern(q string) string {
+	return "%" + likeEscaper.Replace(q) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func validSort(sort string, sorts []string) bool {
+	for _, s := range sorts {
+		if sort == s || sort == "-"+s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fetchLimit returns the number of rows to fetch, one more than the limit to
+// find out whether there is a next page.
+func (p *listParams) fetchLimit() sql.NullInt32 {
+	if !p.Limit.Valid {
+		return p.Limit
+	}
+
+	return sql.NullInt32{Int32: p.Limit.Int32 + 1, Valid: true}
+}
+
+// hasNextPage returns whether more rows were fetched than fit on the page.
+func (p *listParams) hasNextPage(rows int) bool {
+	return p.Limit.Valid && rows > int(p.Limit.Int32)
+}
+
+// sortField returns the sorted column without the direction.
+func (p *listParams) sortField() string {
+	return strings.TrimPrefix(p.Sort, "-")
+}
+
+func (p *listParams) afterID() sql.NullInt64 {
+	if p.Cursor == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: p.Cursor.ID, Valid: true}
+}
+
+func (p *listParams) afterTime() sql.NullTime {
+	if p.Cursor == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: p.Cursor.Time, Valid: true}
+}
+
+func (p *listParams) afterText() sql.NullString {
+	if p.Cursor == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: p.Cursor.Text, Valid: true}
+}
+
+// nextCursor returns the cursor for the page after the given row. Times are
+// used for timestamp sorts, text for everything else.
+func (p *listParams) nextCursor(id int64, createdAt, updatedAt time.Time, text string) string {
+	cur := cursor{Sort: p.Sort, ID: id}
+
+	switch p.sortField() {
+	case "created_at":
+		cur.Time = createdAt
+	case "updated_at":
+		cur.Time = updatedAt
+	default:
+		cur.Text = text
+	}
+
+	return encodeCursor(cur)
+}
+
+func encodeCursor(cur cursor) string {
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+
+	cur := &cursor{}
+	if err := json.Unmarshal(raw, cur); err != nil {
+		return nil, errors.Wrap(err, "invalid cursor")
+	}
+
+	return cur, nil
+}
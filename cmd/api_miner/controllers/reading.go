@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/readingstats"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+// ReadingAPI tracks reading sessions in texts and reports reading statistics.
+type ReadingAPI interface {
+	StartSession(c echo.Context) error
+	StopSession(c echo.Context) error
+	AddLookup(c echo.Context) error
+	GetStats(c echo.Context) error
+}
+
+type readingAPI struct {
+	psql    *sql.DB
+	queries *postgres.Queries
+}
+
+func NewReadingAPI(psql *sql.DB) ReadingAPI {
+	return &readingAPI{
+		psql:    psql,
+		queries: postgres.New(psql),
+	}
+}
+
+// StartSession starts a reading session at the current reading position of a
+// text.
+func (api *readingAPI) StartSession(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	text, err := api.queries.GetText(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	sessionID, err := api.queries.StartReadingSession(ctx, postgres.StartReadingSessionParams{
+		TextID:        sql.NullInt64{Int64: text.ID, Valid: true},
+		LanguageCode:  text.LanguageCode,
+		StartPosition: text.LastPosition,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, sessionID)
+}
+
+// StopSession ends a reading session at the given position, which becomes
+// the reading position of the text when it's further along. The characters
+// read are counted from the lines between the start and end position unless
+// the reader sends them.
+func (api *readingAPI) StopSession(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &StopReadingSessionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	session, err := qtx.GetReadingSession(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if session.EndedAt.Valid {
+		return c.NoContent(http.StatusConflict)
+	}
+
+	charactersRead := 0
+	if req.CharactersRead != nil {
+		charactersRead = *req.CharactersRead
+	}
+
+	// The text is gone when it was deleted during the session
+	if session.TextID.Valid {
+		text, err := qtx.GetText(ctx, session.TextID.Int64)
+		if err != nil && errors.Cause(err) != sql.ErrNoRows {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		if err == nil {
+			if req.CharactersRead == nil {
+				charactersRead = readingstats.CharactersBetween(text.Content, int(session.StartPosition), req.Position)
+			}
+
+			err = qtx.UpdateReadingPositionOfText(ctx, postgres.UpdateReadingPositionOfTextParams{
+				ID:           text.ID,
+				LastPosition: int32(req.Position),
+			})
+			if err != nil {
+				log.Println("could not process request:", err)
+				return c.NoContent(http.StatusInternalServerError)
+			}
+		}
+	}
+
+	_, err = qtx.StopReadingSession(ctx, postgres.StopReadingSessionParams{
+		EndPosition:    sql.NullInt32{Int32: int32(req.Position), Valid: true},
+		CharactersRead: int32(charactersRead),
+		Lookups:        int32(req.Lookups),
+		ID:             id,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+type StopReadingSessionRequest struct {
+	Position int `json:"position"`
+	// Counted from the text when left out
+	CharactersRead *int `json:"characters_read"`
+	// Added to the lookups that were reported during the session
+	Lookups int `json:"lookups"`
+}
+
+func (req *StopReadingSessionRequest) Validate() error {
+	if req.Position < 0 {
+		return errors.Errorf("position can't be negative")
+	}
+
+	if req.CharactersRead != nil && *req.CharactersRead < 0 {
+		return errors.Errorf("characters_read can't be negative")
+	}
+
+	if req.Lookups < 0 {
+		return errors.Errorf("lookups can't be negative")
+	}
+
+	return nil
+}
+
+// AddLookup counts a dictionary lookup in an ongoing reading session.
+func (api *readingAPI) AddLookup(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	updated, err := api.queries.AddReadingSessionLookup(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	// The session doesn't exist or was already stopped
+	if updated == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+type ReadingStatsResponse struct {
+	readingstats.Stats
+	ReadingSessions []ReadingSession `json:"reading_sessions"`
+}
+
+type ReadingSession struct {
+	ID                  int64      `json:"id"`
+	TextID              *int64     `json:"text_id"`
+	Title               string     `json:"title,omitempty"`
+	StartedAt           time.Time  `json:"started_at"`
+	EndedAt             *time.Time `json:"ended_at"`
+	Minutes             float64    `json:"minutes"`
+	CharactersRead      int        `json:"characters_read"`
+	CharactersPerMinute float64    `json:"characters_per_minute"`
+	Lookups             int        `json:"lookups"`
+	WordsMined          int        `json:"words_mined"`
+}
+
+// GetStats reports the reading speed, streaks and characters read per day in
+// a language. Words mined are the pending cards of the session's text that
+// were created while it was running.
+// The totals can be limited to sessions started between `from` and `to`,
+// streaks always cover all sessions.
+func (api *readingAPI) GetStats(c echo.Context) error {
+	languageCode := c.QueryParam("language_code")
+	if languageCode == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	from, err := parseDateParam(c.QueryParam("from"))
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	to, err := parseDateParam(c.QueryParam("to"))
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := api.queries.ListReadingSessions(c.Request().Context(), languageCode)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	all := []readingstats.Session{}
+	inRange := []readingstats.Session{}
+	res := &ReadingStatsResponse{ReadingSessions: []ReadingSession{}}
+
+	for _, row := range rows {
+		session := readingstats.Session{
+			StartedAt:      row.StartedAt,
+			EndedAt:        nullTime(row.EndedAt),
+			CharactersRead: int(row.CharactersRead),
+			Lookups:        int(row.Lookups),
+			WordsMined:     int(row.WordsMined),
+		}
+		all = append(all, session)
+
+		if (from.Valid && row.StartedAt.Before(from.Time)) || (to.Valid && !row.StartedAt.Before(to.Time)) {
+			continue
+		}
+		inRange = append(inRange, session)
+
+		res.ReadingSessions = append(res.ReadingSessions, ReadingSession{
+			ID:                  row.ID,
+			TextID:              nullInt64(row.TextID),
+			Title:               row.Title.String,
+			StartedAt:           row.StartedAt,
+			EndedAt:             session.EndedAt,
+			Minutes:             session.Minutes(),
+			CharactersRead:      session.CharactersRead,
+			CharactersPerMinute: readingstats.CharactersPerMinute(session.CharactersRead, session.Minutes()),
+			Lookups:             session.Lookups,
+			WordsMined:          session.WordsMined,
+		})
+	}
+
+	now := time.Now().UTC()
+	res.Stats = readingstats.Summarize(inRange, now)
+	res.CurrentStreak, res.LongestStreak = readingstats.Streaks(all, now)
+
+	return c.JSON(http.StatusOK, res)
+}
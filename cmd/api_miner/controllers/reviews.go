@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/srs"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+type ReviewsAPI interface {
+	DueReviews(c echo.Context) error
+	Review(c echo.Context) error
+	ReviewLogs(c echo.Context) error
+}
+
+type reviewsAPI struct {
+	psql    *sql.DB
+	queries *postgres.Queries
+}
+
+func NewReviewsAPI(psql *sql.DB) ReviewsAPI {
+	return &reviewsAPI{
+		psql:    psql,
+		queries: postgres.New(psql),
+	}
+}
+
+const defaultReviewLimit = 50
+
+// DueReviews lists the cards of a language that are due, cards that were
+// never reviewed come after the cards that are due.
+func (api *reviewsAPI) DueReviews(c echo.Context) error {
+	languageCode := c.QueryParam("language_code")
+	if languageCode == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	limit := defaultReviewLimit
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			return c.NoContent(http.StatusBadRequest)
+		}
+	}
+
+	ctx := c.Request().Context()
+	now := time.Now()
+
+	rows, err := api.queries.ListDueReviews(ctx, postgres.ListDueReviewsParams{
+		LanguageCode: languageCode,
+		Now:          now,
+		Limit:        int32(limit),
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	counts, err := api.queries.CountDueReviews(ctx, postgres.CountDueReviewsParams{
+		LanguageCode: languageCode,
+		Now:          now,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &DueReviewsResponse{
+		Reviews: make([]DueReview, len(rows)),
+		New:     counts.NewCards,
+		Due:     counts.DueCards,
+	}
+
+	for i, row := range rows {
+		res.Reviews[i] = DueReview{
+			Card: Card{
+				ID:           row.ID,
+				LanguageCode: row.LanguageCode,
+				Token:        row.Token,
+				Meta:         row.Meta,
+			},
+			Repetitions: int(row.Repetitions),
+			Interval:    int(row.IntervalDays),
+			EaseFactor:  row.EaseFactor,
+			DueAt:       nullTime(row.DueAt),
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type DueReviewsResponse struct {
+	Reviews []DueReview `json:"reviews"`
+	New     int64       `json:"new"`
+	Due     int64       `json:"due"`
+}
+
+type DueReview struct {
+	Card        Card       `json:"card"`
+	Repetitions int        `json:"repetitions"`
+	Interval    int        `json:"interval"`
+	EaseFactor  float64    `json:"ease_factor"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+}
+
+// Review grades a card and schedules its next review. The rating of the word
+// token is updated so known-word coverage follows the reviews.
+func (api *reviewsAPI) Review(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("card_id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &ReviewRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+	now := time.Now()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	card, err := qtx.GetCardForUpdate(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	state := srs.New(now)
+
+	review, err := qtx.GetReview(ctx, id)
+	switch {
+	case err == nil:
+		state = srs.State{
+			Repetitions: int(review.Repetitions),
+			Interval:    int(review.IntervalDays),
+			EaseFactor:  review.EaseFactor,
+			Due:         review.DueAt,
+		}
+	case errors.Cause(err) != sql.ErrNoRows:
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	state, err = srs.Review(state, *req.Grade, now)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := qtx.SaveReview(ctx, postgres.SaveReviewParams{
+		CardID:       id,
+		Repetitions:  int32(state.Repetitions),
+		IntervalDays: int32(state.Interval),
+		EaseFactor:   state.EaseFactor,
+		DueAt:        state.Due,
+	}); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := qtx.CreateReviewLog(ctx, postgres.CreateReviewLogParams{
+		CardID:       id,
+		Grade:        int16(*req.Grade),
+		Repetitions:  int32(state.Repetitions),
+		IntervalDays: int32(state.Interval),
+		EaseFactor:   state.EaseFactor,
+		DueAt:        state.Due,
+	}); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	rating, err := updateWordTokenRating(ctx, qtx, card.LanguageCode, card.Token)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusOK, ReviewResponse{
+		Repetitions: state.Repetitions,
+		Interval:    state.Interval,
+		EaseFactor:  state.EaseFactor,
+		DueAt:       state.Due,
+		Rating:      rating,
+	})
+}
+
+// updateWordTokenRating sets the rating of a word to the rating of its best
+// known card, so reviewing one card of a word that was mined twice doesn't
+// make it look new again. Words that aren't tracked yet are added. The card
+// that was reviewed has to be saved first. The word stays locked until the
+// transaction of q ends, so reviews of its other cards wait for this one.
+func updateWordTokenRating(ctx context.Context, q *postgres.Queries, languageCode, token string) (int, error) {
+	if err := q.LockWordToken(ctx, postgres.LockWordTokenParams{
+		LanguageCode: languageCode,
+		Token:        token,
+	}); err != nil {
+		return 0, errors.Wrap(err, "could not lock word")
+	}
+
+	reviews, err := q.ListReviewsForToken(ctx, postgres.ListReviewsForTokenParams{
+		LanguageCode: languageCode,
+		Token:        token,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	states := make([]srs.State, len(reviews))
+	for i, r := range reviews {
+		states[i] = srs.State{
+			Repetitions: int(r.Repetitions),
+			Interval:    int(r.IntervalDays),
+		}
+	}
+	rating := srs.WordRating(states)
+
+	return rating, q.SaveWordTokenRating(ctx, postgres.SaveWordTokenRatingParams{
+		LanguageCode: languageCode,
+		Token:        token,
+		Rating:       sql.NullInt16{Int16: int16(rating), Valid: true},
+	})
+}
+
+type ReviewRequest struct {
+	Grade *int `json:"grade"`
+}
+
+func (req *ReviewRequest) Validate() error {
+	if req.Grade == nil {
+		return errors.Errorf("grade is required")
+	}
+
+	if *req.Grade < srs.MinGrade || *req.Grade > srs.MaxGrade {
+		return errors.Errorf("grade must be between %d and %d", srs.MinGrade, srs.MaxGrade)
+	}
+
+	return nil
+}
+
+type ReviewResponse struct {
+	Repetitions int       `json:"repetitions"`
+	Interval    int       `json:"interval"`
+	EaseFactor  float64   `json:"ease_factor"`
+	DueAt       time.Time `json:"due_at"`
+	Rating      int       `json:"rating"`
+}
+
+func (api *reviewsAPI) ReviewLogs(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("card_id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := api.queries.ListReviewLogs(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &ReviewLogsResponse{
+		Logs: make([]ReviewLog, len(rows)),
+	}
+
+	for i, row := range rows {
+		res.Logs[i] = ReviewLog{
+			Grade:       int(row.Grade),
+			Repetitions: int(row.Repetitions),
+			Interval:    int(row.IntervalDays),
+			EaseFactor:  row.EaseFactor,
+			DueAt:       row.DueAt,
+			ReviewedAt:  row.ReviewedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type ReviewLogsResponse struct {
+	Logs []ReviewLog `json:"logs"`
+}
+
+type ReviewLog struct {
+	Grade       int       `json:"grade"`
+	Repetitions int       `json:"repetitions"`
+	Interval    int       `json:"interval"`
+	EaseFactor  float64   `json:"ease_factor"`
+	DueAt       time.Time `json:"due_at"`
+	ReviewedAt  time.Time `json:"reviewed_at"`
+}
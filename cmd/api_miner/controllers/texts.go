@@ -1,73 +1,257 @@
 package controllers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
+	"github.com/antonve/language-learning-tools/internal/pkg/extract"
 	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+	"github.com/antonve/language-learning-tools/internal/pkg/textfile"
 )
 
 type TextsAPI interface {
 	CreateText(c echo.Context) error
+	ImportTexts(c echo.Context) error
+	ImportURL(c echo.Context) error
 	ListTexts(c echo.Context) error
+	ListTags(c echo.Context) error
 	GetText(c echo.Context) error
+	UpdateText(c echo.Context) error
+	DeleteText(c echo.Context) error
 	UpdateReadingPosition(c echo.Context) error
+	SetReadingPosition(c echo.Context) error
+	ResetReadingPosition(c echo.Context) error
 }
 
 type textsAPI struct {
-	psql    *sql.DB
-	queries *postgres.Queries
+	psql       *sql.DB
+	queries    *postgres.Queries
+	extractors *extract.Registry
 }
 
-func NewTextsAPI(psql *sql.DB) TextsAPI {
+func NewTextsAPI(psql *sql.DB, extractors *extract.Registry) TextsAPI {
 	return &textsAPI{
-		psql:    psql,
-		queries: postgres.New(psql),
+		psql:       psql,
+		queries:    postgres.New(psql),
+		extractors: extractors,
 	}
 }
 
+// ListTexts lists the texts of a language, newest first. Texts can be
+// searched by title with `q` and filtered by `tag` and `collection_id`,
+// results are paginated with a cursor and sorted by `created_at`,
+// `updated_at` or `title`.
 func (api *textsAPI) ListTexts(c echo.Context) error {
 	languageCode := c.QueryParam("language_code")
 	if languageCode == "" {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	rows, err := api.queries.GetTextsForLanguage(c.Request().Context(), languageCode)
+	list, err := parseListParams(c, []string{"created_at", "updated_at", "title"}, "-created_at")
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	filters := postgres.CountTextsParams{
+		LanguageCode: languageCode,
+		Pattern:      list.Pattern,
+	}
+
+	if tag := strings.TrimSpace(c.QueryParam("tag")); tag != "" {
+		filters.Tag = sql.NullString{String: tag, Valid: true}
+	}
+
+	if collectionID := c.QueryParam("collection_id"); collectionID != "" {
+		id, err := strconv.ParseInt(collectionID, 10, 64)
+		if err != nil {
+			return c.NoContent(http.StatusBadRequest)
+		}
+		filters.CollectionID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	ctx := c.Request().Context()
+
+	rows, err := searchTexts(ctx, api.queries, list, filters)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	total, err := api.queries.CountTexts(ctx, filters)
 	if err != nil {
 		log.Println("could not process request:", err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
-	res := &ListTextsResponse{Texts: []Text{}}
+	res := &ListTextsResponse{Texts: []Text{}, Total: total}
+
+	if list.hasNextPage(len(rows)) {
+		rows = rows[:list.Limit.Int32]
+		last := rows[len(rows)-1]
+		res.NextCursor = list.nextCursor(last.ID, last.CreatedAt, last.UpdatedAt, last.Title)
+	}
 
 	for _, row := range rows {
 		res.Texts = append(res.Texts, Text{
-			ID:           row.ID,
-			LanguageCode: row.LanguageCode,
-			Title:        row.Title,
+			ID:              row.ID,
+			LanguageCode:    row.LanguageCode,
+			Title:           row.Title,
+			CollectionID:    nullInt64(row.CollectionID),
+			CollectionOrder: nullInt32(row.CollectionOrder),
+			Tags:            textTags(row.Tags),
 		})
 	}
 
 	return c.JSON(http.StatusOK, res)
 }
 
+// searchTexts lists the texts matching the filters in the order of the
+// listing. Every order has its own query so it can use its index.
+func searchTexts(ctx context.Context, q *postgres.Queries, list *listParams, f postgres.CountTextsParams) ([]postgres.SearchTextsByCreatedAtRow, error) {
+	byTime := postgres.SearchTextsByCreatedAtParams{
+		LanguageCode: f.LanguageCode,
+		Pattern:      f.Pattern,
+		Tag:          f.Tag,
+		CollectionID: f.CollectionID,
+		AfterID:      list.afterID(),
+		AfterTime:    list.afterTime(),
+		Limit:        list.fetchLimit(),
+	}
+	byTitle := postgres.SearchTextsByTitleParams{
+		LanguageCode: f.LanguageCode,
+		Pattern:      f.Pattern,
+		Tag:          f.Tag,
+		CollectionID: f.CollectionID,
+		AfterID:      list.afterID(),
+		AfterText:    list.afterText(),
+		Limit:        list.fetchLimit(),
+	}
+
+	res := []postgres.SearchTextsByCreatedAtRow{}
+
+	switch list.Sort {
+	case "created_at":
+		return q.SearchTextsByCreatedAt(ctx, byTime)
+	case "-created_at":
+		rows, err := q.SearchTextsByCreatedAtDesc(ctx, postgres.SearchTextsByCreatedAtDescParams(byTime))
+		for _, row := range rows {
+			res = append(res, postgres.SearchTextsByCreatedAtRow(row))
+		}
+		return res, err
+	case "updated_at":
+		rows, err := q.SearchTextsByUpdatedAt(ctx, postgres.SearchTextsByUpdatedAtParams(byTime))
+		for _, row := range rows {
+			res = append(res, postgres.SearchTextsByCreatedAtRow(row))
+		}
+		return res, err
+	case "-updated_at":
+		rows, err := q.SearchTextsByUpdatedAtDesc(ctx, postgres.SearchTextsByUpdatedAtDescParams(byTime))
+		for _, row := range rows {
+			res = append(res, postgres.SearchTextsByCreatedAtRow(row))
+		}
+		return res, err
+	case "title":
+		rows, err := q.SearchTextsByTitle(ctx, byTitle)
+		for _, row := range rows {
+			res = append(res, postgres.SearchTextsByCreatedAtRow(row))
+		}
+		return res, err
+	case "-title":
+		rows, err := q.SearchTextsByTitleDesc(ctx, postgres.SearchTextsByTitleDescParams(byTitle))
+		for _, row := range rows {
+			res = append(res, postgres.SearchTextsByCreatedAtRow(row))
+		}
+		return res, err
+	default:
+		return nil, errors.Errorf("unsupported sort %s", list.Sort)
+	}
+}
+
 type Text struct {
-	ID           int64  `json:"id"`
-	LanguageCode string `json:"language_code"`
-	Title        string `json:"title"`
-	Content      string `json:"content,omitempty"`
-	LastPosition int32  `json:"last_position,omitempty"`
+	ID              int64           `json:"id"`
+	LanguageCode    string          `json:"language_code"`
+	Title           string          `json:"title"`
+	Content         string          `json:"content,omitempty"`
+	LastPosition    int32           `json:"last_position,omitempty"`
+	CollectionID    *int64          `json:"collection_id,omitempty"`
+	CollectionOrder *int32          `json:"collection_order,omitempty"`
+	Tags            []string        `json:"tags"`
+	Ruby            json.RawMessage `json:"ruby,omitempty"`
+}
+
+func textTags(raw json.RawMessage) []string {
+	tags := []string{}
+	if err := json.Unmarshal(raw, &tags); err != nil || tags == nil {
+		return []string{}
+	}
+
+	return tags
+}
+
+func nullInt64(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Int64
+}
+
+func nullInt32(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Int32
+}
+
+// ListTags lists the tags used by the texts of a language.
+func (api *textsAPI) ListTags(c echo.Context) error {
+	languageCode := c.QueryParam("language_code")
+	if languageCode == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	rows, err := api.queries.ListTextTags(c.Request().Context(), languageCode)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	res := &ListTagsResponse{Tags: []Tag{}}
+
+	for _, row := range rows {
+		res.Tags = append(res.Tags, Tag{
+			Tag:       row.Tag,
+			TextCount: row.TextCount,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+type ListTagsResponse struct {
+	Tags []Tag `json:"tags"`
+}
+
+type Tag struct {
+	Tag       string `json:"tag"`
+	TextCount int64  `json:"text_count"`
 }
 
 type ListTextsResponse struct {
-	Texts []Text `json:"texts"`
+	Texts      []Text `json:"texts"`
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func (api *textsAPI) CreateText(c echo.Context) error {
@@ -88,7 +272,18 @@ func (api *textsAPI) CreateText(c echo.Context) error {
 		return c.NoContent(http.StatusBadRequest)
 	}
 
-	id, err := api.queries.CreateText(c.Request().Context(), postgres.CreateTextParams{
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	id, err := qtx.CreateText(ctx, postgres.CreateTextParams{
 		LanguageCode: req.LanguageCode,
 		Title:        req.Title,
 		Content:      req.Content,
@@ -98,13 +293,30 @@ func (api *textsAPI) CreateText(c echo.Context) error {
 		return c.NoContent(http.StatusInternalServerError)
 	}
 
+	if err := setTextTags(ctx, qtx, id, req.Tags); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := setTextCollection(ctx, qtx, id, req.LanguageCode, req.CollectionID); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(textCollectionErrorStatus(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
 	return c.JSON(http.StatusCreated, id)
 }
 
 type CreateTextRequest struct {
-	LanguageCode string `json:"language_code"`
-	Title        string `json:"title"`
-	Content      string `json:"content"`
+	LanguageCode string   `json:"language_code"`
+	Title        string   `json:"title"`
+	Content      string   `json:"content"`
+	Tags         []string `json:"tags"`
+	CollectionID *int64   `json:"collection_id"`
 }
 
 func (req *CreateTextRequest) Validate() error {
@@ -123,6 +335,280 @@ func (req *CreateTextRequest) Validate() error {
 	return nil
 }
 
+// Largest file that can be imported as texts
+const maxTextFileSize = 50 << 20
+
+// ImportTexts creates texts from an EPUB, HTML or plain text file in the
+// body. Every chapter of an EPUB becomes a text in a new collection that is
+// named after the book.
+func (api *textsAPI) ImportTexts(c echo.Context) error {
+	languageCode := c.QueryParam("language_code")
+	if languageCode == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, maxTextFileSize))
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return c.NoContent(http.StatusRequestEntityTooLarge)
+		}
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = textfile.Detect(body)
+	}
+
+	if !textfile.ValidFormat(format) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	book, err := textfile.Read(body, format, c.QueryParam("title"))
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	res := &ImportTextsResponse{TextIDs: []int64{}}
+
+	var collectionID sql.NullInt64
+	if format == textfile.FormatEPUB {
+		id, err := qtx.CreateCollection(ctx, postgres.CreateCollectionParams{
+			LanguageCode: languageCode,
+			Title:        book.Title,
+			Author:       book.Author,
+		})
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		collectionID = sql.NullInt64{Int64: id, Valid: true}
+		res.CollectionID = &id
+	}
+
+	for i, chapter := range book.Chapters {
+		ruby, err := json.Marshal(chapter.Ruby)
+		if err != nil || chapter.Ruby == nil {
+			ruby = []byte("[]")
+		}
+
+		params := postgres.CreateImportedTextParams{
+			LanguageCode: languageCode,
+			Title:        chapter.Title,
+			Content:      chapter.Content(),
+			Ruby:         ruby,
+			CollectionID: collectionID,
+		}
+		if collectionID.Valid {
+			params.CollectionOrder = sql.NullInt32{Int32: int32(i + 1), Valid: true}
+		}
+
+		id, err := qtx.CreateImportedText(ctx, params)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		res.TextIDs = append(res.TextIDs, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+type ImportTextsResponse struct {
+	CollectionID *int64  `json:"collection_id,omitempty"`
+	TextIDs      []int64 `json:"text_ids"`
+}
+
+// ImportURL downloads a page and stores its article as a text. Known sites
+// like Syosetu, Kakuyomu and Aozora Bunko have their own extractor, the
+// article of other pages is guessed.
+func (api *textsAPI) ImportURL(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &ImportURLRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	article, err := api.extractors.Extract(ctx, req.URL)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case extract.ErrInvalidURL, extract.ErrForbiddenAddress:
+			return c.NoContent(http.StatusBadRequest)
+		case extract.ErrNoContent:
+			return c.NoContent(http.StatusUnprocessableEntity)
+		default:
+			return c.NoContent(http.StatusBadGateway)
+		}
+	}
+
+	ruby, err := json.Marshal(article.Ruby)
+	if err != nil || article.Ruby == nil {
+		ruby = []byte("[]")
+	}
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	id, err := qtx.CreateImportedText(ctx, postgres.CreateImportedTextParams{
+		LanguageCode: req.LanguageCode,
+		Title:        article.Title,
+		Content:      article.Content(),
+		Ruby:         ruby,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := setTextTags(ctx, qtx, id, req.Tags); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := setTextCollection(ctx, qtx, id, req.LanguageCode, req.CollectionID); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(textCollectionErrorStatus(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.JSON(http.StatusCreated, &ImportURLResponse{
+		ID:     id,
+		Title:  article.Title,
+		Author: article.Author,
+		URL:    article.URL,
+	})
+}
+
+type ImportURLRequest struct {
+	LanguageCode string   `json:"language_code"`
+	URL          string   `json:"url"`
+	Tags         []string `json:"tags"`
+	CollectionID *int64   `json:"collection_id"`
+}
+
+func (req *ImportURLRequest) Validate() error {
+	if req.LanguageCode == "" {
+		return errors.Errorf("language_code is required")
+	}
+
+	if req.URL == "" {
+		return errors.Errorf("url is required")
+	}
+
+	return nil
+}
+
+type ImportURLResponse struct {
+	ID     int64  `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author,omitempty"`
+	URL    string `json:"url"`
+}
+
+// errInvalidCollection is returned when a text is added to a collection that
+// doesn't exist or belongs to another language.
+var errInvalidCollection = errors.New("invalid collection")
+
+func textCollectionErrorStatus(err error) int {
+	if errors.Cause(err) == errInvalidCollection {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// setTextCollection moves a text to the end of a collection, or out of its
+// collection when no collection is given. Texts that are already in the
+// collection keep their place.
+func setTextCollection(ctx context.Context, q *postgres.Queries, id int64, languageCode string, collectionID *int64) error {
+	params := postgres.SetTextCollectionParams{ID: id}
+
+	if collectionID != nil {
+		collection, err := q.GetCollection(ctx, *collectionID)
+		if err != nil {
+			if errors.Cause(err) == sql.ErrNoRows {
+				return errors.Wrapf(errInvalidCollection, "collection %d does not exist", *collectionID)
+			}
+			return errors.Wrap(err, "could not get collection")
+		}
+
+		if collection.LanguageCode != languageCode {
+			return errors.Wrapf(errInvalidCollection, "collection %d is not a %s collection", *collectionID, languageCode)
+		}
+
+		params.CollectionID = sql.NullInt64{Int64: *collectionID, Valid: true}
+	}
+
+	return errors.Wrap(q.SetTextCollection(ctx, params), "could not set collection")
+}
+
+// setTextTags replaces the tags of a text, tags are trimmed and deduplicated.
+func setTextTags(ctx context.Context, q *postgres.Queries, id int64, tags []string) error {
+	if err := q.DeleteTextTags(ctx, id); err != nil {
+		return errors.Wrap(err, "could not delete tags")
+	}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		if err := q.AddTextTag(ctx, postgres.AddTextTagParams{TextID: id, Tag: tag}); err != nil {
+			return errors.Wrapf(err, "could not add tag %s", tag)
+		}
+	}
+
+	return nil
+}
+
 func (api *textsAPI) GetText(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
@@ -137,22 +623,171 @@ func (api *textsAPI) GetText(c echo.Context) error {
 	row, err := api.queries.GetText(c.Request().Context(), int64(intID))
 	if err != nil {
 		log.Println("could not process request:", err)
-		return c.NoContent(http.StatusInternalServerError)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
 	}
 
 	return c.JSON(http.StatusOK, Text{
-		ID:           row.ID,
-		LanguageCode: row.LanguageCode,
-		Title:        row.Title,
-		Content:      row.Content,
-		LastPosition: row.LastPosition,
+		ID:              row.ID,
+		LanguageCode:    row.LanguageCode,
+		Title:           row.Title,
+		Content:         row.Content,
+		LastPosition:    row.LastPosition,
+		CollectionID:    nullInt64(row.CollectionID),
+		CollectionOrder: nullInt32(row.CollectionOrder),
+		Tags:            textTags(row.Tags),
+		Ruby:            row.Ruby,
 	})
 }
 
+// UpdateText replaces the title, content, tags and collection of a text.
+func (api *textsAPI) UpdateText(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &UpdateTextRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	// Tags and the collection are kept when they're left out
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	text, err := qtx.GetText(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if _, err := qtx.UpdateText(ctx, postgres.UpdateTextParams{
+		Title:     req.Title,
+		Content:   req.Content,
+		LineCount: lineCount(req.Content),
+		ID:        id,
+	}); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if err := reattachAnnotations(ctx, qtx, id, text.Content, req.Content); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if _, ok := fields["tags"]; ok {
+		if err := setTextTags(ctx, qtx, id, req.Tags); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	if _, ok := fields["collection_id"]; ok {
+		if err := setTextCollection(ctx, qtx, id, text.LanguageCode, req.CollectionID); err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(textCollectionErrorStatus(err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// lineCount returns the number of lines of a text like the reader counts
+// them, the reading position is a line and can be one past the last line.
+func lineCount(content string) int32 {
+	return int32(len(strings.FieldsFunc(content, func(c rune) bool {
+		return c == '\n'
+	})))
+}
+
+type UpdateTextRequest struct {
+	Title        string   `json:"title"`
+	Content      string   `json:"content"`
+	Tags         []string `json:"tags"`
+	CollectionID *int64   `json:"collection_id"`
+}
+
+func (req *UpdateTextRequest) Validate() error {
+	if req.Title == "" {
+		return errors.Errorf("title is required")
+	}
+
+	if req.Content == "" {
+		return errors.Errorf("content is required")
+	}
+
+	return nil
+}
+
+func (api *textsAPI) DeleteText(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	deleted, err := api.queries.DeleteText(c.Request().Context(), id)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if deleted == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 type UpdateReadingPositionRequest struct {
 	LastPosition int64 `json:"last_position"`
 }
 
+// UpdateReadingPosition moves the reading position forward, it's ignored when
+// the text was already read further. Use SetReadingPosition to go back.
 func (api *textsAPI) UpdateReadingPosition(c echo.Context) error {
 	id := c.Param("id")
 	if id == "" {
@@ -187,3 +822,57 @@ func (api *textsAPI) UpdateReadingPosition(c echo.Context) error {
 
 	return c.NoContent(http.StatusOK)
 }
+
+// SetReadingPosition sets the reading position of a text, also when it's
+// before the current position.
+func (api *textsAPI) SetReadingPosition(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &UpdateReadingPositionRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if req.LastPosition < 0 {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	return api.setReadingPosition(c, id, int32(req.LastPosition))
+}
+
+// ResetReadingPosition moves the reading position back to the start of a text.
+func (api *textsAPI) ResetReadingPosition(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	return api.setReadingPosition(c, id, 0)
+}
+
+func (api *textsAPI) setReadingPosition(c echo.Context, id int64, position int32) error {
+	updated, err := api.queries.SetReadingPositionOfText(c.Request().Context(), postgres.SetReadingPositionOfTextParams{
+		LastPosition: position,
+		ID:           id,
+	})
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	if updated == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/siongui/gojianfan"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/cardschema"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/segmenter"
+	"github.com/antonve/language-learning-tools/internal/pkg/duplicates"
+	"github.com/antonve/language-learning-tools/internal/pkg/enrich"
+	"github.com/antonve/language-learning-tools/internal/pkg/frequency"
+	"github.com/antonve/language-learning-tools/internal/pkg/srs"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+	"github.com/antonve/language-learning-tools/internal/pkg/vocabulary"
+)
+
+// Statuses of words, words that aren't in word_tokens are unknown
+const (
+	wordStatusUnknown     = "unknown"
+	wordStatusNew         = "new"
+	wordStatusLearned     = "learned"
+	wordStatusComfortable = "comfortable"
+	wordStatusMature      = "mature"
+)
+
+var wordStatuses = map[int16]string{
+	srs.RatingNew:         wordStatusNew,
+	srs.RatingLearned:     wordStatusLearned,
+	srs.RatingComfortable: wordStatusComfortable,
+	srs.RatingMature:      wordStatusMature,
+}
+
+var errUnsupportedLanguage = errors.New("vocabulary isn't supported for this language")
+
+// VocabularyAPI lists the words in a text so they can be studied before
+// reading it.
+type VocabularyAPI interface {
+	GetVocabulary(c echo.Context) error
+	CreateVocabularyCards(c echo.Context) error
+}
+
+type vocabularyAPI struct {
+	psql    *sql.DB
+	queries *postgres.Queries
+
+	// Tokenizers by language code, languages without one are split with
+	// their frequency list
+	tokenizers  map[string]vocabulary.Tokenizer
+	frequencies frequency.Lists
+
+	segmenters map[string]segmenter.Segmenter
+	schemas    *cardschema.Validator
+	enricher   *enrich.Enricher
+	duplicates *duplicates.Normalizer
+}
+
+func NewVocabularyAPI(psql *sql.DB, tokenizers map[string]vocabulary.Tokenizer, frequencies frequency.Lists, segmenters map[string]segmenter.Segmenter, schemas *cardschema.Validator, enricher *enrich.Enricher, duplicates *duplicates.Normalizer) VocabularyAPI {
+	return &vocabularyAPI{
+		psql:        psql,
+		queries:     postgres.New(psql),
+		tokenizers:  tokenizers,
+		frequencies: frequencies,
+		segmenters:  segmenters,
+		schemas:     schemas,
+		enricher:    enricher,
+		duplicates:  duplicates,
+	}
+}
+
+type VocabularyWord struct {
+	Word     string `json:"word"`
+	Form     string `json:"form"`
+	Count    int    `json:"count"`
+	Rank     int    `json:"rank,omitempty"`
+	Status   string `json:"status"`
+	Mined    bool   `json:"mined"`
+	Offset   int    `json:"offset"`
+	Sentence string `json:"sentence"`
+}
+
+type VocabularyResponse struct {
+	Words []VocabularyWord `json:"words"`
+	// Words of languages without an analyser aren't dictionary forms, cards
+	// can only be created for them with the lemma
+	LemmasRequired bool `json:"lemmas_required"`
+}
+
+// textVocabulary is the vocabulary of a text with what's known about every
+// word.
+type textVocabulary struct {
+	words   []vocabulary.Word
+	ratings map[string]int16
+	mined   map[string]bool
+}
+
+func (v *textVocabulary) status(word string) string {
+	rating, ok := v.ratings[word]
+	if !ok {
+		return wordStatusUnknown
+	}
+
+	return wordStatuses[rating]
+}
+
+// GetVocabulary returns the unique words of a text with their count in the
+// text, their rank in the frequency list of the language and whether they
+// are known or mined. Words are ordered by their first occurrence, or with
+// `sort=count` or `sort=frequency`. `unknown=true` leaves out words that are
+// known or mined.
+func (api *vocabularyAPI) GetVocabulary(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	order := c.QueryParam("sort")
+	if order == "" {
+		order = vocabulary.SortFirst
+	}
+	if !vocabulary.ValidSort(order) {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	onlyUnknown := c.QueryParam("unknown") == "true"
+
+	ctx := c.Request().Context()
+
+	text, err := api.queries.GetText(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	v, err := api.vocabulary(ctx, api.queries, text.LanguageCode, text.Content)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(vocabularyErrorStatus(err))
+	}
+
+	vocabulary.Sort(v.words, order)
+
+	res := &VocabularyResponse{
+		Words:          []VocabularyWord{},
+		LemmasRequired: !api.analysed(text.LanguageCode),
+	}
+
+	for _, w := range v.words {
+		status := v.status(w.Word)
+		mined := v.mined[w.Word]
+
+		if onlyUnknown && (status != wordStatusUnknown || mined) {
+			continue
+		}
+
+		res.Words = append(res.Words, VocabularyWord{
+			Word:     w.Word,
+			Form:     w.Form,
+			Count:    w.Count,
+			Rank:     w.Rank,
+			Status:   status,
+			Mined:    mined,
+			Offset:   w.Offset,
+			Sentence: w.Sentence,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// CreateVocabularyCards creates a pending card for every selected word of a
+// text. The card links back to the first occurrence of the word and uses its
+// sentence, definitions are added by the enricher. Cards are created like
+// mined cards: words that are known or likely mined before are skipped, or
+// with `merge=true` the sentence is added to the existing card.
+func (api *vocabularyAPI) CreateVocabularyCards(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	req := &CreateVocabularyCardsRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	if len(req.Words) == 0 {
+		return c.NoContent(http.StatusBadRequest)
+	}
+
+	merge := c.QueryParam("merge") == "true"
+
+	ctx := c.Request().Context()
+
+	tx, err := api.psql.BeginTx(ctx, nil)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+	defer tx.Rollback()
+
+	qtx := api.queries.WithTx(tx)
+
+	text, err := qtx.GetText(ctx, id)
+	if err != nil {
+		log.Println("could not process request:", err)
+
+		switch errors.Cause(err) {
+		case sql.ErrNoRows:
+			return c.NoContent(http.StatusNotFound)
+		default:
+			return c.NoContent(http.StatusInternalServerError)
+		}
+	}
+
+	v, err := api.vocabulary(ctx, qtx, text.LanguageCode, text.Content)
+	if err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(vocabularyErrorStatus(err))
+	}
+
+	words := map[string]vocabulary.Word{}
+	for _, w := range v.words {
+		words[w.Word] = w
+	}
+
+	res := &CreateVocabularyCardsResponse{
+		CardIDs:       []int64{},
+		MergedCardIDs: []int64{},
+		Skipped:       []string{},
+	}
+	created := []string{}
+
+	for i, word := range req.Words {
+		w, ok := words[word]
+		if !ok {
+			log.Println("could not process request: word isn't in the text:", word)
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		// Without an analyser inflected words are split up, so the card
+		// can't be made for the word that was found
+		token := word
+		if !api.analysed(text.LanguageCode) {
+			token = strings.TrimSpace(req.Lemmas[word])
+			if token == "" {
+				log.Println("could not process request: lemma is required for word:", word)
+				return c.NoContent(http.StatusUnprocessableEntity)
+			}
+		}
+
+		if v.mined[token] || v.status(token) != wordStatusUnknown {
+			res.Skipped = append(res.Skipped, word)
+			continue
+		}
+
+		meta, err := json.Marshal(map[string]interface{}{
+			"sentence": map[string]string{
+				"chapter": text.Title,
+				"line":    w.Sentence,
+			},
+			"highlight": w.Form,
+		})
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		if errs := api.schemas.Validate(text.LanguageCode, meta); len(errs) > 0 {
+			return c.JSON(http.StatusBadRequest, newValidationErrorResponse(errs, fmt.Sprintf("words[%d].", i)))
+		}
+
+		card, wordToken, err := findDuplicate(ctx, qtx, api.duplicates, text.LanguageCode, token, meta)
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		switch {
+		case card != nil && merge:
+			if err := mergeIntoCard(ctx, qtx, api.duplicates, card, meta); err != nil {
+				log.Println("could not process request:", err)
+				return c.NoContent(http.StatusInternalServerError)
+			}
+
+			res.MergedCardIDs = append(res.MergedCardIDs, card.ID)
+			continue
+		case card != nil, wordToken != "" && !merge:
+			res.Skipped = append(res.Skipped, word)
+			continue
+		}
+
+		cardID, err := createCard(ctx, qtx, api.duplicates, api.enricher, postgres.CreatePendingCardParams{
+			LanguageCode: text.LanguageCode,
+			Token:        token,
+			Meta:         meta,
+			TextID:       sql.NullInt64{Int64: text.ID, Valid: true},
+			TextOffset:   sql.NullInt32{Int32: int32(w.Offset), Valid: true},
+		})
+		if err != nil {
+			log.Println("could not process request:", err)
+			return c.NoContent(http.StatusInternalServerError)
+		}
+
+		// Selecting a word twice only creates one card
+		v.mined[token] = true
+		res.CardIDs = append(res.CardIDs, cardID)
+		created = append(created, token)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Println("could not process request:", err)
+		return c.NoContent(http.StatusInternalServerError)
+	}
+
+	api.enricher.Notify()
+
+	if seg, ok := api.segmenters[text.LanguageCode]; ok {
+		for _, word := range created {
+			seg.AddWord(word)
+		}
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+type CreateVocabularyCardsRequest struct {
+	Words []string `json:"words"`
+	// Dictionary forms of the words chosen by the user, required for
+	// languages without an analyser
+	Lemmas map[string]string `json:"lemmas"`
+}
+
+type CreateVocabularyCardsResponse struct {
+	CardIDs       []int64 `json:"card_ids"`
+	MergedCardIDs []int64 `json:"merged_card_ids"`
+	// Words that are known or were mined before
+	Skipped []string `json:"skipped"`
+}
+
+// vocabulary extracts the words of a text and looks up which are known or
+// mined and their frequency rank.
+func (api *vocabularyAPI) vocabulary(ctx context.Context, q *postgres.Queries, languageCode, content string) (*textVocabulary, error) {
+	tokens, err := q.ListTokenRatingsForLanguage(ctx, languageCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list word tokens")
+	}
+
+	cards, err := q.ListCardTokensForLanguage(ctx, languageCode)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list card tokens")
+	}
+
+	v := &textVocabulary{
+		ratings: map[string]int16{},
+		mined:   map[string]bool{},
+	}
+
+	for _, t := range tokens {
+		// Keep the best rating when a word is tracked more than once
+		if rating, ok := v.ratings[t.Token]; !ok || t.Rating.Int16 > rating {
+			v.ratings[t.Token] = t.Rating.Int16
+		}
+	}
+
+	for _, token := range cards {
+		v.mined[token] = true
+	}
+
+	list := api.frequencies[languageCode]
+
+	tokenizer, ok := api.tokenizers[languageCode]
+	if !ok && list != nil {
+		tokenizer = vocabulary.Dictionary(func(word string) bool {
+			_, known := v.ratings[word]
+			return known || v.mined[word] || list.Has(word)
+		})
+	}
+	if tokenizer == nil {
+		return nil, errors.Wrap(errUnsupportedLanguage, languageCode)
+	}
+
+	v.words = vocabulary.Extract(content, tokenizer)
+
+	for i, w := range v.words {
+		spellings := []string{w.Word}
+		// Frequency lists of Chinese are often in simplified characters
+		if languageCode == "zho" || languageCode == "yue" {
+			spellings = append(spellings, gojianfan.T2S(w.Word))
+		}
+
+		v.words[i].Rank = list.Rank(spellings...)
+	}
+
+	return v, nil
+}
+
+// analysed reports whether words of a language are found by an analyser,
+// otherwise the words are split by a frequency list and inflected words are
+// split up.
+func (api *vocabularyAPI) analysed(languageCode string) bool {
+	_, ok := api.tokenizers[languageCode]
+	return ok
+}
+
+func vocabularyErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case errUnsupportedLanguage:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
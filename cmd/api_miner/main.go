@@ -1,19 +1,42 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
+	"time"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jcramb/cedict"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
 	"github.com/antonve/language-learning-tools/cmd/api_miner/controllers"
+	"github.com/antonve/language-learning-tools/internal/pkg/anki"
+	"github.com/antonve/language-learning-tools/internal/pkg/blobstore"
+	"github.com/antonve/language-learning-tools/internal/pkg/cardimage"
+	"github.com/antonve/language-learning-tools/internal/pkg/cardschema"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/cantodict"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/hanzi"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/levels"
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/segmenter"
 	"github.com/antonve/language-learning-tools/internal/pkg/corpus"
+	"github.com/antonve/language-learning-tools/internal/pkg/duplicates"
+	"github.com/antonve/language-learning-tools/internal/pkg/enrich"
+	"github.com/antonve/language-learning-tools/internal/pkg/extract"
+	"github.com/antonve/language-learning-tools/internal/pkg/frequency"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/analyser"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/compound"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+	"github.com/antonve/language-learning-tools/internal/pkg/goo"
 	"github.com/antonve/language-learning-tools/internal/pkg/gtranslate"
+	"github.com/antonve/language-learning-tools/internal/pkg/jisho"
 	"github.com/antonve/language-learning-tools/internal/pkg/persistedcache"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+	"github.com/antonve/language-learning-tools/internal/pkg/vocabulary"
+	"github.com/antonve/language-learning-tools/internal/pkg/zdic"
 	"github.com/labstack/gommon/log"
 )
 
@@ -39,25 +62,84 @@ func main() {
 	e.POST("/zh_TW/cedict", api.Chinese().Cedict)
 	e.GET("/zh_TW/zdic/:token", api.Chinese().Zdic)
 	e.POST("/zh_TW/text-analyse", api.Chinese().TextAnalyse)
+	e.GET("/zh_TW/level/:token", api.Chinese().Level)
+	e.GET("/zh_TW/texts/:id/levels", api.Chinese().TextLevels)
+	e.GET("/zh_TW/hanzi/:char", api.Chinese().Hanzi)
+	e.GET("/zh_TW/hanzi/:char/containing", api.Chinese().HanziContaining)
+	e.GET("/zh_TW/segmentation/entries", api.Chinese().ListSegmentationEntries)
+	e.POST("/zh_TW/segmentation/entries", api.Chinese().AddSegmentationEntry)
+	e.DELETE("/zh_TW/segmentation/entries/:word", api.Chinese().RemoveSegmentationEntry)
+	e.POST("/zh_TW/segmentation/reload", api.Chinese().ReloadSegmentation)
+
+	e.POST("/zh_HK/cantodict", api.Cantonese().CantoDict)
+	e.POST("/zh_HK/text-analyse", api.Cantonese().TextAnalyse)
 
 	e.GET("/de/lemma/:token", api.German().Lemma)
+	e.POST("/de/lemmas", api.German().SentenceLemmas)
+	e.GET("/de/word/:lemma", api.German().Word)
+	e.GET("/de/split/:token", api.German().Split)
+	e.POST("/de/text-analyse", api.German().TextAnalyse)
 
 	e.POST("/ocr", api.CloudVision().OCR)
 	e.POST("/detect-texts", api.CloudVision().DetectTexts)
 
 	e.GET("/pending_cards", api.Mining().ListPendingCards)
+	e.GET("/pending_cards/export.apkg", api.Mining().ExportPackage)
+	e.GET("/pending_cards/export", api.Mining().ExportCards)
+	e.POST("/pending_cards/import", api.Mining().ImportCards)
 	e.POST("/pending_cards", api.Mining().CreatePendingCard)
 	e.PUT("/pending_cards/:id", api.Mining().UpdateCard)
 	e.GET("/pending_cards/:id/image", api.Mining().CardImage)
+	e.GET("/pending_cards/:id/thumbnail", api.Mining().CardThumbnail)
+	e.DELETE("/pending_cards/:id", api.Mining().DeleteCard)
 	e.POST("/pending_cards/:id/mark", api.Mining().MarkCardAsExported)
+	e.POST("/pending_cards/:id/unmark", api.Mining().UnmarkCardAsExported)
+	e.POST("/pending_cards/:id/archive", api.Mining().ArchiveCard)
+	e.POST("/pending_cards/:id/unarchive", api.Mining().UnarchiveCard)
+	e.GET("/pending_cards/:id/events", api.Mining().CardEvents)
+	e.GET("/pending_cards/:id/enrichment", api.Mining().CardEnrichment)
+	e.POST("/pending_cards/:id/enrich", api.Mining().EnrichCard)
+
+	e.GET("/card_schemas/:lang", api.Mining().CardSchema)
 
 	e.GET("/texts", api.Texts().ListTexts)
 	e.POST("/texts", api.Texts().CreateText)
+	e.POST("/texts/import", api.Texts().ImportTexts)
+	e.POST("/texts/import-url", api.Texts().ImportURL)
+	e.GET("/texts/tags", api.Texts().ListTags)
 	e.GET("/texts/:id", api.Texts().GetText)
+	e.PUT("/texts/:id", api.Texts().UpdateText)
+	e.DELETE("/texts/:id", api.Texts().DeleteText)
 	e.POST("/texts/:id/last_position", api.Texts().UpdateReadingPosition)
+	e.PUT("/texts/:id/last_position", api.Texts().SetReadingPosition)
+	e.DELETE("/texts/:id/last_position", api.Texts().ResetReadingPosition)
+
+	e.GET("/collections", api.Collections().ListCollections)
+	e.POST("/collections", api.Collections().CreateCollection)
+	e.GET("/collections/:id", api.Collections().GetCollection)
+	e.PUT("/collections/:id", api.Collections().UpdateCollection)
+	e.DELETE("/collections/:id", api.Collections().DeleteCollection)
+	e.PUT("/collections/:id/texts", api.Collections().OrderCollection)
+
+	e.GET("/texts/:id/annotations", api.Annotations().ListAnnotations)
+	e.POST("/texts/:id/annotations", api.Annotations().CreateAnnotation)
+	e.PUT("/texts/:id/annotations/:annotation_id", api.Annotations().UpdateAnnotation)
+	e.DELETE("/texts/:id/annotations/:annotation_id", api.Annotations().DeleteAnnotation)
+
+	e.GET("/texts/:id/vocabulary", api.Vocabulary().GetVocabulary)
+	e.POST("/texts/:id/vocabulary/cards", api.Vocabulary().CreateVocabularyCards)
+
+	e.POST("/texts/:id/sessions", api.Reading().StartSession)
+	e.POST("/reading_sessions/:id/stop", api.Reading().StopSession)
+	e.POST("/reading_sessions/:id/lookups", api.Reading().AddLookup)
+	e.GET("/stats/reading", api.Reading().GetStats)
 
 	e.POST("/translate", api.Translation().Translate)
 
+	e.GET("/reviews/due", api.Reviews().DueReviews)
+	e.POST("/reviews/:card_id", api.Reviews().Review)
+	e.GET("/reviews/:card_id/logs", api.Reviews().ReviewLogs)
+
 	e.Logger.Fatal(e.Start(fmt.Sprintf(":%d", api.Config().Port)))
 }
 
@@ -70,6 +152,24 @@ type Config struct {
 		SSLMode  string `valid:"required"`
 	}
 
+	Jieba struct {
+		UserDictionary          string `default:"/app/out/jieba/zh_TW.user.dict.utf8"`
+		CantoneseUserDictionary string `default:"/app/out/jieba/zh_HK.user.dict.utf8"`
+	}
+
+	// Pending cards are pushed to AnkiConnect when a URL is configured
+	Anki struct {
+		ConnectURL   string
+		Languages    []string      `default:"zho,yue,jpn,deu"`
+		SyncInterval time.Duration `default:"1m"`
+		MaxBackoff   time.Duration `default:"30m"`
+	}
+
+	// Card images and thumbnails are stored by content hash
+	Blobs struct {
+		Path string `default:"/app/out/blobs"`
+	}
+
 	Port int `valid:"required"`
 }
 
@@ -77,11 +177,17 @@ type API interface {
 	Corpus() controllers.CorpusAPI
 	Japanese() controllers.JapaneseAPI
 	Chinese() controllers.ChineseAPI
+	Cantonese() controllers.CantoneseAPI
 	German() controllers.GermanAPI
 	Mining() controllers.MiningAPI
 	CloudVision() controllers.CloudVisionAPI
 	Texts() controllers.TextsAPI
+	Collections() controllers.CollectionsAPI
+	Annotations() controllers.AnnotationsAPI
+	Reading() controllers.ReadingAPI
+	Vocabulary() controllers.VocabularyAPI
 	Translation() controllers.TranslateAPI
+	Reviews() controllers.ReviewsAPI
 
 	Config() Config
 }
@@ -92,11 +198,17 @@ type api struct {
 	corpus      controllers.CorpusAPI
 	japanese    controllers.JapaneseAPI
 	chinese     controllers.ChineseAPI
+	cantonese   controllers.CantoneseAPI
 	german      controllers.GermanAPI
 	mining      controllers.MiningAPI
 	cloudvision controllers.CloudVisionAPI
 	texts       controllers.TextsAPI
+	collections controllers.CollectionsAPI
+	annotations controllers.AnnotationsAPI
+	reading     controllers.ReadingAPI
+	vocabulary  controllers.VocabularyAPI
 	translation controllers.TranslateAPI
+	reviews     controllers.ReviewsAPI
 }
 
 func NewAPI() API {
@@ -113,25 +225,132 @@ func NewAPI() API {
 		panic(err)
 	}
 
+	cyue, err := corpus.New("/app/out", "yue")
+	if err != nil {
+		panic(err)
+	}
+
 	ocrCache, err := persistedcache.New("/app/out/ocr_cache/")
 	if err != nil {
 		panic(err)
 	}
 
+	zhLevels, err := levels.New("/app/out/levels")
+	if err != nil {
+		panic(err)
+	}
+
+	zhHanzi, err := hanzi.New("/app/out/hanzi")
+	if err != nil {
+		panic(err)
+	}
+
 	psql := initPostgres(cfg)
 
+	zhSegmenter, err := segmenter.New(context.Background(), cfg.Jieba.UserDictionary, minedWords(psql, "zho"))
+	if err != nil {
+		panic(err)
+	}
+
+	cantoDict, err := cantodict.New("/app/out/cantodict")
+	if err != nil {
+		panic(err)
+	}
+
+	yueSegmenter, err := segmenter.New(context.Background(), cfg.Jieba.CantoneseUserDictionary, withWords(minedWords(psql, "yue"), cantoDict.Words()))
+	if err != nil {
+		panic(err)
+	}
+
+	segmenters := map[string]segmenter.Segmenter{
+		"zho": zhSegmenter,
+		"yue": yueSegmenter,
+	}
+
 	translate := gtranslate.NewGTranslate(psql)
 
+	blobs, err := blobstore.NewLocal(cfg.Blobs.Path)
+	if err != nil {
+		panic(err)
+	}
+	images := cardimage.New(blobs)
+
+	schemas, err := cardschema.New()
+	if err != nil {
+		panic(err)
+	}
+
+	cedictDict := cedict.New()
+	deLemmatizer := lemmatizer.NewGermanLemmatizer()
+	deSplitter := compound.New(deLemmatizer)
+
+	// The dictionary might not be imported yet, the analyser then only links
+	// the separable verbs the lemmatizer knows
+	separableVerbs, err := postgres.New(psql).ListGermanSeparableVerbs(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	deAnalyser := analyser.New(deLemmatizer, deSplitter, separableVerbs)
+
+	frequencies, err := frequency.Load("/app/out/frequency")
+	if err != nil {
+		panic(err)
+	}
+
+	tokenizers := map[string]vocabulary.Tokenizer{
+		"zho": vocabulary.Chinese(zhSegmenter),
+		"yue": vocabulary.Chinese(yueSegmenter),
+		"deu": vocabulary.German(deAnalyser),
+	}
+
+	dups := duplicates.New(deLemmatizer)
+	backfilled, err := dups.Backfill(context.Background(), postgres.New(psql))
+	if err != nil {
+		panic(err)
+	}
+	if backfilled > 0 {
+		log.Printf("stored the forms of %d cards", backfilled)
+	}
+
+	enricher := enrich.New(psql, map[string][]enrich.Provider{
+		"jpn": {enrich.Goo(goo.New()), enrich.Jisho(jisho.New()), enrich.Translate(translate)},
+		"zho": {enrich.Zdic(zdic.New()), enrich.Cedict(cedictDict), enrich.Translate(translate)},
+		"yue": {enrich.CantoDict(cantoDict), enrich.Translate(translate)},
+		"deu": {enrich.GermanDictionary(postgres.New(psql), deLemmatizer), enrich.Translate(translate)},
+	}, dups, schemas)
+	go enricher.Run(context.Background())
+
+	if cfg.Anki.ConnectURL != "" {
+		syncer := anki.NewSyncer(
+			anki.NewConnect(cfg.Anki.ConnectURL),
+			controllers.NewAnkiCardStore(psql, images),
+			cfg.Anki.Languages,
+			cfg.Anki.SyncInterval,
+			cfg.Anki.MaxBackoff,
+		)
+		go syncer.Run(context.Background())
+	}
+
 	return &api{
-		config:      cfg,
-		corpus:      controllers.NewCorpusAPI(cjp, czh),
+		config: cfg,
+		corpus: controllers.NewCorpusAPI(map[string]corpus.Corpus{
+			"jp":  cjp,
+			"zh":  czh,
+			"yue": cyue,
+		}),
 		japanese:    controllers.NewJapaneseAPI(),
-		chinese:     controllers.NewChineseAPI(),
-		german:      controllers.NewGermanAPI(),
-		mining:      controllers.NewMiningAPI(psql),
+		chinese:     controllers.NewChineseAPI(psql, cedictDict, zhSegmenter, zhLevels, zhHanzi),
+		cantonese:   controllers.NewCantoneseAPI(cantoDict, yueSegmenter),
+		german:      controllers.NewGermanAPI(psql, deLemmatizer, deSplitter, deAnalyser),
+		mining:      controllers.NewMiningAPI(psql, segmenters, images, schemas, enricher, dups),
 		cloudvision: controllers.NewCloudVisionAPI(ocrCache),
-		texts:       controllers.NewTextsAPI(psql),
+		texts:       controllers.NewTextsAPI(psql, extract.Default()),
+		collections: controllers.NewCollectionsAPI(psql),
+		annotations: controllers.NewAnnotationsAPI(psql),
+		reading:     controllers.NewReadingAPI(psql),
+		vocabulary:  controllers.NewVocabularyAPI(psql, tokenizers, frequencies, segmenters, schemas, enricher, dups),
 		translation: controllers.NewTranslateAPI(translate),
+		reviews:     controllers.NewReviewsAPI(psql),
 	}
 }
 
@@ -153,6 +372,38 @@ func initPostgres(config Config) *sql.DB {
 	return psql
 }
 
+// minedWords returns all words of a language that were mined as a card or
+// that are tracked as a word token.
+func minedWords(psql *sql.DB, languageCode string) segmenter.WordSource {
+	queries := postgres.New(psql)
+
+	return func(ctx context.Context) ([]string, error) {
+		cards, err := queries.ListCardTokensForLanguage(ctx, languageCode)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens, err := queries.ListTokensForLanguage(ctx, languageCode)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(cards, tokens...), nil
+	}
+}
+
+// withWords adds a static list of words to a word source.
+func withWords(source segmenter.WordSource, words []string) segmenter.WordSource {
+	return func(ctx context.Context) ([]string, error) {
+		res, err := source(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(res, words...), nil
+	}
+}
+
 func (api *api) Config() Config {
 	return api.config
 }
@@ -169,6 +420,10 @@ func (api *api) Chinese() controllers.ChineseAPI {
 	return api.chinese
 }
 
+func (api *api) Cantonese() controllers.CantoneseAPI {
+	return api.cantonese
+}
+
 func (api *api) German() controllers.GermanAPI {
 	return api.german
 }
@@ -185,6 +440,26 @@ func (api *api) Texts() controllers.TextsAPI {
 	return api.texts
 }
 
+func (api *api) Collections() controllers.CollectionsAPI {
+	return api.collections
+}
+
+func (api *api) Annotations() controllers.AnnotationsAPI {
+	return api.annotations
+}
+
+func (api *api) Reading() controllers.ReadingAPI {
+	return api.reading
+}
+
+func (api *api) Vocabulary() controllers.VocabularyAPI {
+	return api.vocabulary
+}
+
 func (api *api) Translation() controllers.TranslateAPI {
 	return api.translation
 }
+
+func (api *api) Reviews() controllers.ReviewsAPI {
+	return api.reviews
+}
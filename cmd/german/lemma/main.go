@@ -21,5 +21,7 @@ func main() {
 		return
 	}
 
-	fmt.Println(results)
+	for _, c := range results {
+		fmt.Printf("%s\t%s\t%.2f\n", c.Lemma, c.POS, c.Score)
+	}
 }
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/extract"
 )
 
 func main() {
@@ -127,16 +130,24 @@ func save(path string, chapter Chapter) {
 func normalizeChapter(raw RawChapter) Chapter {
 	c := Chapter{URL: raw.URL, Index: raw.Index}
 
-	doc, err := htmlquery.Parse(strings.NewReader(raw.Body))
+	u, err := url.Parse(raw.URL)
+	if err != nil {
+		panic(err)
+	}
+
+	doc, err := html.Parse(strings.NewReader(raw.Body))
 	if err != nil {
 		panic(err)
 	}
 
-	title := htmlquery.FindOne(doc, "//p[@class=\"novel_subtitle\"]")
-	c.Title = htmlquery.InnerText(title)
+	// Blank lines separate scenes in the text files
+	article, err := extract.Syosetu{KeepBlankLines: true}.Extract(u, doc)
+	if err != nil {
+		panic(err)
+	}
 
-	body := htmlquery.FindOne(doc, "//div[@id=\"novel_honbun\"]")
-	c.Body = htmlquery.InnerText(body)
+	c.Title = article.Title
+	c.Body = article.Content()
 
 	return c
 }
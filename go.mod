@@ -3,6 +3,7 @@ module github.com/antonve/language-learning-tools
 go 1.21
 
 require (
+	cloud.google.com/go/translate v1.10.0
 	cloud.google.com/go/vision v1.2.0
 	github.com/aaaton/golem/v4 v4.0.0
 	github.com/aaaton/golem/v4/dicts/de v1.0.1
@@ -14,9 +15,12 @@ require (
 	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/labstack/echo/v4 v4.6.1
 	github.com/labstack/gommon v0.3.0
+	github.com/mattn/go-sqlite3 v1.14.17
 	github.com/pkg/errors v0.9.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
 	github.com/siongui/gojianfan v0.0.0-20210926212422-2f175ac615de
 	github.com/yanyiwu/gojieba v1.2.0
+	golang.org/x/image v0.14.0
 	golang.org/x/net v0.17.0
 	golang.org/x/sync v0.4.0
 	golang.org/x/text v0.14.0
@@ -27,7 +31,6 @@ require (
 	cloud.google.com/go/compute v1.23.1 // indirect
 	cloud.google.com/go/compute/metadata v0.2.3 // indirect
 	cloud.google.com/go/longrunning v0.5.2 // indirect
-	cloud.google.com/go/translate v1.10.0 // indirect
 	cloud.google.com/go/vision/v2 v2.7.3 // indirect
 	github.com/antchfx/xpath v1.2.0 // indirect
 	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
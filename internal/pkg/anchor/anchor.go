@@ -0,0 +1,164 @@
+package anchor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Anchor is a range of characters in the content of a text, the end is
+// exclusive. Offsets are counted in runes from the start of the whole content,
+// unlike the offsets of ruby which start at every line. A bookmark is an
+// anchor without characters.
+type Anchor struct {
+	Start int
+	End   int
+	// The annotated text when the anchor was created
+	Quote string
+	// The quote couldn't be found after an edit, the offsets are a best guess
+	Orphaned bool
+}
+
+// Valid reports whether an anchor lies within content of the given length.
+func Valid(start, end, length int) bool {
+	return start >= 0 && start <= end && end <= length
+}
+
+// Quote returns the characters of content in a range.
+func Quote(content string, start, end int) string {
+	runes := []rune(content)
+	if !Valid(start, end, len(runes)) {
+		return ""
+	}
+
+	return string(runes[start:end])
+}
+
+// Edit is the part of a text that changed between two versions. Everything
+// before Start and after the old or new end is the same in both versions.
+type Edit struct {
+	Start  int
+	OldEnd int
+	NewEnd int
+}
+
+// Diff finds the part that changed between two versions of a text by
+// stripping the common prefix and suffix.
+func Diff(before, after string) Edit {
+	old := []rune(before)
+	cur := []rune(after)
+
+	prefix := 0
+	for prefix < len(old) && prefix < len(cur) && old[prefix] == cur[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(cur)-prefix && old[len(old)-1-suffix] == cur[len(cur)-1-suffix] {
+		suffix++
+	}
+
+	return Edit{
+		Start:  prefix,
+		OldEnd: len(old) - suffix,
+		NewEnd: len(cur) - suffix,
+	}
+}
+
+// Offset moves a position in the old version to the new version. Positions
+// move with the text that follows them, so text inserted right at a position
+// ends up before it. Positions in the changed part are kept where they were
+// when possible, or moved to the end of the new part when it's shorter.
+func (e Edit) Offset(pos int) int {
+	switch {
+	case pos >= e.OldEnd:
+		return pos + e.NewEnd - e.OldEnd
+	case pos <= e.Start:
+		return pos
+	default:
+		return min(pos, e.NewEnd)
+	}
+}
+
+// EndOffset moves the exclusive end of a range to the new version. Ends stay
+// with the text before them, so text inserted right at an end isn't added to
+// the range.
+func (e Edit) EndOffset(pos int) int {
+	switch {
+	case pos <= e.Start:
+		return pos
+	case pos >= e.OldEnd:
+		return pos + e.NewEnd - e.OldEnd
+	default:
+		return min(pos, e.NewEnd)
+	}
+}
+
+// Reattach moves an anchor to the new version of a text. Anchors are moved
+// along with the text around them when their quote is still there. Otherwise
+// they're attached to the occurrence of their quote closest to where they
+// were. When the quote is gone the anchor is orphaned and its offsets are
+// moved like a bookmark.
+func Reattach(a Anchor, e Edit, content string) Anchor {
+	start := e.Offset(a.Start)
+	moved := Anchor{
+		Start:    start,
+		End:      max(e.EndOffset(a.End), start),
+		Quote:    a.Quote,
+		Orphaned: a.Orphaned,
+	}
+
+	if a.Quote == "" {
+		return moved
+	}
+
+	if Quote(content, moved.Start, moved.End) == a.Quote {
+		moved.Orphaned = false
+		return moved
+	}
+
+	start, ok := closest(content, a.Quote, moved.Start)
+	if !ok {
+		moved.Orphaned = true
+		return moved
+	}
+
+	return Anchor{
+		Start: start,
+		End:   start + utf8.RuneCountInString(a.Quote),
+		Quote: a.Quote,
+	}
+}
+
+// closest returns the character offset of the occurrence of quote that's
+// closest to pos.
+func closest(content, quote string, pos int) (int, bool) {
+	best := -1
+	offset := 0
+
+	for rest := content; ; {
+		i := strings.Index(rest, quote)
+		if i < 0 {
+			break
+		}
+
+		offset += utf8.RuneCountInString(rest[:i])
+		if best < 0 || abs(offset-pos) < abs(best-pos) {
+			best = offset
+		}
+
+		// Occurrences can overlap, continue after the first character
+		_, size := utf8.DecodeRuneInString(rest[i:])
+		rest = rest[i+size:]
+		offset++
+	}
+
+	return best, best >= 0
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,176 @@
+package anchor
+
+import (
+	"testing"
+)
+
+func TestReattach(t *testing.T) {
+	// The anchor is on "cat"
+	const before = "the cat sat"
+	cat := Anchor{Start: 4, End: 7, Quote: "cat"}
+
+	tests := []struct {
+		name   string
+		after  string
+		anchor Anchor
+		want   Anchor
+	}{
+		{
+			name:   "insert before",
+			after:  "so the cat sat",
+			anchor: cat,
+			want:   Anchor{Start: 7, End: 10, Quote: "cat"},
+		},
+		{
+			// The start moves with the text after it
+			name:   "insert at start",
+			after:  "the big cat sat",
+			anchor: cat,
+			want:   Anchor{Start: 8, End: 11, Quote: "cat"},
+		},
+		{
+			// The end stays with the text before it
+			name:   "insert at end",
+			after:  "the cats sat",
+			anchor: cat,
+			want:   Anchor{Start: 4, End: 7, Quote: "cat"},
+		},
+		{
+			name:   "insert inside",
+			after:  "the ca-t sat",
+			anchor: cat,
+			want:   Anchor{Start: 4, End: 8, Quote: "cat", Orphaned: true},
+		},
+		{
+			name:   "insert after",
+			after:  "the cat sat down",
+			anchor: cat,
+			want:   cat,
+		},
+		{
+			name:   "delete before",
+			after:  "cat sat",
+			anchor: cat,
+			want:   Anchor{Start: 0, End: 3, Quote: "cat"},
+		},
+		{
+			name:   "delete up to start",
+			after:  "tcat sat",
+			anchor: cat,
+			want:   Anchor{Start: 1, End: 4, Quote: "cat"},
+		},
+		{
+			name:   "delete from end",
+			after:  "the catat",
+			anchor: cat,
+			want:   cat,
+		},
+		{
+			name:   "delete inside",
+			after:  "the ct sat",
+			anchor: cat,
+			want:   Anchor{Start: 4, End: 6, Quote: "cat", Orphaned: true},
+		},
+		{
+			name:   "delete anchor",
+			after:  "the  sat",
+			anchor: cat,
+			want:   Anchor{Start: 4, End: 4, Quote: "cat", Orphaned: true},
+		},
+		{
+			name:   "replace before",
+			after:  "a cat sat",
+			anchor: cat,
+			want:   Anchor{Start: 2, End: 5, Quote: "cat"},
+		},
+		{
+			name:   "replace at start",
+			after:  "the hat sat",
+			anchor: cat,
+			want:   Anchor{Start: 4, End: 7, Quote: "cat", Orphaned: true},
+		},
+		{
+			name:   "replace inside",
+			after:  "the cut sat",
+			anchor: cat,
+			want:   Anchor{Start: 4, End: 7, Quote: "cat", Orphaned: true},
+		},
+		{
+			name:   "replace after",
+			after:  "the cat ran",
+			anchor: cat,
+			want:   cat,
+		},
+		{
+			// The quote is still in the text but somewhere else
+			name:   "replace anchor",
+			after:  "the dog sat on a cat",
+			anchor: cat,
+			want:   Anchor{Start: 17, End: 20, Quote: "cat"},
+		},
+		{
+			name:   "orphan found again",
+			after:  "the cat sat",
+			anchor: Anchor{Start: 4, End: 6, Quote: "cat", Orphaned: true},
+			want:   cat,
+		},
+		{
+			name:   "bookmark at insert",
+			after:  "the big cat sat",
+			anchor: Anchor{Start: 4, End: 4},
+			want:   Anchor{Start: 8, End: 8},
+		},
+		{
+			name:   "characters",
+			after:  "the 大きい cat sat",
+			anchor: cat,
+			want:   Anchor{Start: 8, End: 11, Quote: "cat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := before
+			if tt.anchor.Orphaned {
+				content = "the ct sat"
+			}
+
+			got := Reattach(tt.anchor, Diff(content, tt.after), tt.after)
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		after  string
+		pos    int
+		offset int
+		end    int
+	}{
+		{name: "insert before", after: "so the cat", pos: 4, offset: 7, end: 7},
+		{name: "insert at", after: "the big cat", pos: 4, offset: 8, end: 4},
+		{name: "insert after", after: "the cat sat", pos: 4, offset: 4, end: 4},
+		{name: "delete before", after: "cat", pos: 4, offset: 0, end: 0},
+		{name: "delete at", after: "the ", pos: 4, offset: 4, end: 4},
+		{name: "delete around", after: "tht", pos: 4, offset: 2, end: 2},
+		{name: "replace at", after: "the dog", pos: 4, offset: 4, end: 4},
+		{name: "replace around", after: "a", pos: 4, offset: 1, end: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Diff("the cat", tt.after)
+
+			if got := e.Offset(tt.pos); got != tt.offset {
+				t.Errorf("Offset(%d) = %d, want %d", tt.pos, got, tt.offset)
+			}
+			if got := e.EndOffset(tt.pos); got != tt.end {
+				t.Errorf("EndOffset(%d) = %d, want %d", tt.pos, got, tt.end)
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package anki
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// Package is a deck with notes that can be written as an .apkg file, which
+// can be imported in any Anki client.
+type Package struct {
+	Deck     string
+	NoteType NoteType
+	Notes    []Note
+}
+
+type NoteType struct {
+	Name      string
+	Fields    []string
+	Templates []Template
+	CSS       string
+}
+
+type Template struct {
+	Name  string
+	Front string
+	Back  string
+}
+
+type Note struct {
+	// GUID identifies a note across exports, importing a note with the same
+	// GUID again updates it instead of creating a duplicate
+	GUID   string
+	Fields map[string]string
+	Tags   []string
+	Media  []Media
+}
+
+type Media struct {
+	Filename string
+	Data     []byte
+
+	// URL of media that hasn't been downloaded yet
+	URL string
+
+	// Field that references the media
+	Field string
+}
+
+// Write writes the package as a zip file with the collection database and the
+// media of all notes.
+func (p *Package) Write(w io.Writer) error {
+	dir, err := os.MkdirTemp("", "apkg")
+	if err != nil {
+		return errors.Wrap(err, "could not create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collection.anki2")
+	if err := p.writeCollection(path); err != nil {
+		return err
+	}
+
+	z := zip.NewWriter(w)
+
+	collection, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "could not read collection")
+	}
+
+	if err := writeZipFile(z, "collection.anki2", collection); err != nil {
+		return err
+	}
+
+	// Media files are stored by index, the media file maps them to their name
+	mediaNames := map[string]string{}
+	i := 0
+	for _, n := range p.Notes {
+		for _, m := range n.Media {
+			if m.Data == nil {
+				continue
+			}
+
+			name := strconv.Itoa(i)
+			mediaNames[name] = m.Filename
+
+			if err := writeZipFile(z, name, m.Data); err != nil {
+				return err
+			}
+			i++
+		}
+	}
+
+	media, err := json.Marshal(mediaNames)
+	if err != nil {
+		return errors.Wrap(err, "could not encode media")
+	}
+
+	if err := writeZipFile(z, "media", media); err != nil {
+		return err
+	}
+
+	return errors.Wrap(z.Close(), "could not write package")
+}
+
+func writeZipFile(z *zip.Writer, name string, data []byte) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "could not add %s to package", name)
+	}
+
+	_, err = f.Write(data)
+	return errors.Wrapf(err, "could not add %s to package", name)
+}
+
+func (p *Package) writeCollection(path string) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return errors.Wrap(err, "could not create collection")
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not create collection")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(schema); err != nil {
+		return errors.Wrap(err, "could not create collection schema")
+	}
+
+	now := time.Now()
+	deckID := stableID(p.Deck)
+	modelID := stableID(p.NoteType.Name)
+
+	models, err := json.Marshal(map[string]interface{}{
+		strconv.FormatInt(modelID, 10): p.NoteType.model(modelID, deckID, now),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not encode note type")
+	}
+
+	decks, err := json.Marshal(map[string]interface{}{
+		"1":                           deck(1, "Default", now),
+		strconv.FormatInt(deckID, 10): deck(deckID, p.Deck, now),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not encode decks")
+	}
+
+	if _, err := tx.Exec(
+		`insert into col values (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		now.Unix(), now.UnixMilli(), now.UnixMilli(), collectionConfig, string(models), string(decks), deckConfig,
+	); err != nil {
+		return errors.Wrap(err, "could not create collection")
+	}
+
+	// Note and card IDs are creation timestamps in milliseconds in Anki
+	id := now.UnixMilli()
+
+	for i, n := range p.Notes {
+		fields := make([]string, len(p.NoteType.Fields))
+		for j, name := range p.NoteType.Fields {
+			fields[j] = n.Fields[name]
+		}
+
+		sortField := stripHTML(fields[0])
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " " + strings.Join(n.Tags, " ") + " "
+		}
+
+		noteID := id + int64(i)
+		if _, err := tx.Exec(
+			`insert into notes values (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			noteID, n.GUID, modelID, now.Unix(), tags, strings.Join(fields, "\x1f"), sortField, checksum(sortField),
+		); err != nil {
+			return errors.Wrap(err, "could not add note")
+		}
+
+		for ord := range p.NoteType.Templates {
+			cardID := id + int64(len(p.Notes)*(ord+1)+i)
+			if _, err := tx.Exec(
+				`insert into cards values (?, ?, ?, ?, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+				cardID, noteID, deckID, ord, now.Unix(), i+1,
+			); err != nil {
+				return errors.Wrap(err, "could not add card")
+			}
+		}
+	}
+
+	return errors.Wrap(tx.Commit(), "could not write collection")
+}
+
+func (t NoteType) model(id, deckID int64, now time.Time) map[string]interface{} {
+	fields := make([]map[string]interface{}, len(t.Fields))
+	for i, name := range t.Fields {
+		fields[i] = map[string]interface{}{
+			"name":   name,
+			"ord":    i,
+			"font":   "Arial",
+			"size":   20,
+			"media":  []string{},
+			"rtl":    false,
+			"sticky": false,
+		}
+	}
+
+	templates := make([]map[string]interface{}, len(t.Templates))
+	required := make([]interface{}, len(t.Templates))
+	for i, tmpl := range t.Templates {
+		templates[i] = map[string]interface{}{
+			"name":  tmpl.Name,
+			"ord":   i,
+			"qfmt":  tmpl.Front,
+			"afmt":  tmpl.Back,
+			"bqfmt": "",
+			"bafmt": "",
+			"did":   nil,
+			"bfont": "",
+			"bsize": 0,
+		}
+
+		// A card is generated when any of the fields on its front is filled in
+		used := []int{}
+		for j, name := range t.Fields {
+			if strings.Contains(tmpl.Front, "{{"+name+"}}") {
+				used = append(used, j)
+			}
+		}
+		required[i] = []interface{}{i, "any", used}
+	}
+
+	return map[string]interface{}{
+		"id":        id,
+		"name":      t.Name,
+		"type":      0,
+		"mod":       now.Unix(),
+		"usn":       -1,
+		"sortf":     0,
+		"did":       deckID,
+		"tmpls":     templates,
+		"flds":      fields,
+		"css":       t.CSS,
+		"latexPre":  latexPre,
+		"latexPost": "\\end{document}",
+		"req":       required,
+		"tags":      []string{},
+		"vers":      []string{},
+	}
+}
+
+func deck(id int64, name string, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               id,
+		"name":             name,
+		"desc":             "",
+		"mod":              now.Unix(),
+		"usn":              -1,
+		"conf":             1,
+		"dyn":              0,
+		"collapsed":        false,
+		"extendNew":        10,
+		"extendRev":        50,
+		"newToday":         []int{0, 0},
+		"revToday":         []int{0, 0},
+		"lrnToday":         []int{0, 0},
+		"timeToday":        []int{0, 0},
+		"browserCollapsed": true,
+	}
+}
+
+// stableID derives an ID from a name so the same deck and note type are
+// reused when importing multiple exports.
+func stableID(name string) int64 {
+	sum := sha1.Sum([]byte(name))
+	return int64(binary.BigEndian.Uint64(sum[:8]) >> 12)
+}
+
+// GUID returns a stable note GUID for a key, e.g. the ID of a card.
+func GUID(key string) string {
+	sum := sha1.Sum([]byte("ankiminer:" + key))
+	return hex.EncodeToString(sum[:5])
+}
+
+// checksum is used by Anki to find duplicates, it's based on the first field.
+func checksum(field string) int64 {
+	sum := sha1.Sum([]byte(field))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func stripHTML(s string) string {
+	return htmlTag.ReplaceAllString(s, "")
+}
+
+const latexPre = "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n" +
+	"\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n"
+
+const collectionConfig = `{"activeDecks":[1],"addToCur":true,"collapseTime":1200,"curDeck":1,"curModel":null,` +
+	`"dueCounts":true,"estTimes":true,"newBury":true,"newSpread":0,"nextPos":1,"sortBackwards":false,` +
+	`"sortType":"noteFld","timeLim":0}`
+
+const deckConfig = `{"1":{"autoplay":true,"dyn":false,"id":1,"maxTaken":60,"mod":0,"name":"Default",` +
+	`"new":{"bury":true,"delays":[1,10],"initialFactor":2500,"ints":[1,4,7],"order":1,"perDay":20,"separate":true},` +
+	`"lapse":{"delays":[10],"leechAction":0,"leechFails":8,"minInt":1,"mult":0},` +
+	`"rev":{"bury":true,"ease4":1.3,"fuzz":0.05,"ivlFct":1,"maxIvl":36500,"minSpace":1,"perDay":100},` +
+	`"replayq":true,"timer":0,"usn":0}}`
+
+const schema = `
+create table col (
+  id integer primary key,
+  crt integer not null,
+  mod integer not null,
+  scm integer not null,
+  ver integer not null,
+  dty integer not null,
+  usn integer not null,
+  ls integer not null,
+  conf text not null,
+  models text not null,
+  decks text not null,
+  dconf text not null,
+  tags text not null
+);
+
+create table notes (
+  id integer primary key,
+  guid text not null,
+  mid integer not null,
+  mod integer not null,
+  usn integer not null,
+  tags text not null,
+  flds text not null,
+  sfld integer not null,
+  csum integer not null,
+  flags integer not null,
+  data text not null
+);
+
+create table cards (
+  id integer primary key,
+  nid integer not null,
+  did integer not null,
+  ord integer not null,
+  mod integer not null,
+  usn integer not null,
+  type integer not null,
+  queue integer not null,
+  due integer not null,
+  ivl integer not null,
+  factor integer not null,
+  reps integer not null,
+  lapses integer not null,
+  left integer not null,
+  odue integer not null,
+  odid integer not null,
+  flags integer not null,
+  data text not null
+);
+
+create table revlog (
+  id integer primary key,
+  cid integer not null,
+  usn integer not null,
+  ease integer not null,
+  ivl integer not null,
+  lastIvl integer not null,
+  factor integer not null,
+  time integer not null,
+  type integer not null
+);
+
+create table graves (
+  usn integer not null,
+  oid integer not null,
+  type integer not null
+);
+
+create index ix_notes_usn on notes (usn);
+create index ix_cards_usn on cards (usn);
+create index ix_revlog_usn on revlog (usn);
+create index ix_cards_nid on cards (nid);
+create index ix_cards_sched on cards (did, queue, due);
+create index ix_revlog_cid on revlog (cid);
+create index ix_notes_csum on notes (csum);
+`
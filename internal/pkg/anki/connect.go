@@ -0,0 +1,207 @@
+package anki
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Connect is a client for the AnkiConnect add-on.
+type Connect interface {
+	DeckNames(ctx context.Context) ([]string, error)
+	CreateDeck(ctx context.Context, name string) error
+	ModelNames(ctx context.Context) ([]string, error)
+	CreateModel(ctx context.Context, noteType NoteType) error
+	StoreMediaFile(ctx context.Context, filename string, data []byte) error
+	CanAddNotes(ctx context.Context, deck string, noteType string, notes []Note) ([]NoteCheck, error)
+	AddNotes(ctx context.Context, deck string, noteType string, notes []Note) ([]*int64, error)
+}
+
+// NoteCheck tells whether a note can be added, the error explains why not.
+type NoteCheck struct {
+	CanAdd bool   `json:"canAdd"`
+	Error  string `json:"error"`
+}
+
+// Duplicate reports whether a note can't be added because it's already in
+// the deck.
+func (c NoteCheck) Duplicate() bool {
+	return !c.CanAdd && strings.Contains(c.Error, "duplicate")
+}
+
+type connect struct {
+	url    string
+	client *http.Client
+}
+
+func NewConnect(url string) Connect {
+	return &connect{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type connectRequest struct {
+	Action  string      `json:"action"`
+	Version int         `json:"version"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type connectResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *string         `json:"error"`
+}
+
+func (c *connect) call(ctx context.Context, action string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(connectRequest{Action: action, Version: 6, Params: params})
+	if err != nil {
+		return errors.Wrapf(err, "could not encode %s request", action)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "could not create %s request", action)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "could not call %s", action)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return errors.Wrapf(err, "could not read %s response", action)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("could not call %s: status %d", action, res.StatusCode)
+	}
+
+	r := connectResponse{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return errors.Wrapf(err, "could not decode %s response", action)
+	}
+
+	if r.Error != nil {
+		return errors.Errorf("%s failed: %s", action, *r.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return errors.Wrapf(json.Unmarshal(r.Result, result), "could not decode %s result", action)
+}
+
+func (c *connect) DeckNames(ctx context.Context) ([]string, error) {
+	res := []string{}
+	err := c.call(ctx, "deckNames", nil, &res)
+
+	return res, err
+}
+
+func (c *connect) CreateDeck(ctx context.Context, name string) error {
+	return c.call(ctx, "createDeck", map[string]interface{}{"deck": name}, nil)
+}
+
+func (c *connect) ModelNames(ctx context.Context) ([]string, error) {
+	res := []string{}
+	err := c.call(ctx, "modelNames", nil, &res)
+
+	return res, err
+}
+
+func (c *connect) CreateModel(ctx context.Context, noteType NoteType) error {
+	templates := make([]map[string]string, len(noteType.Templates))
+	for i, t := range noteType.Templates {
+		templates[i] = map[string]string{
+			"Name":  t.Name,
+			"Front": t.Front,
+			"Back":  t.Back,
+		}
+	}
+
+	return c.call(ctx, "createModel", map[string]interface{}{
+		"modelName":     noteType.Name,
+		"inOrderFields": noteType.Fields,
+		"css":           noteType.CSS,
+		"isCloze":       false,
+		"cardTemplates": templates,
+	}, nil)
+}
+
+func (c *connect) StoreMediaFile(ctx context.Context, filename string, data []byte) error {
+	return c.call(ctx, "storeMediaFile", map[string]interface{}{
+		"filename": filename,
+		"data":     base64.StdEncoding.EncodeToString(data),
+	}, nil)
+}
+
+// CanAddNotes checks whether notes can be added to a deck, e.g. whether
+// they're not a duplicate. Anki fails the whole batch of addNotes when one of
+// the notes is a duplicate, so notes are checked first.
+func (c *connect) CanAddNotes(ctx context.Context, deck string, noteType string, notes []Note) ([]NoteCheck, error) {
+	res := []NoteCheck{}
+	err := c.call(ctx, "canAddNotesWithErrorDetail", map[string]interface{}{"notes": noteParams(deck, noteType, notes)}, &res)
+
+	return res, err
+}
+
+// AddNotes adds notes to a deck. The IDs of notes that couldn't be added, e.g.
+// because they're a duplicate, are nil. Media with data has to be stored
+// first, media that is referenced by URL is downloaded by Anki.
+func (c *connect) AddNotes(ctx context.Context, deck string, noteType string, notes []Note) ([]*int64, error) {
+	res := []*int64{}
+	err := c.call(ctx, "addNotes", map[string]interface{}{"notes": noteParams(deck, noteType, notes)}, &res)
+
+	return res, err
+}
+
+func noteParams(deck string, noteType string, notes []Note) []map[string]interface{} {
+	params := make([]map[string]interface{}, len(notes))
+
+	for i, n := range notes {
+		fields := map[string]string{}
+		for k, v := range n.Fields {
+			fields[k] = v
+		}
+
+		audio := []map[string]interface{}{}
+		for _, m := range n.Media {
+			if m.Data != nil || m.URL == "" {
+				continue
+			}
+
+			// AnkiConnect adds the sound tag to the field itself
+			fields[m.Field] = ""
+			audio = append(audio, map[string]interface{}{
+				"url":      m.URL,
+				"filename": m.Filename,
+				"fields":   []string{m.Field},
+			})
+		}
+
+		params[i] = map[string]interface{}{
+			"deckName":  deck,
+			"modelName": noteType,
+			"fields":    fields,
+			"tags":      n.Tags,
+			"audio":     audio,
+			"options": map[string]interface{}{
+				"allowDuplicate": false,
+				"duplicateScope": "deck",
+			},
+		}
+	}
+
+	return params
+}
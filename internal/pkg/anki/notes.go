@@ -0,0 +1,267 @@
+package anki
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/cardschema"
+)
+
+var ErrUnsupportedLanguage = errors.New("language is not supported")
+
+// Audio larger than this isn't the pronunciation of a word
+const maxMediaSize = 10 << 20
+
+// Card is a mined card that is turned into a note.
+type Card struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	Image        []byte
+}
+
+// Meta is the data the frontend stores for a mined card. Cards from the
+// Chinese and German readers use their own fields, they're used when the
+// common fields are empty.
+type Meta struct {
+	Sentence                 *cardschema.Sentence `json:"sentence"`
+	Reading                  string               `json:"reading"`
+	Zhuyin                   string               `json:"zhuyin"`
+	DefinitionEnglish        string               `json:"definitionEnglish"`
+	DefinitionTargetLanguage string               `json:"definitionTargetLanguage"`
+	AudioURL                 string               `json:"audioUrl"`
+	VocabCard                bool                 `json:"vocabCard"`
+	Highlight                string               `json:"highlight"`
+
+	CardType    string   `json:"card_type"`
+	PinyinTones string   `json:"pinyin_tones"`
+	Meanings    []string `json:"meanings"`
+	Meaning     string   `json:"meaning"`
+}
+
+// Format describes how cards of a language are turned into notes.
+type Format struct {
+	Deck     string
+	NoteType NoteType
+	Tags     []string
+
+	// Fields for the reading and the definition in the target language
+	readingFields   []string
+	definitionField string
+}
+
+var formats = map[string]Format{
+	"zho": newFormat("2. Chinese::Mined", "chinese native with focus word", []string{"Pinyin", "Zhuyin"}, "ChineseDefinition", "chinese"),
+	"yue": newFormat("2. Chinese::Cantonese", "ankiminer_yue", []string{"Jyutping"}, "ChineseDefinition", "cantonese"),
+	"jpn": newFormat("3. Japanese::3. Vocab", "ankiminer_jp", []string{"Reading"}, "JapaneseDefinition", "japanese"),
+	"deu": newFormat("4. German::Mined", "ankiminer_de", nil, "GermanDefinition", "german"),
+}
+
+func newFormat(deck, noteType string, readingFields []string, definitionField, language string) Format {
+	fields := []string{"Expression", "Focus"}
+	fields = append(fields, readingFields...)
+	fields = append(fields, "EnglishDefinition", definitionField, "VocabOnlyCard", "Source", "Audio", "Image")
+
+	back := &strings.Builder{}
+	back.WriteString("{{FrontSide}}\n<hr id=answer>\n{{Focus}}\n")
+	for _, f := range readingFields {
+		back.WriteString("<div>{{" + f + "}}</div>\n")
+	}
+	back.WriteString("<div>{{EnglishDefinition}}</div>\n<div>{{" + definitionField + "}}</div>\n")
+	back.WriteString("{{Audio}}\n<div>{{Image}}</div>\n<div class=source>{{Source}}</div>")
+
+	return Format{
+		Deck: deck,
+		NoteType: NoteType{
+			Name:   noteType,
+			Fields: fields,
+			Templates: []Template{{
+				Name:  "Card 1",
+				Front: "{{#VocabOnlyCard}}{{Focus}}{{/VocabOnlyCard}}{{^VocabOnlyCard}}{{Expression}}{{/VocabOnlyCard}}",
+				Back:  back.String(),
+			}},
+			CSS: ".card { font-size: 24px; text-align: center; }\n.source { font-size: 14px; color: grey; }",
+		},
+		Tags:            []string{"ankiminer", language, "mined", "native"},
+		readingFields:   readingFields,
+		definitionField: definitionField,
+	}
+}
+
+// FormatForLanguage returns the note format for a language code.
+func FormatForLanguage(languageCode string) (Format, error) {
+	f, ok := formats[languageCode]
+	if !ok {
+		return Format{}, ErrUnsupportedLanguage
+	}
+
+	return f, nil
+}
+
+// Note maps the meta of a card to the fields of a note. The source image is
+// included as media, audio is referenced by URL and has to be fetched before
+// writing a package. Media is named after the card, so words with the same
+// spelling don't share their audio.
+func (f Format) Note(card Card) (Note, error) {
+	meta := Meta{}
+	if len(card.Meta) > 0 {
+		if err := json.Unmarshal(card.Meta, &meta); err != nil {
+			return Note{}, errors.Wrapf(err, "could not decode meta of card %d", card.ID)
+		}
+	}
+
+	if meta.Reading == "" {
+		meta.Reading = meta.PinyinTones
+	}
+	if meta.DefinitionEnglish == "" && len(meta.Meanings) > 0 {
+		meta.DefinitionEnglish = strings.Join(meta.Meanings, "\n")
+	}
+	if meta.DefinitionEnglish == "" {
+		meta.DefinitionEnglish = meta.Meaning
+	}
+	if meta.CardType == "vocab" {
+		meta.VocabCard = true
+	}
+
+	n := Note{
+		GUID: GUID(strconv.FormatInt(card.ID, 10)),
+		Fields: map[string]string{
+			"Focus":             html.EscapeString(card.Token),
+			"EnglishDefinition": nl2br(html.EscapeString(meta.DefinitionEnglish)),
+			f.definitionField:   nl2br(html.EscapeString(meta.DefinitionTargetLanguage)),
+		},
+		Tags: append([]string{}, f.Tags...),
+	}
+
+	if meta.Sentence != nil {
+		target := meta.Highlight
+		if target == "" {
+			target = card.Token
+		}
+
+		line := html.EscapeString(meta.Sentence.Line)
+		target = html.EscapeString(target)
+		n.Fields["Expression"] = strings.ReplaceAll(line, target, "<b>"+target+"</b>")
+
+		if meta.Sentence.Series != "" && meta.Sentence.Chapter != "" {
+			n.Fields["Source"] = html.EscapeString(meta.Sentence.Series + " - " + meta.Sentence.Chapter)
+		}
+
+		if meta.Sentence.Series != "" {
+			n.Tags = append(n.Tags, strings.ReplaceAll(meta.Sentence.Series, " ", "_"))
+		}
+	}
+
+	// The first reading field holds the main reading, zhuyin is the only
+	// secondary reading that's stored separately
+	if len(f.readingFields) > 0 {
+		n.Fields[f.readingFields[0]] = html.EscapeString(meta.Reading)
+	}
+	if len(f.readingFields) > 1 {
+		n.Fields[f.readingFields[1]] = html.EscapeString(meta.Zhuyin)
+	}
+
+	if meta.VocabCard {
+		n.Fields["VocabOnlyCard"] = "1"
+	}
+
+	if len(card.Image) > 0 {
+		filename := fmt.Sprintf("ankiminer_%d.%s", card.ID, imageExtension(card.Image))
+		n.Fields["Image"] = fmt.Sprintf(`<img src="%s">`, filename)
+		n.Media = append(n.Media, Media{Filename: filename, Data: card.Image, Field: "Image"})
+	}
+
+	// Older cards have protocol relative zdic URLs
+	if strings.HasPrefix(meta.AudioURL, "//") {
+		meta.AudioURL = "https:" + meta.AudioURL
+	}
+
+	if meta.AudioURL != "" {
+		filename := fmt.Sprintf("ankiminer_%d_audio.mp3", card.ID)
+		n.Fields["Audio"] = "[sound:" + filename + "]"
+		n.Media = append(n.Media, Media{Filename: filename, URL: meta.AudioURL, Field: "Audio"})
+	}
+
+	return n, nil
+}
+
+// FetchMedia downloads media that is only referenced by URL. Media that can't
+// be downloaded is left out, the note is still useful without it. The media
+// that's left out is returned so it can be reported. URLs come from the meta
+// of cards, so the client should only connect to public addresses, see
+// publichttp.Client.
+func (p *Package) FetchMedia(client *http.Client) []Media {
+	missing := []Media{}
+
+	for i := range p.Notes {
+		media := []Media{}
+
+		for _, m := range p.Notes[i].Media {
+			if m.Data == nil && m.URL != "" {
+				data, err := download(client, m.URL)
+				if err != nil {
+					log.Println("could not download media:", err)
+					missing = append(missing, m)
+					p.Notes[i].Fields[m.Field] = ""
+					continue
+				}
+				m.Data = data
+			}
+
+			media = append(media, m)
+		}
+
+		p.Notes[i].Media = media
+	}
+
+	return missing
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download %s", url)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not download %s: status %d", url, res.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not download %s", url)
+	}
+
+	if len(data) > maxMediaSize {
+		return nil, errors.Errorf("could not download %s: larger than %d bytes", url, maxMediaSize)
+	}
+
+	return data, nil
+}
+
+func imageExtension(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return "jpg"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return "png"
+	}
+}
+
+func nl2br(s string) string {
+	return strings.ReplaceAll(s, "\n", "<br />")
+}
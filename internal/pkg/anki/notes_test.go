@@ -0,0 +1,114 @@
+package anki
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/publichttp"
+)
+
+func TestNoteEscapesMeta(t *testing.T) {
+	format, err := FormatForLanguage("zho")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note, err := format.Note(Card{
+		ID:    1,
+		Token: "學生",
+		Meta: []byte(`{
+			"sentence": {"line": "他是<i>學生</i>"},
+			"reading": "xué<script>",
+			"zhuyin": "ㄒㄩㄝˊ&",
+			"definitionEnglish": "student\n<b>pupil</b>",
+			"definitionTargetLanguage": "在学校读书的人<br>"
+		}`),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"Expression":        "他是&lt;i&gt;<b>學生</b>&lt;/i&gt;",
+		"Pinyin":            "xué&lt;script&gt;",
+		"Zhuyin":            "ㄒㄩㄝˊ&amp;",
+		"EnglishDefinition": "student<br />&lt;b&gt;pupil&lt;/b&gt;",
+		"ChineseDefinition": "在学校读书的人&lt;br&gt;",
+	}
+
+	for field, value := range want {
+		if got := note.Fields[field]; got != value {
+			t.Errorf("%s: got %q, want %q", field, got, value)
+		}
+	}
+}
+
+func TestFetchMedia(t *testing.T) {
+	audio := []byte("ID3 audio")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/audio.mp3":
+			w.Write(audio)
+		case "/large.mp3":
+			w.Write(bytes.Repeat([]byte{0}, maxMediaSize+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	newPackage := func(urls ...string) *Package {
+		p := &Package{}
+		for _, u := range urls {
+			p.Notes = append(p.Notes, Note{
+				Fields: map[string]string{"Audio": "[sound:audio.mp3]"},
+				Media:  []Media{{Filename: "audio.mp3", URL: u, Field: "Audio"}},
+			})
+		}
+		return p
+	}
+
+	p := newPackage(srv.URL+"/audio.mp3", srv.URL+"/large.mp3", srv.URL+"/missing.mp3")
+
+	missing := p.FetchMedia(srv.Client())
+	if len(missing) != 2 {
+		t.Fatalf("got %d missing media, want 2", len(missing))
+	}
+
+	if len(p.Notes[0].Media) != 1 || !bytes.Equal(p.Notes[0].Media[0].Data, audio) {
+		t.Errorf("expected the audio to be downloaded, got %+v", p.Notes[0].Media)
+	}
+
+	for _, n := range p.Notes[1:] {
+		if len(n.Media) != 0 || n.Fields["Audio"] != "" {
+			t.Errorf("expected media that couldn't be downloaded to be left out, got %+v", n)
+		}
+	}
+
+	// Audio URLs are part of the meta users can edit, they mustn't reach the
+	// network of the API
+	p = newPackage(srv.URL + "/audio.mp3")
+	if missing := p.FetchMedia(publichttp.Client(time.Second)); len(missing) != 1 {
+		t.Errorf("expected audio on a private address to be left out, got %+v", p.Notes[0].Media)
+	}
+}
+
+func TestNoteProtocolRelativeAudio(t *testing.T) {
+	format, err := FormatForLanguage("zho")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note, err := format.Note(Card{ID: 1, Token: "學", Meta: []byte(`{"audioUrl": "//img.zdic.net/audio/zd/py/xué.mp3"}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(note.Media) != 1 || note.Media[0].URL != "https://img.zdic.net/audio/zd/py/xué.mp3" {
+		t.Errorf("expected the audio to be fetched over https, got %+v", note.Media)
+	}
+}
@@ -0,0 +1,242 @@
+package anki
+
+import (
+	"context"
+	"crypto/sha1"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CardStore provides the pending cards that are pushed to Anki.
+type CardStore interface {
+	PendingCards(ctx context.Context, languageCode string) ([]Card, error)
+	MarkExported(ctx context.Context, id int64) error
+}
+
+// Syncer pushes pending cards to Anki through AnkiConnect. Cards are only
+// marked as exported after Anki accepted them, or when Anki already has them.
+type Syncer struct {
+	connect    Connect
+	store      CardStore
+	languages  []string
+	interval   time.Duration
+	maxBackoff time.Duration
+
+	// Checksums of the media files that were stored by filename, so they
+	// aren't uploaded again when a sync is retried
+	stored map[string][sha1.Size]byte
+}
+
+func NewSyncer(connect Connect, store CardStore, languages []string, interval, maxBackoff time.Duration) *Syncer {
+	return &Syncer{
+		connect:    connect,
+		store:      store,
+		languages:  languages,
+		interval:   interval,
+		maxBackoff: maxBackoff,
+		stored:     map[string][sha1.Size]byte{},
+	}
+}
+
+// Run syncs every interval until the context is cancelled. Failed syncs are
+// retried with an exponential backoff, e.g. when Anki isn't running. Notes that
+// Anki rejects don't fail a sync, they would keep it backing off.
+func (s *Syncer) Run(ctx context.Context) {
+	wait := time.Duration(0)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		if err := s.Sync(ctx); err != nil {
+			wait = wait * 2
+			if wait < s.interval {
+				wait = s.interval
+			}
+			if wait > s.maxBackoff {
+				wait = s.maxBackoff
+			}
+
+			log.Printf("could not sync cards to anki, retrying in %s: %v", wait, err)
+			continue
+		}
+
+		wait = s.interval
+	}
+}
+
+// Sync pushes the pending cards of all languages once. Only errors of the
+// store or AnkiConnect are returned, cards that can't be turned into a note
+// or that Anki rejects are logged and stay pending, so they're added once
+// they're fixed.
+func (s *Syncer) Sync(ctx context.Context) error {
+	var failed error
+
+	for _, lang := range s.languages {
+		if err := s.syncLanguage(ctx, lang); err != nil {
+			failed = errors.Wrapf(err, "could not sync %s", lang)
+		}
+	}
+
+	return failed
+}
+
+func (s *Syncer) syncLanguage(ctx context.Context, languageCode string) error {
+	format, err := FormatForLanguage(languageCode)
+	if err != nil {
+		return err
+	}
+
+	cards, err := s.store.PendingCards(ctx, languageCode)
+	if err != nil {
+		return errors.Wrap(err, "could not list pending cards")
+	}
+
+	if len(cards) == 0 {
+		return nil
+	}
+
+	if err := s.ensureDeck(ctx, format.Deck); err != nil {
+		return err
+	}
+
+	if err := s.ensureNoteType(ctx, format.NoteType); err != nil {
+		return err
+	}
+
+	valid := []Card{}
+	notes := []Note{}
+	for _, card := range cards {
+		note, err := format.Note(card)
+		if err != nil {
+			log.Printf("skipping card %d: %v", card.ID, err)
+			continue
+		}
+
+		valid = append(valid, card)
+		notes = append(notes, note)
+	}
+	cards = valid
+
+	if len(notes) == 0 {
+		return nil
+	}
+
+	checks, err := s.connect.CanAddNotes(ctx, format.Deck, format.NoteType.Name, notes)
+	if err != nil {
+		return err
+	}
+
+	// Duplicates are already in Anki, other notes that can't be added stay
+	// pending so they're retried after they're fixed
+	addable := []Card{}
+	addableNotes := []Note{}
+
+	for i, check := range checks {
+		if i >= len(cards) {
+			break
+		}
+
+		switch {
+		case check.CanAdd:
+			addable = append(addable, cards[i])
+			addableNotes = append(addableNotes, notes[i])
+		case check.Duplicate():
+			log.Printf("card %d is already in anki, marking it as exported", cards[i].ID)
+
+			if err := s.store.MarkExported(ctx, cards[i].ID); err != nil {
+				return errors.Wrapf(err, "could not mark card %d as exported", cards[i].ID)
+			}
+		default:
+			log.Printf("anki can't add card %d, skipping it: %s", cards[i].ID, check.Error)
+		}
+	}
+
+	for _, n := range addableNotes {
+		if err := s.storeMedia(ctx, n.Media); err != nil {
+			return err
+		}
+	}
+
+	if len(addableNotes) > 0 {
+		ids, err := s.connect.AddNotes(ctx, format.Deck, format.NoteType.Name, addableNotes)
+		if err != nil {
+			return err
+		}
+
+		for i, id := range ids {
+			if i >= len(addable) {
+				break
+			}
+
+			if id == nil {
+				log.Printf("anki didn't add card %d, skipping it", addable[i].ID)
+				continue
+			}
+
+			if err := s.store.MarkExported(ctx, addable[i].ID); err != nil {
+				return errors.Wrapf(err, "could not mark card %d as exported", addable[i].ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// storeMedia uploads media with data to Anki. Files that were stored before
+// with the same content are skipped.
+func (s *Syncer) storeMedia(ctx context.Context, media []Media) error {
+	for _, m := range media {
+		if m.Data == nil {
+			continue
+		}
+
+		sum := sha1.Sum(m.Data)
+		if stored, ok := s.stored[m.Filename]; ok && stored == sum {
+			continue
+		}
+
+		if err := s.connect.StoreMediaFile(ctx, m.Filename, m.Data); err != nil {
+			return err
+		}
+
+		s.stored[m.Filename] = sum
+	}
+
+	return nil
+}
+
+func (s *Syncer) ensureDeck(ctx context.Context, name string) error {
+	decks, err := s.connect.DeckNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range decks {
+		if d == name {
+			return nil
+		}
+	}
+
+	return s.connect.CreateDeck(ctx, name)
+}
+
+func (s *Syncer) ensureNoteType(ctx context.Context, noteType NoteType) error {
+	models, err := s.connect.ModelNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range models {
+		if m == noteType.Name {
+			return nil
+		}
+	}
+
+	return s.connect.CreateModel(ctx, noteType)
+}
@@ -0,0 +1,258 @@
+package anki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAnki is a stand-in for AnkiConnect. Like newer versions of AnkiConnect
+// it fails the whole batch of addNotes when one of the notes is a duplicate.
+type fakeAnki struct {
+	mu sync.Mutex
+
+	// Expressions of the notes that are in the deck
+	notes map[string]bool
+	// Number of times every media file was stored
+	media map[string]int
+	// Fails addNotes without adding anything, e.g. when Anki is closed
+	failAdd bool
+}
+
+func newFakeAnki() *fakeAnki {
+	return &fakeAnki{
+		notes: map[string]bool{},
+		media: map[string]int{},
+	}
+}
+
+type fakeNote struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (a *fakeAnki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	req := struct {
+		Action string `json:"action"`
+		Params struct {
+			Filename string     `json:"filename"`
+			Notes    []fakeNote `json:"notes"`
+		} `json:"params"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	var failure *string
+	fail := func(msg string) { failure = &msg }
+
+	switch req.Action {
+	case "deckNames", "modelNames":
+		result = []string{}
+	case "createDeck", "createModel":
+	case "storeMediaFile":
+		a.media[req.Params.Filename]++
+		result = req.Params.Filename
+	case "canAddNotesWithErrorDetail":
+		checks := []NoteCheck{}
+		for _, n := range req.Params.Notes {
+			switch {
+			case n.Fields["Expression"] == "":
+				checks = append(checks, NoteCheck{Error: "cannot create note because it is empty"})
+			case a.notes[n.Fields["Expression"]]:
+				checks = append(checks, NoteCheck{Error: "cannot create note because it is a duplicate"})
+			default:
+				checks = append(checks, NoteCheck{CanAdd: true})
+			}
+		}
+		result = checks
+	case "addNotes":
+		if a.failAdd {
+			fail("collection is not available")
+			break
+		}
+
+		for _, n := range req.Params.Notes {
+			if a.notes[n.Fields["Expression"]] {
+				fail("cannot create note because it is a duplicate")
+				break
+			}
+		}
+		if failure != nil {
+			break
+		}
+
+		ids := []int64{}
+		for i, n := range req.Params.Notes {
+			a.notes[n.Fields["Expression"]] = true
+			ids = append(ids, int64(i+1))
+		}
+		result = ids
+	default:
+		fail("unsupported action")
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "error": failure})
+}
+
+type fakeStore struct {
+	cards    []Card
+	exported []int64
+}
+
+func (s *fakeStore) PendingCards(ctx context.Context, languageCode string) ([]Card, error) {
+	pending := []Card{}
+
+	for _, c := range s.cards {
+		exported := false
+		for _, id := range s.exported {
+			exported = exported || id == c.ID
+		}
+
+		if !exported {
+			pending = append(pending, c)
+		}
+	}
+
+	return pending, nil
+}
+
+func (s *fakeStore) MarkExported(ctx context.Context, id int64) error {
+	s.exported = append(s.exported, id)
+	return nil
+}
+
+func card(id int64, token, line string) Card {
+	meta, _ := json.Marshal(map[string]interface{}{
+		"sentence": map[string]string{"line": line},
+	})
+
+	return Card{
+		ID:           id,
+		LanguageCode: "jpn",
+		Token:        token,
+		Meta:         meta,
+		Image:        []byte("\x89PNG\r\n\x1a\n"),
+	}
+}
+
+func TestSyncDuplicates(t *testing.T) {
+	anki := newFakeAnki()
+	anki.notes["猫が<b>好き</b>"] = true
+
+	srv := httptest.NewServer(anki)
+	defer srv.Close()
+
+	store := &fakeStore{cards: []Card{
+		card(1, "好き", "猫が好き"),
+		card(2, "犬", "犬を飼う"),
+		{ID: 3, LanguageCode: "jpn", Token: "空"},
+	}}
+	syncer := NewSyncer(NewConnect(srv.URL), store, []string{"jpn"}, time.Minute, time.Hour)
+
+	if err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []int64{1, 2}; !reflect.DeepEqual(store.exported, want) {
+		t.Errorf("exported %v, want %v", store.exported, want)
+	}
+
+	if !anki.notes["<b>犬</b>を飼う"] {
+		t.Error("expected the new note to be added")
+	}
+
+	if _, ok := anki.media["ankiminer_1.png"]; ok {
+		t.Error("expected no media to be stored for the duplicate")
+	}
+}
+
+func TestSyncStoresMediaOnce(t *testing.T) {
+	anki := newFakeAnki()
+	anki.failAdd = true
+
+	srv := httptest.NewServer(anki)
+	defer srv.Close()
+
+	store := &fakeStore{cards: []Card{card(1, "犬", "犬を飼う")}}
+	syncer := NewSyncer(NewConnect(srv.URL), store, []string{"jpn"}, time.Minute, time.Hour)
+
+	if err := syncer.Sync(context.Background()); err == nil {
+		t.Fatal("expected an error when anki fails to add notes")
+	}
+
+	anki.failAdd = false
+
+	if err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := anki.media["ankiminer_1.png"]; got != 1 {
+		t.Errorf("media stored %d times, want 1", got)
+	}
+
+	if want := []int64{1}; !reflect.DeepEqual(store.exported, want) {
+		t.Errorf("exported %v, want %v", store.exported, want)
+	}
+}
+
+func TestSyncSkipsRejectedNotes(t *testing.T) {
+	anki := newFakeAnki()
+
+	srv := httptest.NewServer(anki)
+	defer srv.Close()
+
+	store := &fakeStore{cards: []Card{
+		// Anki rejects notes with an empty Expression
+		{ID: 1, LanguageCode: "jpn", Token: "空"},
+		{ID: 2, LanguageCode: "jpn", Token: "壊", Meta: []byte(`{"sentence":`)},
+	}}
+	syncer := NewSyncer(NewConnect(srv.URL), store, []string{"jpn"}, time.Minute, time.Hour)
+
+	// Notes that are rejected don't fail the sync, that would make it back
+	// off for every other card
+	for i := 0; i < 2; i++ {
+		if err := syncer.Sync(context.Background()); err != nil {
+			t.Fatalf("sync %d: unexpected error: %v", i, err)
+		}
+	}
+
+	store.cards = append(store.cards, card(3, "犬", "犬を飼う"))
+
+	if err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The rejected cards stay pending until they're fixed
+	if want := []int64{3}; !reflect.DeepEqual(store.exported, want) {
+		t.Errorf("exported %v, want %v", store.exported, want)
+	}
+
+	pending, _ := store.PendingCards(context.Background(), "jpn")
+	if len(pending) != 2 {
+		t.Errorf("got %d pending cards, want 2", len(pending))
+	}
+}
+
+func TestSyncFailsWhenAnkiIsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{cards: []Card{card(1, "犬", "犬を飼う")}}
+	syncer := NewSyncer(NewConnect(srv.URL), store, []string{"jpn"}, time.Minute, time.Hour)
+
+	if err := syncer.Sync(context.Background()); err == nil {
+		t.Error("expected an error when anki can't be reached")
+	}
+}
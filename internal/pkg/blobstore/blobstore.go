@@ -0,0 +1,125 @@
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Store stores blobs by the SHA-256 hash of their content, storing the same
+// content twice returns the same key without storing it again.
+type Store interface {
+	Put(ctx context.Context, data []byte) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// Key returns the key for a blob.
+func Key(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type local struct {
+	root string
+}
+
+// NewLocal returns a store that keeps blobs on the local filesystem. Blobs are
+// spread over folders by the first characters of their key.
+func NewLocal(root string) (Store, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, errors.Wrap(err, "could not create blob folder")
+	}
+
+	return &local{root: root}, nil
+}
+
+func (s *local) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.root, key[:2], key[2:4], key), nil
+}
+
+func (s *local) Put(ctx context.Context, data []byte) (string, error) {
+	key := Key(data)
+
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return key, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", errors.Wrap(err, "could not create blob folder")
+	}
+
+	// Write to a temporary file first so a blob is never partially written
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return "", errors.Wrap(err, "could not create blob")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", errors.Wrap(err, "could not write blob")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", errors.Wrap(err, "could not write blob")
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", errors.Wrap(err, "could not write blob")
+	}
+
+	return key, nil
+}
+
+func (s *local) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return data, errors.Wrap(err, "could not read blob")
+}
+
+func (s *local) Exists(ctx context.Context, key string) (bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	switch {
+	case err == nil:
+		return true, nil
+	case os.IsNotExist(err):
+		return false, nil
+	default:
+		return false, errors.Wrap(err, "could not check blob")
+	}
+}
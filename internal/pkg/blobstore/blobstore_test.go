@@ -0,0 +1,93 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	s, err := NewLocal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("image")
+
+	key, err := s.Put(ctx, data)
+	if err != nil {
+		t.Fatalf("could not put blob: %v", err)
+	}
+
+	if key != Key(data) {
+		t.Errorf("got key %s, want %s", key, Key(data))
+	}
+
+	if _, err := os.Stat(filepath.Join(root, key[:2], key[2:4], key)); err != nil {
+		t.Errorf("expected the blob in a folder by its key: %v", err)
+	}
+
+	// The same content is stored once
+	again, err := s.Put(ctx, data)
+	if err != nil || again != key {
+		t.Errorf("got key %s (%v), want %s", again, err, key)
+	}
+
+	got, err := s.Get(ctx, key)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("got blob %q (%v), want %q", got, err, data)
+	}
+
+	if exists, err := s.Exists(ctx, key); err != nil || !exists {
+		t.Errorf("expected the blob to exist, got %v (%v)", exists, err)
+	}
+
+	tmp, _ := filepath.Glob(filepath.Join(root, key[:2], key[2:4], "*.tmp"))
+	if len(tmp) > 0 {
+		t.Errorf("expected temporary files to be removed, got %v", tmp)
+	}
+}
+
+func TestLocalMissing(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := Key([]byte("missing"))
+
+	if _, err := s.Get(ctx, key); err != ErrNotFound {
+		t.Errorf("got error %v, want %v", err, ErrNotFound)
+	}
+
+	if exists, err := s.Exists(ctx, key); err != nil || exists {
+		t.Errorf("expected the blob not to exist, got %v (%v)", exists, err)
+	}
+}
+
+func TestLocalInvalidKey(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Keys come from the database, they must never reach outside the root
+	for _, key := range []string{"", "../../etc/passwd", "ABCDEF", Key(nil) + "/.."} {
+		if _, err := s.Get(ctx, key); err != ErrInvalidKey {
+			t.Errorf("%q: got error %v, want %v", key, err, ErrInvalidKey)
+		}
+
+		if _, err := s.Exists(ctx, key); err != ErrInvalidKey {
+			t.Errorf("%q: got error %v, want %v", key, err, ErrInvalidKey)
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package cardfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatJSONL = "jsonl"
+)
+
+// How images are included in an export
+const (
+	// Images are embedded as base64 in the image column
+	ImagesBase64 = "base64"
+
+	// The export is a zip with the card file and an images folder, the image
+	// column contains the path of the image in the zip
+	ImagesZip = "zip"
+)
+
+// Zips larger than this when unpacked are rejected, the import body is
+// limited but zip entries can be compressed far below their size
+const (
+	maxZipFileSize  = 64 << 20
+	maxZipTotalSize = 256 << 20
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrInvalidFile       = errors.New("invalid card file")
+)
+
+// Card is a card as it's written to or read from a file.
+type Card struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	Image        []byte
+	CreatedAt    *time.Time
+	ExportedAt   *time.Time
+	ArchivedAt   *time.Time
+}
+
+type jsonCard struct {
+	ID           int64           `json:"id,omitempty"`
+	LanguageCode string          `json:"language_code"`
+	Token        string          `json:"token"`
+	Meta         json.RawMessage `json:"meta"`
+	Image        string          `json:"image,omitempty"`
+	CreatedAt    *time.Time      `json:"created_at,omitempty"`
+	ExportedAt   *time.Time      `json:"exported_at,omitempty"`
+	ArchivedAt   *time.Time      `json:"archived_at,omitempty"`
+}
+
+// Columns that aren't part of the meta, every other column is a meta key
+var fixedColumns = []string{"id", "language_code", "token", "image", "created_at", "exported_at", "archived_at"}
+
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatTSV || format == FormatJSONL
+}
+
+// ContentType returns the content type of an export.
+func ContentType(format, images string) string {
+	switch {
+	case images == ImagesZip:
+		return "application/zip"
+	case format == FormatCSV:
+		return "text/csv; charset=utf-8"
+	case format == FormatTSV:
+		return "text/tab-separated-values; charset=utf-8"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Write writes cards in the given format. Images are embedded as base64 or
+// the cards are written in a zip together with their images.
+func Write(w io.Writer, format, images string, cards []Card) error {
+	if !ValidFormat(format) {
+		return ErrUnsupportedFormat
+	}
+
+	if images != ImagesZip {
+		return writeCards(w, format, cards, func(c Card) string {
+			return base64.StdEncoding.EncodeToString(c.Image)
+		})
+	}
+
+	imagePath := func(c Card) string {
+		return fmt.Sprintf("images/%d.%s", c.ID, imageExtension(c.Image))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeCards(buf, format, cards, imagePath); err != nil {
+		return err
+	}
+
+	z := zip.NewWriter(w)
+
+	if err := writeZipFile(z, "cards."+format, buf.Bytes()); err != nil {
+		return err
+	}
+
+	for _, c := range cards {
+		if len(c.Image) == 0 {
+			continue
+		}
+
+		if err := writeZipFile(z, imagePath(c), c.Image); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(z.Close(), "could not write zip")
+}
+
+func writeZipFile(z *zip.Writer, name string, data []byte) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return errors.Wrapf(err, "could not add %s", name)
+	}
+
+	_, err = f.Write(data)
+	return errors.Wrapf(err, "could not add %s", name)
+}
+
+// writeCards writes the card file, image returns the value of the image
+// column for cards with an image.
+func writeCards(w io.Writer, format string, cards []Card, image func(Card) string) error {
+	imageValue := func(c Card) string {
+		if len(c.Image) == 0 {
+			return ""
+		}
+		return image(c)
+	}
+
+	if format == FormatJSONL {
+		enc := json.NewEncoder(w)
+
+		for _, c := range cards {
+			line := jsonCard{
+				ID:           c.ID,
+				LanguageCode: c.LanguageCode,
+				Token:        c.Token,
+				Meta:         c.Meta,
+				Image:        imageValue(c),
+				CreatedAt:    c.CreatedAt,
+				ExportedAt:   c.ExportedAt,
+				ArchivedAt:   c.ArchivedAt,
+			}
+
+			if len(line.Meta) == 0 {
+				line.Meta = json.RawMessage("{}")
+			}
+
+			if err := enc.Encode(line); err != nil {
+				return errors.Wrap(err, "could not write card")
+			}
+		}
+
+		return nil
+	}
+
+	metas := make([]map[string]interface{}, len(cards))
+	for i, c := range cards {
+		metas[i] = map[string]interface{}{}
+
+		if len(c.Meta) > 0 {
+			if err := json.Unmarshal(c.Meta, &metas[i]); err != nil {
+				return errors.Wrapf(err, "could not decode meta of card %d", c.ID)
+			}
+		}
+	}
+
+	keys := metaKeys(metas)
+
+	cw := csv.NewWriter(w)
+	if format == FormatTSV {
+		cw.Comma = '\t'
+	}
+
+	if err := cw.Write(append(append([]string{}, fixedColumns...), keys...)); err != nil {
+		return errors.Wrap(err, "could not write header")
+	}
+
+	for i, c := range cards {
+		record := []string{
+			strconv.FormatInt(c.ID, 10),
+			c.LanguageCode,
+			c.Token,
+			imageValue(c),
+			formatTime(c.CreatedAt),
+			formatTime(c.ExportedAt),
+			formatTime(c.ArchivedAt),
+		}
+
+		for _, k := range keys {
+			v, err := formatMetaValue(metas[i][k])
+			if err != nil {
+				return errors.Wrapf(err, "could not encode meta of card %d", c.ID)
+			}
+			record = append(record, v)
+		}
+
+		if err := cw.Write(record); err != nil {
+			return errors.Wrap(err, "could not write card")
+		}
+	}
+
+	cw.Flush()
+
+	return errors.Wrap(cw.Error(), "could not write cards")
+}
+
+// metaKeys returns all top level meta keys that are used by any card.
+func metaKeys(metas []map[string]interface{}) []string {
+	fixed := map[string]bool{}
+	for _, c := range fixedColumns {
+		fixed[c] = true
+	}
+
+	seen := map[string]bool{}
+	keys := []string{}
+
+	for _, m := range metas {
+		for k := range m {
+			if seen[k] || fixed[k] {
+				continue
+			}
+
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// formatMetaValue writes strings as-is so they're easy to edit, other values
+// are written as JSON.
+func formatMetaValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+// FieldTypes returns the JSON types a meta field of a language is declared
+// with, values of plain text files are converted to these types.
+type FieldTypes func(languageCode, field string) []string
+
+// parseMetaValue is the inverse of formatMetaValue. Objects and arrays are
+// decoded, numbers and booleans only when the field is declared as one, so
+// text like "1984" or "true" is kept as it was written.
+func parseMetaValue(s string, types []string) interface{} {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return nil
+	}
+
+	for _, t := range types {
+		if t == "string" {
+			return s
+		}
+	}
+
+	decode := trimmed[0] == '{' || trimmed[0] == '['
+	for _, t := range types {
+		decode = decode || t == "number" || t == "integer" || t == "boolean"
+	}
+
+	if decode {
+		var v interface{}
+		if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+			return v
+		}
+	}
+
+	return s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidFile, "invalid time %s", s)
+	}
+
+	return &t, nil
+}
+
+// Read reads cards from a card file or from a zip with a card file and its
+// images. The format of a card file in a zip is taken from its extension.
+// Types are used to convert the meta values of CSV and TSV files.
+func Read(data []byte, format string, types FieldTypes) ([]Card, error) {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return readCards(data, format, types, func(v string) ([]byte, error) {
+			img, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, errors.Wrap(ErrInvalidFile, "invalid base64 image")
+			}
+			return img, nil
+		})
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidFile, err.Error())
+	}
+
+	files := map[string]*zip.File{}
+	var cardFile *zip.File
+
+	for _, f := range z.File {
+		files[f.Name] = f
+
+		for _, ext := range []string{FormatCSV, FormatTSV, FormatJSONL} {
+			if cardFile == nil && !strings.Contains(f.Name, "/") && strings.HasSuffix(f.Name, "."+ext) {
+				cardFile, format = f, ext
+			}
+		}
+	}
+
+	if cardFile == nil {
+		return nil, errors.Wrap(ErrInvalidFile, "zip doesn't contain a card file")
+	}
+
+	total := 0
+	read := func(f *zip.File) ([]byte, error) {
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		total += len(data)
+		if total > maxZipTotalSize {
+			return nil, errors.Wrap(ErrInvalidFile, "zip is too large")
+		}
+
+		return data, nil
+	}
+
+	content, err := read(cardFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return readCards(content, format, types, func(v string) ([]byte, error) {
+		f, ok := files[v]
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidFile, "image %s is missing", v)
+		}
+
+		return read(f)
+	})
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxZipFileSize {
+		return nil, errors.Wrapf(ErrInvalidFile, "%s is too large", f.Name)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open %s", f.Name)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxZipFileSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidFile, "could not read %s: %v", f.Name, err)
+	}
+
+	if len(data) > maxZipFileSize {
+		return nil, errors.Wrapf(ErrInvalidFile, "%s is too large", f.Name)
+	}
+
+	return data, nil
+}
+
+func readCards(data []byte, format string, types FieldTypes, image func(string) ([]byte, error)) ([]Card, error) {
+	if !ValidFormat(format) {
+		return nil, ErrUnsupportedFormat
+	}
+
+	res := []Card{}
+
+	if format == FormatJSONL {
+		for i, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			jc := jsonCard{}
+			if err := json.Unmarshal([]byte(line), &jc); err != nil {
+				return nil, errors.Wrapf(ErrInvalidFile, "line %d: %s", i+1, err)
+			}
+
+			c := Card{
+				ID:           jc.ID,
+				LanguageCode: jc.LanguageCode,
+				Token:        jc.Token,
+				Meta:         jc.Meta,
+				CreatedAt:    jc.CreatedAt,
+				ExportedAt:   jc.ExportedAt,
+				ArchivedAt:   jc.ArchivedAt,
+			}
+
+			if len(c.Meta) == 0 || string(c.Meta) == "null" {
+				c.Meta = json.RawMessage("{}")
+			}
+
+			if jc.Image != "" {
+				img, err := image(jc.Image)
+				if err != nil {
+					return nil, err
+				}
+				c.Image = img
+			}
+
+			res = append(res, c)
+		}
+
+		return res, nil
+	}
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	if format == FormatTSV {
+		cr.Comma = '\t'
+		cr.LazyQuotes = true
+	}
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidFile, err.Error())
+	}
+
+	if len(records) == 0 {
+		return res, nil
+	}
+
+	header := records[0]
+	for i, record := range records[1:] {
+		c := Card{}
+		values := map[string]string{}
+
+		for j, value := range record {
+			if j >= len(header) {
+				break
+			}
+
+			switch header[j] {
+			case "id":
+				// Imported cards always get a new ID
+			case "language_code":
+				c.LanguageCode = value
+			case "token":
+				c.Token = value
+			case "image":
+				if value == "" {
+					continue
+				}
+
+				if c.Image, err = image(value); err != nil {
+					return nil, errors.Wrapf(err, "row %d", i+2)
+				}
+			case "created_at":
+				if c.CreatedAt, err = parseTime(value); err != nil {
+					return nil, errors.Wrapf(err, "row %d", i+2)
+				}
+			case "exported_at":
+				if c.ExportedAt, err = parseTime(value); err != nil {
+					return nil, errors.Wrapf(err, "row %d", i+2)
+				}
+			case "archived_at":
+				if c.ArchivedAt, err = parseTime(value); err != nil {
+					return nil, errors.Wrapf(err, "row %d", i+2)
+				}
+			default:
+				values[header[j]] = value
+			}
+		}
+
+		// Types depend on the language, which can be in any column
+		meta := map[string]interface{}{}
+		for k, value := range values {
+			var declared []string
+			if types != nil {
+				declared = types(c.LanguageCode, k)
+			}
+
+			if v := parseMetaValue(value, declared); v != nil {
+				meta[k] = v
+			}
+		}
+
+		if c.Meta, err = json.Marshal(meta); err != nil {
+			return nil, errors.Wrapf(err, "row %d", i+2)
+		}
+
+		res = append(res, c)
+	}
+
+	return res, nil
+}
+
+func imageExtension(data []byte) string {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return "jpg"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return "png"
+	}
+}
@@ -0,0 +1,212 @@
+package cardfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The PNG signature is enough for the image extension
+var testImage = []byte("\x89PNG\r\n\x1a\nimage")
+
+func testCards() []Card {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	exported := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	archived := time.Date(2024, 3, 3, 10, 0, 0, 0, time.UTC)
+
+	return []Card{
+		{
+			ID:           1,
+			LanguageCode: "zho",
+			Token:        "學生",
+			Meta:         json.RawMessage(`{"reading":"xué sheng","vocabCard":true,"sentence":{"line":"他是學生"}}`),
+			Image:        testImage,
+			CreatedAt:    &created,
+			ExportedAt:   &exported,
+		},
+		{
+			ID:           2,
+			LanguageCode: "jpn",
+			Token:        "壊す",
+			Meta:         json.RawMessage(`{"reading":"1984"}`),
+			CreatedAt:    &created,
+			ArchivedAt:   &archived,
+		},
+	}
+}
+
+func testFieldTypes(languageCode, field string) []string {
+	switch field {
+	case "vocabCard":
+		return []string{"boolean"}
+	case "reading":
+		return []string{"string"}
+	default:
+		return nil
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatTSV, FormatJSONL} {
+		for _, images := range []string{ImagesBase64, ImagesZip} {
+			t.Run(format+" "+images, func(t *testing.T) {
+				buf := &bytes.Buffer{}
+				if err := Write(buf, format, images, testCards()); err != nil {
+					t.Fatalf("could not write cards: %v", err)
+				}
+
+				// The format of a zip is taken from the card file in it
+				cards, err := Read(buf.Bytes(), format, testFieldTypes)
+				if err != nil {
+					t.Fatalf("could not read cards: %v", err)
+				}
+
+				want := testCards()
+				if len(cards) != len(want) {
+					t.Fatalf("got %d cards, want %d", len(cards), len(want))
+				}
+
+				for i, c := range cards {
+					w := want[i]
+
+					if c.LanguageCode != w.LanguageCode || c.Token != w.Token {
+						t.Errorf("card %d: got %s %s, want %s %s", i, c.LanguageCode, c.Token, w.LanguageCode, w.Token)
+					}
+
+					if !bytes.Equal(c.Image, w.Image) {
+						t.Errorf("card %d: got image %q, want %q", i, c.Image, w.Image)
+					}
+
+					assertMeta(t, c.Meta, w.Meta)
+					assertTime(t, "created_at", c.CreatedAt, w.CreatedAt)
+					assertTime(t, "exported_at", c.ExportedAt, w.ExportedAt)
+					assertTime(t, "archived_at", c.ArchivedAt, w.ArchivedAt)
+				}
+			})
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "id,language_code,token,image,reading,vocabCard,examples\n" +
+		"7,deu,Haus,,haus,true,\"[\"\"Das Haus\"\"]\"\n"
+
+	cards, err := Read([]byte(data), FormatCSV, testFieldTypes)
+	if err != nil {
+		t.Fatalf("could not read cards: %v", err)
+	}
+
+	if len(cards) != 1 {
+		t.Fatalf("got %d cards, want 1", len(cards))
+	}
+
+	if cards[0].ID != 0 {
+		t.Errorf("expected imported cards to get a new id, got %d", cards[0].ID)
+	}
+
+	assertMeta(t, cards[0].Meta, json.RawMessage(`{"reading":"haus","vocabCard":true,"examples":["Das Haus"]}`))
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{
+			name:   "invalid json line",
+			data:   []byte(`{"token":`),
+			format: FormatJSONL,
+		},
+		{
+			name:   "invalid time",
+			data:   []byte("language_code,token,created_at\nzho,學生,yesterday\n"),
+			format: FormatCSV,
+		},
+		{
+			name:   "invalid base64 image",
+			data:   []byte("language_code,token,image\nzho,學生,%%%\n"),
+			format: FormatCSV,
+		},
+		{
+			name:   "missing image in zip",
+			data:   testZip(t, map[string][]byte{"cards.csv": []byte("language_code,token,image\nzho,學生,images/1.png\n")}),
+			format: FormatCSV,
+		},
+		{
+			name:   "zip without card file",
+			data:   testZip(t, map[string][]byte{"images/1.png": testImage}),
+			format: FormatCSV,
+		},
+		{
+			// Zeros compress to a fraction of the body limit
+			name: "zip entry too large",
+			data: testZip(t, map[string][]byte{
+				"cards.csv":    []byte("language_code,token,image\nzho,學生,images/1.png\n"),
+				"images/1.png": make([]byte, maxZipFileSize+1),
+			}),
+			format: FormatCSV,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(tt.data, tt.format, testFieldTypes)
+			if errors.Cause(err) != ErrInvalidFile {
+				t.Errorf("got error %v, want %v", err, ErrInvalidFile)
+			}
+		})
+	}
+
+	if _, err := Read([]byte("{}"), "xml", nil); err != ErrUnsupportedFormat {
+		t.Errorf("got error %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func testZip(t *testing.T, files map[string][]byte) []byte {
+	buf := &bytes.Buffer{}
+	z := zip.NewWriter(buf)
+
+	for name, data := range files {
+		if err := writeZipFile(z, name, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func assertMeta(t *testing.T, got, want json.RawMessage) {
+	t.Helper()
+
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("could not decode meta %s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got meta %s, want %s", got, want)
+	}
+}
+
+func assertTime(t *testing.T, name string, got, want *time.Time) {
+	t.Helper()
+
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil || !got.Equal(*want):
+		t.Errorf("%s: got %v, want %v", name, got, want)
+	}
+}
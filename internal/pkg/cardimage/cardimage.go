@@ -0,0 +1,68 @@
+package cardimage
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/blobstore"
+	"github.com/antonve/language-learning-tools/internal/pkg/thumbnail"
+)
+
+// Store keeps the source images of cards and their thumbnails in a blob store,
+// cards only hold the keys.
+type Store struct {
+	blobs blobstore.Store
+}
+
+func New(blobs blobstore.Store) *Store {
+	return &Store{blobs: blobs}
+}
+
+type Keys struct {
+	Image     sql.NullString
+	Thumbnail sql.NullString
+}
+
+// Put stores an image together with its thumbnail. Empty images aren't stored
+// and result in empty keys. When no thumbnail can be made the image is still
+// stored, the full image is served as thumbnail instead.
+func (s *Store) Put(ctx context.Context, data []byte) (Keys, error) {
+	keys := Keys{}
+	if len(data) == 0 {
+		return keys, nil
+	}
+
+	key, err := s.blobs.Put(ctx, data)
+	if err != nil {
+		return keys, errors.Wrap(err, "could not store image")
+	}
+	keys.Image = sql.NullString{String: key, Valid: true}
+
+	thumb, err := thumbnail.New(data, thumbnail.DefaultSize)
+	if err != nil {
+		log.Println("could not create thumbnail:", err)
+		return keys, nil
+	}
+
+	key, err = s.blobs.Put(ctx, thumb)
+	if err != nil {
+		return keys, errors.Wrap(err, "could not store thumbnail")
+	}
+	keys.Thumbnail = sql.NullString{String: key, Valid: true}
+
+	return keys, nil
+}
+
+// Get returns the image stored under a key. Cards created before images were
+// moved to the blob store don't have a key yet, their image is passed as
+// legacy and returned as is.
+func (s *Store) Get(ctx context.Context, key sql.NullString, legacy []byte) ([]byte, error) {
+	if !key.Valid {
+		return legacy, nil
+	}
+
+	return s.blobs.Get(ctx, key.String)
+}
@@ -0,0 +1,229 @@
+package cardschema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed schemas/*.json
+var files embed.FS
+
+var ErrUnsupportedLanguage = errors.New("language has no card schema")
+
+// FieldError describes why a field of the meta of a card is invalid. Field is
+// the path to the field with dots, e.g. `meta.sentence.line`.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Validator checks the meta of cards against the JSON Schema of their
+// language.
+type Validator struct {
+	raw     map[string][]byte
+	schemas map[string]*jsonschema.Schema
+}
+
+// New compiles the schemas of all languages.
+func New() (*Validator, error) {
+	v := &Validator{
+		raw:     map[string][]byte{},
+		schemas: map[string]*jsonschema.Schema{},
+	}
+
+	entries, err := files.ReadDir("schemas")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		data, err := files.ReadFile(path.Join("schemas", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		languageCode := strings.TrimSuffix(e.Name(), ".json")
+
+		c := jsonschema.NewCompiler()
+		c.AssertFormat = true
+		if err := c.AddResource(e.Name(), bytes.NewReader(data)); err != nil {
+			return nil, errors.Wrapf(err, "could not load schema for %s", languageCode)
+		}
+
+		schema, err := c.Compile(e.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not compile schema for %s", languageCode)
+		}
+
+		v.raw[languageCode] = data
+		v.schemas[languageCode] = schema
+	}
+
+	return v, nil
+}
+
+// Schema returns the JSON Schema of a language.
+func (v *Validator) Schema(languageCode string) ([]byte, error) {
+	data, ok := v.raw[languageCode]
+	if !ok {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	return data, nil
+}
+
+// Languages returns the languages that have a schema.
+func (v *Validator) Languages() []string {
+	res := make([]string, 0, len(v.raw))
+	for languageCode := range v.raw {
+		res = append(res, languageCode)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// FieldTypes returns the JSON types a top level meta field of a language is
+// declared with, e.g. `string` or `integer`. Fields that aren't declared
+// have no types.
+func (v *Validator) FieldTypes(languageCode, field string) []string {
+	schema, ok := v.schemas[languageCode]
+	if !ok {
+		return nil
+	}
+
+	property, ok := schema.Properties[field]
+	if !ok {
+		return nil
+	}
+
+	for property.Ref != nil && len(property.Types) == 0 {
+		property = property.Ref
+	}
+
+	return property.Types
+}
+
+// Validate returns the problems with the meta of a card. Languages without a
+// schema only require the meta to be an object.
+func (v *Validator) Validate(languageCode string, meta json.RawMessage) []FieldError {
+	if len(meta) == 0 {
+		meta = json.RawMessage("{}")
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(meta, &doc); err != nil {
+		return []FieldError{{Field: "meta", Message: "invalid JSON"}}
+	}
+
+	schema, ok := v.schemas[languageCode]
+	if !ok {
+		if _, ok := doc.(map[string]interface{}); !ok {
+			return []FieldError{{Field: "meta", Message: "expected object"}}
+		}
+
+		return nil
+	}
+
+	err := schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []FieldError{{Field: "meta", Message: err.Error()}}
+	}
+
+	res := fieldErrors(ve)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Field < res[j].Field
+	})
+
+	return res
+}
+
+// fieldErrors flattens a validation error into the errors of single fields.
+// Only one of the alternatives of anyOf has to match, so they're reported
+// together as one error.
+func fieldErrors(ve *jsonschema.ValidationError) []FieldError {
+	if strings.HasSuffix(ve.KeywordLocation, "/anyOf") {
+		messages := []string{}
+		for _, cause := range ve.Causes {
+			for _, e := range fieldErrors(cause) {
+				messages = append(messages, e.Message)
+			}
+		}
+
+		return []FieldError{{
+			Field:   fieldName(ve.InstanceLocation),
+			Message: strings.Join(messages, ", or "),
+		}}
+	}
+
+	if len(ve.Causes) == 0 {
+		return []FieldError{{Field: fieldName(ve.InstanceLocation), Message: ve.Message}}
+	}
+
+	res := []FieldError{}
+	for _, cause := range ve.Causes {
+		res = append(res, fieldErrors(cause)...)
+	}
+
+	return res
+}
+
+// fieldName turns a JSON pointer into the path of a field in the request.
+func fieldName(pointer string) string {
+	parts := []string{"meta"}
+	for _, p := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if p == "" {
+			continue
+		}
+
+		p = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+		parts = append(parts, p)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Sentence is the sentence a card was mined from. Readers that only know the
+// line, like the Chinese and German readers, send it as a string.
+type Sentence struct {
+	Language string `json:"language,omitempty"`
+	Series   string `json:"series,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Chapter  string `json:"chapter,omitempty"`
+	Line     string `json:"line"`
+	Original string `json:"original,omitempty"`
+}
+
+func (s *Sentence) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err == nil {
+		*s = Sentence{Line: line}
+		return nil
+	}
+
+	type sentence Sentence
+	return json.Unmarshal(data, (*sentence)(s))
+}
+
+// SentenceLine returns the line of a sentence in either form, or nothing
+// when it can't be decoded.
+func SentenceLine(raw json.RawMessage) string {
+	s := Sentence{}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return ""
+	}
+
+	return s.Line
+}
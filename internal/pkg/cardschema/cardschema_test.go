@@ -0,0 +1,207 @@
+package cardschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Payloads as the frontend sends them, undefined fields are left out by
+// JSON.stringify.
+func TestValidateFrontendPayloads(t *testing.T) {
+	tests := []struct {
+		name         string
+		languageCode string
+		meta         string
+	}{
+		{
+			// exportWordToAnki in chinesereader/domain.ts
+			name:         "chinese reader sentence card",
+			languageCode: "zho",
+			meta: `{
+				"sentence": "他是一個好學生。",
+				"card_type": "sentence",
+				"hanzi_traditional": "學生",
+				"hanzi_simplified": "学生",
+				"pinyin": "xue2 sheng5",
+				"pinyin_tones": "xué sheng",
+				"meanings": ["student", "schoolchild"]
+			}`,
+		},
+		{
+			// exportWordToAnki without a CC-CEDICT definition
+			name:         "chinese reader vocab card without definition",
+			languageCode: "zho",
+			meta: `{
+				"sentence": "",
+				"card_type": "vocab",
+				"hanzi_traditional": "學生",
+				"hanzi_simplified": "学生",
+				"meanings": []
+			}`,
+		},
+		{
+			// WordMeta of the cards imported by ChineseApp.tsx
+			name:         "chinese anki wizard",
+			languageCode: "zho",
+			meta: `{
+				"sentence": {
+					"language": "zh",
+					"line": "他是一個好學生。",
+					"original": "他是一個好學生。"
+				},
+				"reading": "xué sheng",
+				"definitionEnglish": "student<br />schoolchild",
+				"vocabCard": false,
+				"highlight": "學生",
+				"externalId": 12,
+				"definitionTargetLanguage": "在学校读书的人",
+				"audioUrl": "https://img.zdic.net/audio/zd/py/xué.mp3"
+			}`,
+		},
+		{
+			// GermanMangaReader in german-reader/manga.tsx
+			name:         "german manga reader",
+			languageCode: "deu",
+			meta: `{
+				"card_type": "sentence",
+				"sentence": "Das Essen ist kalt",
+				"meaning": "the food"
+			}`,
+		},
+		{
+			// The crop area can be empty and the translation still loading
+			name:         "german manga reader without translation",
+			languageCode: "deu",
+			meta: `{
+				"card_type": "sentence",
+				"sentence": ""
+			}`,
+		},
+		{
+			// CardWizard.tsx copies audio_url of the zdic endpoint, which is
+			// empty when zdic has no audio for a word
+			name:         "chinese anki wizard without audio",
+			languageCode: "zho",
+			meta: `{
+				"sentence": {
+					"language": "zh",
+					"line": "他是一個好學生。",
+					"original": "他是一個好學生。"
+				},
+				"reading": "xué sheng",
+				"zhuyin": "ㄒㄩㄝˊ ˙ㄕㄥ",
+				"definitionEnglish": "student<br />schoolchild",
+				"vocabCard": false,
+				"highlight": "學生",
+				"definitionTargetLanguage": "在学校读书的人",
+				"audioUrl": ""
+			}`,
+		},
+		{
+			// Cards mined before zdic audio URLs got a scheme
+			name:         "chinese anki wizard with protocol relative audio",
+			languageCode: "zho",
+			meta: `{
+				"sentence": {
+					"language": "zh",
+					"line": "他是一個好學生。"
+				},
+				"reading": "xué sheng",
+				"highlight": "學生",
+				"audioUrl": "//img.zdic.net/audio/zd/py/xué.mp3"
+			}`,
+		},
+		{
+			// WordMeta of the Japanese anki wizard
+			name:         "japanese anki wizard",
+			languageCode: "jpn",
+			meta: `{
+				"sentence": {
+					"language": "ja",
+					"series": "よつばと！",
+					"filename": "yotsuba_01.txt",
+					"chapter": "1",
+					"line": "とーちゃん、あれなに？",
+					"original": "とーちゃん、あれなに？"
+				},
+				"reading": "なに",
+				"definitionEnglish": "what",
+				"definitionTargetLanguage": "不明の事物を問う語。",
+				"vocabCard": false,
+				"highlight": "なに"
+			}`,
+		},
+		{
+			// WordMeta as it's used for Cantonese cards
+			name:         "cantonese anki wizard",
+			languageCode: "yue",
+			meta: `{
+				"sentence": {
+					"language": "yue",
+					"line": "你食咗飯未呀？"
+				},
+				"reading": "sik6",
+				"definitionEnglish": "to eat",
+				"vocabCard": true,
+				"highlight": "食"
+			}`,
+		},
+	}
+
+	v, err := New()
+	if err != nil {
+		t.Fatalf("could not load schemas: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := v.Validate(tt.languageCode, json.RawMessage(tt.meta)); len(errs) > 0 {
+				t.Errorf("expected payload to be valid, got %v", errs)
+			}
+		})
+	}
+}
+
+func TestValidateInvalid(t *testing.T) {
+	v, err := New()
+	if err != nil {
+		t.Fatalf("could not load schemas: %v", err)
+	}
+
+	errs := v.Validate("zho", json.RawMessage(`{"card_type": "cloze", "meanings": "student"}`))
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+
+	for _, f := range []string{"meta.card_type", "meta.meanings"} {
+		if !fields[f] {
+			t.Errorf("expected an error for %s, got %v", f, errs)
+		}
+	}
+
+	for _, audioURL := range []string{"xue.mp3", "/audio/xue.mp3", " "} {
+		meta, _ := json.Marshal(map[string]string{"audioUrl": audioURL})
+		if errs := v.Validate("zho", meta); len(errs) == 0 {
+			t.Errorf("expected audioUrl %q to be invalid", audioURL)
+		}
+	}
+}
+
+func TestSentenceLine(t *testing.T) {
+	tests := []struct {
+		raw  string
+		line string
+	}{
+		{`"他是一個好學生。"`, "他是一個好學生。"},
+		{`{"line": "とーちゃん、あれなに？", "series": "よつばと！"}`, "とーちゃん、あれなに？"},
+		{`null`, ""},
+		{`12`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := SentenceLine(json.RawMessage(tt.raw)); got != tt.line {
+			t.Errorf("SentenceLine(%s) = %q, want %q", tt.raw, got, tt.line)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package cantodict
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Dictionary looks up Cantonese words in CC-Canto and the Cantonese readings
+// of CC-CEDICT.
+type Dictionary interface {
+	Lookup(word string) []*Entry
+	Words() []string
+}
+
+type dictionary struct {
+	traditional map[string][]*Entry
+	simplified  map[string][]*Entry
+}
+
+// Matches `傳統 传统 [chuan2 tong3] {cyun4 tung2} /tradition/`, the definitions
+// are missing in the readings only files.
+var entryPattern = regexp.MustCompile(`^(\S+) (\S+) \[([^\]]*)\] \{([^}]*)\}\s*(/.*/)?`)
+
+// New loads all dictionary files (*.txt, *.u8) in the given directory, e.g.
+// `cccanto-webdist.txt` and `cccedict-canto-readings-150923.txt`.
+func New(path string) (Dictionary, error) {
+	d := &dictionary{
+		traditional: map[string][]*Entry{},
+		simplified:  map[string][]*Entry{},
+	}
+
+	files := []string{}
+	for _, pattern := range []string{"*.txt", "*.u8"} {
+		matches, err := filepath.Glob(filepath.Join(path, pattern))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not find dictionary files")
+		}
+		files = append(files, matches...)
+	}
+
+	for _, f := range files {
+		if err := d.load(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func (d *dictionary) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "could not open dictionary: "+path)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := entryPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		d.add(&Entry{
+			Traditional: m[1],
+			Simplified:  m[2],
+			Pinyin:      m[3],
+			Jyutping:    m[4],
+			Meanings:    parseMeanings(m[5]),
+		})
+	}
+
+	return errors.Wrap(scanner.Err(), "could not read dictionary: "+path)
+}
+
+// add stores an entry, readings without definitions are merged into an
+// existing entry with the same pronunciation.
+func (d *dictionary) add(e *Entry) {
+	for _, existing := range d.traditional[e.Traditional] {
+		if existing.Jyutping != e.Jyutping {
+			continue
+		}
+
+		existing.Meanings = append(existing.Meanings, e.Meanings...)
+		if existing.Pinyin == "" {
+			existing.Pinyin = e.Pinyin
+		}
+
+		return
+	}
+
+	d.traditional[e.Traditional] = append(d.traditional[e.Traditional], e)
+	if e.Simplified != e.Traditional {
+		d.simplified[e.Simplified] = append(d.simplified[e.Simplified], e)
+	}
+}
+
+func parseMeanings(raw string) []string {
+	res := []string{}
+
+	for _, m := range strings.Split(strings.Trim(raw, "/"), "/") {
+		m = strings.TrimSpace(m)
+		if m != "" {
+			res = append(res, m)
+		}
+	}
+
+	return res
+}
+
+func (d *dictionary) Lookup(word string) []*Entry {
+	if entries, ok := d.traditional[word]; ok {
+		return entries
+	}
+
+	return d.simplified[word]
+}
+
+// Words returns the traditional headwords of all entries.
+func (d *dictionary) Words() []string {
+	res := make([]string, 0, len(d.traditional))
+	for w := range d.traditional {
+		res = append(res, w)
+	}
+
+	return res
+}
+
+type Entry struct {
+	Traditional string
+	Simplified  string
+	Pinyin      string
+	Jyutping    string
+	Meanings    []string
+}
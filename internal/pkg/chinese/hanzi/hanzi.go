@@ -0,0 +1,343 @@
+package hanzi
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrNotFound = errors.New("could not find character")
+
+// Dictionary contains the decomposition, radical and stroke count data of
+// Chinese characters. It's backed by an IDS file (in the cjkvi-ids format) and
+// the Unihan database.
+type Dictionary interface {
+	Lookup(char string) (*Character, error)
+	Containing(component string) []string
+}
+
+type dictionary struct {
+	characters map[rune]*Character
+
+	// Reverse index of every component (recursively) to the characters that
+	// contain it
+	containedIn map[rune][]rune
+	// Characters grouped by their phonetic series (Unihan kPhonetic)
+	phoneticSeries map[string][]rune
+}
+
+// New loads `ids.txt` and all `Unihan_*.txt` files found in the given
+// directory.
+func New(path string) (Dictionary, error) {
+	d := &dictionary{
+		characters:     map[rune]*Character{},
+		containedIn:    map[rune][]rune{},
+		phoneticSeries: map[string][]rune{},
+	}
+
+	if err := d.loadIDS(filepath.Join(path, "ids.txt")); err != nil {
+		return nil, err
+	}
+
+	unihan, err := filepath.Glob(filepath.Join(path, "Unihan_*.txt"))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find unihan files")
+	}
+
+	for _, f := range unihan {
+		if err := d.loadUnihan(f); err != nil {
+			return nil, err
+		}
+	}
+
+	d.buildIndexes()
+
+	return d, nil
+}
+
+func (d *dictionary) character(r rune) *Character {
+	c, ok := d.characters[r]
+	if !ok {
+		c = &Character{Character: string(r)}
+		d.characters[r] = c
+	}
+
+	return c
+}
+
+func (d *dictionary) loadIDS(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not open ids file")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		// U+4E0D	不	⿱一𠄠
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+
+		r, _ := utf8.DecodeRuneInString(fields[1])
+		if r == utf8.RuneError {
+			continue
+		}
+
+		c := d.character(r)
+		for _, ids := range fields[2:] {
+			// Remove source annotations, e.g. ⿰氵每[GTJ]
+			if i := strings.Index(ids, "["); i >= 0 {
+				ids = ids[:i]
+			}
+			ids = strings.TrimPrefix(strings.TrimSpace(ids), "^")
+			ids = strings.TrimSuffix(ids, "$")
+
+			if ids == "" || ids == string(r) {
+				continue
+			}
+
+			c.Decompositions = append(c.Decompositions, ids)
+		}
+
+		if len(c.Decompositions) > 0 {
+			c.Components = components(c.Decompositions[0])
+		}
+	}
+
+	return errors.Wrap(scanner.Err(), "could not read ids file")
+}
+
+func (d *dictionary) loadUnihan(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "could not open unihan file: "+path)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// U+6CB3	kRSUnicode	85.5
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+
+		codepoint, err := strconv.ParseInt(strings.TrimPrefix(fields[0], "U+"), 16, 32)
+		if err != nil {
+			continue
+		}
+
+		r := rune(codepoint)
+		value := strings.Fields(fields[2])
+		if len(value) == 0 {
+			continue
+		}
+
+		switch fields[1] {
+		case "kRSUnicode":
+			d.character(r).Radical = parseRadical(value[0])
+		case "kTotalStrokes":
+			// Multiple values are ordered as zh-Hans, zh-Hant; we prefer the latter
+			strokes, err := strconv.Atoi(value[len(value)-1])
+			if err == nil {
+				d.character(r).StrokeCount = strokes
+			}
+		case "kPhonetic":
+			c := d.character(r)
+			for _, v := range value {
+				c.phonetic = append(c.phonetic, strings.TrimRight(v, "*x"))
+			}
+		case "kDefinition":
+			d.character(r).Definition = fields[2]
+		}
+	}
+
+	return errors.Wrap(scanner.Err(), "could not read unihan file: "+path)
+}
+
+// parseRadical parses the radical-stroke value of kRSUnicode, e.g. `85.5`.
+// Simplified radicals are suffixed with an apostrophe, e.g. `120'.3`.
+func parseRadical(value string) *Radical {
+	parts := strings.SplitN(value, ".", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+
+	number, err := strconv.Atoi(strings.TrimRight(parts[0], "'"))
+	if err != nil || number < 1 || number > 214 {
+		return nil
+	}
+
+	extra, _ := strconv.Atoi(parts[1])
+
+	// The Kangxi radicals block is ordered by radical number, NFKC maps them to
+	// their unified ideographs
+	kangxi := string(rune(0x2F00 + number - 1))
+
+	return &Radical{
+		Number:       number,
+		Character:    norm.NFKC.String(kangxi),
+		ExtraStrokes: extra,
+	}
+}
+
+// components returns the direct components of an ideographic description
+// sequence, skipping description characters and unencoded components.
+func components(ids string) []string {
+	res := []string{}
+	seen := map[rune]bool{}
+
+	for _, r := range ids {
+		if isDescriptionCharacter(r) || !unicode.Is(unicode.Han, r) || seen[r] {
+			continue
+		}
+
+		seen[r] = true
+		res = append(res, string(r))
+	}
+
+	return res
+}
+
+func isDescriptionCharacter(r rune) bool {
+	return r >= 0x2FF0 && r <= 0x2FFF || r == 0x31EF
+}
+
+func (d *dictionary) buildIndexes() {
+	memo := map[rune][]rune{}
+
+	for r, c := range d.characters {
+		for _, component := range d.allComponents(r, memo, map[rune]bool{}) {
+			d.containedIn[component] = append(d.containedIn[component], r)
+		}
+
+		for _, p := range c.phonetic {
+			d.phoneticSeries[p] = append(d.phoneticSeries[p], r)
+		}
+	}
+
+	for k := range d.containedIn {
+		sortRunes(d.containedIn[k])
+	}
+
+	for k := range d.phoneticSeries {
+		sortRunes(d.phoneticSeries[k])
+	}
+}
+
+// allComponents recursively collects the components of a character.
+func (d *dictionary) allComponents(r rune, memo map[rune][]rune, visiting map[rune]bool) []rune {
+	if res, ok := memo[r]; ok {
+		return res
+	}
+
+	c, ok := d.characters[r]
+	if !ok || visiting[r] {
+		return nil
+	}
+	visiting[r] = true
+
+	seen := map[rune]bool{}
+	res := []rune{}
+
+	for _, component := range c.Components {
+		cr, _ := utf8.DecodeRuneInString(component)
+		if cr == r {
+			continue
+		}
+
+		for _, sub := range append([]rune{cr}, d.allComponents(cr, memo, visiting)...) {
+			if !seen[sub] {
+				seen[sub] = true
+				res = append(res, sub)
+			}
+		}
+	}
+
+	delete(visiting, r)
+	memo[r] = res
+
+	return res
+}
+
+func sortRunes(runes []rune) {
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+}
+
+func (d *dictionary) Lookup(char string) (*Character, error) {
+	r, _ := utf8.DecodeRuneInString(char)
+
+	c, ok := d.characters[r]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	res := *c
+	res.PhoneticSeries = []string{}
+
+	seen := map[rune]bool{r: true}
+	for _, p := range c.phonetic {
+		for _, sibling := range d.phoneticSeries[p] {
+			if !seen[sibling] {
+				seen[sibling] = true
+				res.PhoneticSeries = append(res.PhoneticSeries, string(sibling))
+			}
+		}
+	}
+
+	return &res, nil
+}
+
+func (d *dictionary) Containing(component string) []string {
+	r, _ := utf8.DecodeRuneInString(component)
+
+	res := make([]string, len(d.containedIn[r]))
+	for i, c := range d.containedIn[r] {
+		res[i] = string(c)
+	}
+
+	return res
+}
+
+type Character struct {
+	Character string
+	// Ideographic description sequences, e.g. ⿰氵可
+	Decompositions []string
+	// Direct components of the first decomposition
+	Components     []string
+	Radical        *Radical
+	StrokeCount    int
+	Definition     string
+	PhoneticSeries []string
+
+	phonetic []string
+}
+
+type Radical struct {
+	Number       int
+	Character    string
+	ExtraStrokes int
+}
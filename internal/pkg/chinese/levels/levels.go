@@ -0,0 +1,222 @@
+package levels
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/siongui/gojianfan"
+)
+
+const (
+	ListTOCFL = "tocfl"
+	ListHSK   = "hsk"
+
+	Unknown = "unknown"
+)
+
+// Levels looks up the TOCFL and HSK level of Chinese words. TOCFL lists are
+// keyed by traditional characters, HSK lists by simplified characters.
+type Levels interface {
+	Lookup(traditional, simplified string) *Result
+}
+
+type levels struct {
+	tocfl map[string]Level
+	hsk   map[string]Level
+}
+
+// New loads the level lists from `tocfl.tsv` and `hsk.tsv` in the given
+// directory. Every line contains a word and its level separated by a tab, e.g.
+// `學生	A1` or `学生	1`. Spelling variants can be separated by a slash.
+// The lists are optional, a missing file is logged and skipped.
+func New(path string) (Levels, error) {
+	tocfl, err := loadList(filepath.Join(path, ListTOCFL+".tsv"), ListTOCFL)
+	if err != nil {
+		return nil, err
+	}
+
+	hsk, err := loadList(filepath.Join(path, ListHSK+".tsv"), ListHSK)
+	if err != nil {
+		return nil, err
+	}
+
+	return &levels{
+		tocfl: tocfl,
+		hsk:   hsk,
+	}, nil
+}
+
+func loadList(path, list string) (map[string]Level, error) {
+	res := map[string]Level{}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		log.Printf("level list %s not found, %s levels won't be available", path, list)
+		return res, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open level list: "+path)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+
+		if strings.TrimSpace(fields[1]) == "" {
+			continue
+		}
+
+		level := newLevel(list, strings.TrimSpace(fields[1]))
+
+		for _, word := range strings.Split(fields[0], "/") {
+			word = strings.TrimSpace(word)
+			if word == "" {
+				continue
+			}
+
+			// Keep the easiest level when a word is listed more than once
+			if existing, ok := res[word]; ok && !easier(level.Level, existing.Level) {
+				continue
+			}
+
+			res[word] = level
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read level list: "+path)
+	}
+
+	return res, nil
+}
+
+func newLevel(list, level string) Level {
+	l := Level{
+		List:  list,
+		Level: strings.ToUpper(level),
+	}
+
+	if list == ListTOCFL {
+		r, _ := utf8.DecodeRuneInString(l.Level)
+		l.Band = string(r)
+	}
+
+	return l
+}
+
+// easier reports whether level a is easier than level b. Levels are compared
+// by their letters first and then by their number, so `2` comes before `10`
+// and `A2` before `B1`.
+func easier(a, b string) bool {
+	prefixA, numberA := splitLevel(a)
+	prefixB, numberB := splitLevel(b)
+
+	if prefixA != prefixB {
+		return prefixA < prefixB
+	}
+
+	return numberA < numberB
+}
+
+// splitLevel splits a level like `A2` or `7-9` into the letters before the
+// number and the number.
+func splitLevel(level string) (string, int) {
+	i := strings.IndexAny(level, "0123456789")
+	if i < 0 {
+		return level, 0
+	}
+
+	end := i
+	for end < len(level) && level[end] >= '0' && level[end] <= '9' {
+		end++
+	}
+
+	n, _ := strconv.Atoi(level[i:end])
+
+	return level[:i], n
+}
+
+func (l *levels) Lookup(traditional, simplified string) *Result {
+	if simplified == "" {
+		simplified = gojianfan.T2S(traditional)
+	}
+
+	res := &Result{}
+
+	if level, ok := l.tocfl[traditional]; ok {
+		res.TOCFL = &level
+	}
+
+	if level, ok := l.hsk[simplified]; ok {
+		res.HSK = &level
+	}
+
+	return res
+}
+
+type Level struct {
+	List  string
+	Level string
+	// Band is only set for TOCFL levels (A, B or C)
+	Band string
+}
+
+type Result struct {
+	TOCFL *Level
+	HSK   *Level
+}
+
+// Histogram counts how many unique words of a text fall in each TOCFL band
+// and HSK level. Words that are not on a list are counted as `unknown`, words
+// that occur more than once are only counted the first time.
+type Histogram struct {
+	TOCFL map[string]int
+	HSK   map[string]int
+	Total int
+
+	seen map[string]bool
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{
+		TOCFL: map[string]int{},
+		HSK:   map[string]int{},
+		seen:  map[string]bool{},
+	}
+}
+
+func (h *Histogram) Add(word string, r *Result) {
+	if h.seen[word] {
+		return
+	}
+	h.seen[word] = true
+
+	h.Total++
+
+	if r.TOCFL != nil {
+		h.TOCFL[r.TOCFL.Band]++
+	} else {
+		h.TOCFL[Unknown]++
+	}
+
+	if r.HSK != nil {
+		h.HSK[r.HSK.Level]++
+	} else {
+		h.HSK[Unknown]++
+	}
+}
@@ -0,0 +1,133 @@
+package levels
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newTestLevels(t *testing.T) Levels {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	tocfl := "# TOCFL\n學生\tA1\n老師/老师\tA2\n學生\tB1\n電腦\t\n研究\tb2\n"
+	hsk := "学生\t1\n研究\t10\n研究\t2\n"
+
+	if err := os.WriteFile(filepath.Join(dir, ListTOCFL+".tsv"), []byte(tocfl), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ListHSK+".tsv"), []byte(hsk), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := New(dir)
+	if err != nil {
+		t.Fatalf("could not load levels: %v", err)
+	}
+
+	return l
+}
+
+func TestLookup(t *testing.T) {
+	l := newTestLevels(t)
+
+	tests := []struct {
+		traditional string
+		simplified  string
+		tocfl       *Level
+		hsk         *Level
+	}{
+		{
+			traditional: "學生",
+			tocfl:       &Level{List: ListTOCFL, Level: "A1", Band: "A"},
+			hsk:         &Level{List: ListHSK, Level: "1"},
+		},
+		{
+			// Spelling variants are separated by a slash
+			traditional: "老师",
+			tocfl:       &Level{List: ListTOCFL, Level: "A2", Band: "A"},
+		},
+		{
+			// The easiest level is kept, 2 comes before 10
+			traditional: "研究",
+			tocfl:       &Level{List: ListTOCFL, Level: "B2", Band: "B"},
+			hsk:         &Level{List: ListHSK, Level: "2"},
+		},
+		{
+			// Lines without a level are skipped
+			traditional: "電腦",
+		},
+		{
+			traditional: "貓",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.traditional, func(t *testing.T) {
+			res := l.Lookup(tt.traditional, tt.simplified)
+
+			if !reflect.DeepEqual(res.TOCFL, tt.tocfl) {
+				t.Errorf("got TOCFL %+v, want %+v", res.TOCFL, tt.tocfl)
+			}
+			if !reflect.DeepEqual(res.HSK, tt.hsk) {
+				t.Errorf("got HSK %+v, want %+v", res.HSK, tt.hsk)
+			}
+		})
+	}
+}
+
+func TestMissingLists(t *testing.T) {
+	l, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if res := l.Lookup("學生", ""); res.TOCFL != nil || res.HSK != nil {
+		t.Errorf("expected no levels, got %+v", res)
+	}
+}
+
+func TestEasier(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		easier bool
+	}{
+		{"2", "10", true},
+		{"10", "2", false},
+		{"A2", "B1", true},
+		{"B1", "A2", false},
+		{"A1", "A1", false},
+		{"7-9", "6", false},
+	}
+
+	for _, tt := range tests {
+		if got := easier(tt.a, tt.b); got != tt.easier {
+			t.Errorf("easier(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.easier)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	l := newTestLevels(t)
+	h := NewHistogram()
+
+	for _, word := range []string{"學生", "研究", "學生", "貓", "學生"} {
+		h.Add(word, l.Lookup(word, ""))
+	}
+
+	if h.Total != 3 {
+		t.Errorf("got total %d, want 3", h.Total)
+	}
+
+	tocfl := map[string]int{"A": 1, "B": 1, Unknown: 1}
+	if !reflect.DeepEqual(h.TOCFL, tocfl) {
+		t.Errorf("got TOCFL %v, want %v", h.TOCFL, tocfl)
+	}
+
+	hsk := map[string]int{"1": 1, "2": 1, Unknown: 1}
+	if !reflect.DeepEqual(h.HSK, hsk) {
+		t.Errorf("got HSK %v, want %v", h.HSK, hsk)
+	}
+}
@@ -0,0 +1,368 @@
+package segmenter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/siongui/gojianfan"
+	"github.com/yanyiwu/gojieba"
+)
+
+var (
+	ErrInvalidEntry  = errors.New("invalid segmentation entry")
+	ErrEntryNotFound = errors.New("segmentation entry not found")
+)
+
+// WordSource returns words that should always be segmented as a single token,
+// e.g. words that were mined before.
+type WordSource func(ctx context.Context) ([]string, error)
+
+// Segmenter splits simplified Chinese text into words with jieba. Besides the
+// default jieba dictionaries it uses a user dictionary with custom entries and
+// the words returned by the word source. Jieba runs on simplified text, so all
+// entries are converted to simplified characters before they are loaded.
+type Segmenter interface {
+	Tokenize(simplified string) []gojieba.Word
+	// AddWord adds a word to the running segmenter without persisting it
+	AddWord(word string)
+
+	Entries() []Entry
+	AddEntry(entry Entry) error
+	RemoveEntry(word string) error
+	Reload(ctx context.Context) error
+}
+
+type segmenter struct {
+	// update serialises changes to the user dictionary, mu guards the jieba
+	// instance which is swapped out when the dictionary changes
+	update sync.Mutex
+	mu     sync.RWMutex
+
+	jieba   *gojieba.Jieba
+	path    string
+	source  WordSource
+	entries map[string]Entry
+	// Words from the word source and AddWord, kept to rebuild jieba
+	words []string
+}
+
+// New creates a segmenter backed by the user dictionary at the given path. The
+// file uses the jieba user dictionary format: `word [frequency] [tag]`.
+func New(ctx context.Context, path string, source WordSource) (Segmenter, error) {
+	s := &segmenter{
+		path:   path,
+		source: source,
+	}
+
+	if err := s.load(ctx, source); err != nil {
+		// The word source might not be available yet, e.g. when the database is
+		// still starting up. Those words will be picked up by the next reload.
+		log.Println("could not load segmenter with mined words:", err)
+
+		if err := s.load(ctx, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *segmenter) Tokenize(simplified string) []gojieba.Word {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	useHMM := true
+	return s.jieba.Tokenize(simplified, gojieba.DefaultMode, useHMM)
+}
+
+// AddWord inserts the word into the dictionary of jieba, which isn't safe to
+// do while it's tokenizing.
+func (s *segmenter) AddWord(word string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jieba.AddWord(gojianfan.T2S(word))
+	s.words = append(s.words, word)
+}
+
+func (s *segmenter) Entries() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedEntries(s.entries)
+}
+
+// AddEntry adds or replaces an entry of the user dictionary. Jieba can only
+// add words without a frequency or tag to a running instance, so a new
+// instance is loaded with the updated dictionary.
+func (s *segmenter) AddEntry(entry Entry) error {
+	entry.Word = strings.TrimSpace(entry.Word)
+	if entry.Word == "" || strings.ContainsAny(entry.Word, " \t\n") {
+		return ErrInvalidEntry
+	}
+
+	s.update.Lock()
+	defer s.update.Unlock()
+
+	s.mu.RLock()
+	entries := copyEntries(s.entries)
+	words := append([]string{}, s.words...)
+	s.mu.RUnlock()
+
+	entries[entry.Word] = entry
+
+	if err := writeEntries(s.path, entries); err != nil {
+		return err
+	}
+
+	jieba, err := newJieba(entries, words)
+	if err != nil {
+		return err
+	}
+
+	s.swap(jieba, entries, words, len(words))
+
+	return nil
+}
+
+func (s *segmenter) RemoveEntry(word string) error {
+	s.update.Lock()
+	defer s.update.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[word]; !ok {
+		return ErrEntryNotFound
+	}
+
+	entries := copyEntries(s.entries)
+	delete(entries, word)
+
+	if err := writeEntries(s.path, entries); err != nil {
+		return err
+	}
+
+	s.entries = entries
+
+	// Jieba removes the word whatever its source, mined words with the same
+	// spelling have to stay in the dictionary
+	simplified := gojianfan.T2S(word)
+	s.jieba.RemoveWord(simplified)
+
+	for _, w := range s.words {
+		if gojianfan.T2S(strings.TrimSpace(w)) == simplified {
+			s.jieba.AddWord(simplified)
+			break
+		}
+	}
+
+	return nil
+}
+
+// swap replaces the jieba instance. Words that were added with AddWord while
+// the new instance was loading are added to it, added is the number of words
+// that were added before it started loading.
+func (s *segmenter) swap(jieba *gojieba.Jieba, entries map[string]Entry, words []string, added int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, word := range s.words[added:] {
+		jieba.AddWord(gojianfan.T2S(word))
+		words = append(words, word)
+	}
+
+	// The previous instance is freed by its finalizer once it's unreachable
+	s.jieba = jieba
+	s.entries = entries
+	s.words = words
+}
+
+// Reload reads the user dictionary and word source again and swaps in a fresh
+// jieba instance.
+func (s *segmenter) Reload(ctx context.Context) error {
+	return s.load(ctx, s.source)
+}
+
+func (s *segmenter) load(ctx context.Context, source WordSource) error {
+	s.update.Lock()
+	defer s.update.Unlock()
+
+	s.mu.RLock()
+	added := len(s.words)
+	s.mu.RUnlock()
+
+	entries, err := readEntries(s.path)
+	if err != nil {
+		return err
+	}
+
+	words := []string{}
+	if source != nil {
+		words, err = source(ctx)
+		if err != nil {
+			return errors.Wrap(err, "could not load words for user dictionary")
+		}
+	}
+
+	jieba, err := newJieba(entries, words)
+	if err != nil {
+		return err
+	}
+
+	s.swap(jieba, entries, words, added)
+
+	return nil
+}
+
+// newJieba writes all entries and words to a temporary dictionary in
+// simplified characters and loads it next to the default user dictionary.
+func newJieba(entries map[string]Entry, words []string) (*gojieba.Jieba, error) {
+	f, err := os.CreateTemp("", "jieba-user-dict-*.utf8")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create user dictionary")
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	for _, e := range sortedEntries(entries) {
+		e.Word = gojianfan.T2S(e.Word)
+		fmt.Fprintln(w, e.String())
+	}
+
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" || strings.ContainsAny(word, " \t\n") {
+			continue
+		}
+		fmt.Fprintln(w, gojianfan.T2S(word))
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "could not write user dictionary")
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not write user dictionary")
+	}
+
+	userDicts := gojieba.USER_DICT_PATH + "|" + f.Name()
+
+	return gojieba.NewJieba(gojieba.DICT_PATH, gojieba.HMM_PATH, userDicts), nil
+}
+
+func readEntries(path string) (map[string]Entry, error) {
+	entries := map[string]Entry{}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open user dictionary")
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := parseEntry(line)
+		entries[entry.Word] = entry
+	}
+
+	return entries, errors.Wrap(scanner.Err(), "could not read user dictionary")
+}
+
+func writeEntries(path string, entries map[string]Entry) error {
+	b := &strings.Builder{}
+	for _, e := range sortedEntries(entries) {
+		b.WriteString(e.String() + "\n")
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return errors.Wrap(err, "could not write user dictionary")
+	}
+
+	return nil
+}
+
+func copyEntries(entries map[string]Entry) map[string]Entry {
+	res := make(map[string]Entry, len(entries))
+	for k, v := range entries {
+		res[k] = v
+	}
+
+	return res
+}
+
+func sortedEntries(entries map[string]Entry) []Entry {
+	res := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, e)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Word < res[j].Word })
+
+	return res
+}
+
+type Entry struct {
+	Word      string
+	Frequency int
+	Tag       string
+}
+
+func parseEntry(line string) Entry {
+	fields := strings.Fields(line)
+	entry := Entry{Word: fields[0]}
+
+	switch len(fields) {
+	case 2:
+		// The frequency and tag are both optional, `word 100` only has a
+		// frequency and `word n` only a tag
+		if frequency, err := strconv.Atoi(fields[1]); err == nil {
+			entry.Frequency = frequency
+		} else {
+			entry.Tag = fields[1]
+		}
+	case 3:
+		entry.Frequency, _ = strconv.Atoi(fields[1])
+		entry.Tag = fields[2]
+	}
+
+	return entry
+}
+
+// unknownTag is the tag jieba uses for words without a part of speech
+const unknownTag = "x"
+
+// String formats the entry as a jieba user dictionary line. Jieba only accepts
+// a frequency when it's followed by a tag.
+func (e Entry) String() string {
+	fields := []string{e.Word}
+
+	if e.Frequency > 0 {
+		fields = append(fields, strconv.Itoa(e.Frequency))
+	}
+
+	if e.Tag != "" {
+		fields = append(fields, e.Tag)
+	} else if e.Frequency > 0 {
+		fields = append(fields, unknownTag)
+	}
+
+	return strings.Join(fields, " ")
+}
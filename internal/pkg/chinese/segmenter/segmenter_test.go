@@ -0,0 +1,28 @@
+package segmenter
+
+import "testing"
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		line  string
+		entry Entry
+		// The line written back to the user dictionary
+		formatted string
+	}{
+		{"台北", Entry{Word: "台北"}, "台北"},
+		{"台北 100", Entry{Word: "台北", Frequency: 100}, "台北 100 x"},
+		{"台北 ns", Entry{Word: "台北", Tag: "ns"}, "台北 ns"},
+		{"台北 100 ns", Entry{Word: "台北", Frequency: 100, Tag: "ns"}, "台北 100 ns"},
+	}
+
+	for _, tt := range tests {
+		entry := parseEntry(tt.line)
+		if entry != tt.entry {
+			t.Errorf("parseEntry(%q) = %+v, want %+v", tt.line, entry, tt.entry)
+		}
+
+		if got := entry.String(); got != tt.formatted {
+			t.Errorf("%+v formatted as %q, want %q", entry, got, tt.formatted)
+		}
+	}
+}
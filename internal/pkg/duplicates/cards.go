@@ -0,0 +1,178 @@
+package duplicates
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+// Kinds of forms stored for a card
+const (
+	kindSpelling = "spelling"
+	kindReading  = "reading"
+)
+
+// Number of cards that get their forms at a time when backfilling
+const backfillBatchSize = 100
+
+// Reading returns the reading in the meta of a card, cards from the Chinese
+// reader only have pinyin.
+func Reading(meta json.RawMessage) string {
+	var m struct {
+		Reading     string `json:"reading"`
+		PinyinTones string `json:"pinyin_tones"`
+	}
+	if err := json.Unmarshal(meta, &m); err != nil {
+		return ""
+	}
+
+	if m.Reading == "" {
+		return m.PinyinTones
+	}
+
+	return m.Reading
+}
+
+// SaveCard stores the forms of a card so it can be found as a duplicate. It
+// has to be called whenever the token or meta of a card changes.
+func (n *Normalizer) SaveCard(ctx context.Context, q *postgres.Queries, id int64, languageCode, token string, meta json.RawMessage) error {
+	if err := q.DeleteCardForms(ctx, id); err != nil {
+		return errors.Wrapf(err, "could not delete forms of card %d", id)
+	}
+
+	forms := n.Forms(languageCode, Word{Token: token, Reading: Reading(meta)})
+
+	save := func(kind string, values []string) error {
+		for _, form := range values {
+			if err := q.CreateCardForm(ctx, postgres.CreateCardFormParams{
+				CardID:       id,
+				LanguageCode: languageCode,
+				Kind:         kind,
+				Form:         form,
+				KanaOnly:     forms.KanaOnly,
+			}); err != nil {
+				return errors.Wrapf(err, "could not save forms of card %d", id)
+			}
+		}
+
+		return nil
+	}
+
+	if err := save(kindSpelling, forms.Spellings); err != nil {
+		return err
+	}
+
+	return save(kindReading, forms.Readings)
+}
+
+// FindCard returns a pending or exported card of the same language that is
+// likely the same word. Cards are looked up by their stored forms, the
+// language is locked until the transaction of q ends so two requests can't
+// add the same word at the same time.
+func (n *Normalizer) FindCard(ctx context.Context, q *postgres.Queries, languageCode string, w Word) (*postgres.ListCardsRow, error) {
+	if err := q.LockCardForms(ctx, languageCode); err != nil {
+		return nil, errors.Wrap(err, "could not lock cards")
+	}
+
+	forms := n.Forms(languageCode, w)
+
+	rows, err := q.FindCardsByForms(ctx, postgres.FindCardsByFormsParams{
+		LanguageCode: languageCode,
+		Spellings:    forms.Spellings,
+		KanaOnly:     forms.KanaOnly,
+		Readings:     nonNil(forms.Readings),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find cards")
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	card := postgres.ListCardsRow(rows[0])
+	return &card, nil
+}
+
+// FindWordToken returns a known word token of the same language that is
+// likely the same word, or nothing. Word tokens are looked up by the forms
+// of the word, so inflected word tokens aren't found.
+func (n *Normalizer) FindWordToken(ctx context.Context, q *postgres.Queries, languageCode string, w Word) (string, error) {
+	forms := n.Forms(languageCode, w)
+
+	candidates := append(append([]string{}, forms.Spellings...), forms.Readings...)
+	if languageCode == "jpn" {
+		for _, c := range candidates {
+			candidates = append(candidates, toKatakana(c))
+		}
+	}
+
+	tokens, err := q.FindWordTokensByForms(ctx, postgres.FindWordTokensByFormsParams{
+		LanguageCode: languageCode,
+		Forms:        unique(candidates...),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "could not find word tokens")
+	}
+
+	for _, t := range tokens {
+		if forms.Matches(n.Forms(languageCode, Word{Token: t})) {
+			return t, nil
+		}
+	}
+
+	return "", nil
+}
+
+// Backfill stores the forms of cards that were created before forms were
+// stored and returns how many cards were updated.
+func (n *Normalizer) Backfill(ctx context.Context, q *postgres.Queries) (int, error) {
+	count := 0
+	afterID := int64(0)
+
+	for {
+		rows, err := q.ListCardsWithoutForms(ctx, postgres.ListCardsWithoutFormsParams{
+			AfterID: afterID,
+			Limit:   backfillBatchSize,
+		})
+		if err != nil {
+			return count, errors.Wrap(err, "could not list cards without forms")
+		}
+
+		if len(rows) == 0 {
+			return count, nil
+		}
+
+		for _, row := range rows {
+			if err := n.SaveCard(ctx, q, row.ID, row.LanguageCode, row.Token, row.Meta); err != nil {
+				return count, err
+			}
+
+			afterID = row.ID
+			count++
+		}
+	}
+}
+
+// toKatakana converts hiragana to katakana, other characters are kept.
+func toKatakana(s string) string {
+	res := []rune(s)
+	for i, r := range res {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			res[i] = r + 0x60
+		}
+	}
+
+	return string(res)
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
@@ -0,0 +1,139 @@
+package duplicates
+
+import (
+	"github.com/siongui/gojianfan"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+)
+
+// Word is a mined word, the reading is optional.
+type Word struct {
+	Token   string
+	Reading string
+}
+
+// Forms are the normalised spellings of a word that are compared to find
+// duplicates.
+type Forms struct {
+	Spellings []string
+
+	// Readings are only compared to words written in kana, two words that
+	// share a reading aren't the same word (橋 and 箸)
+	Readings []string
+	KanaOnly bool
+}
+
+// Matches reports whether two words are likely the same word.
+func (f Forms) Matches(o Forms) bool {
+	if intersects(f.Spellings, o.Spellings) {
+		return true
+	}
+
+	if f.KanaOnly && intersects(f.Spellings, o.Readings) {
+		return true
+	}
+
+	return o.KanaOnly && intersects(o.Spellings, f.Readings)
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// German lemmas that score lower don't fit the capitalisation of the word,
+// like the noun Essen for the verb essen
+const minLemmaScore = 0.5
+
+// Normalizer finds the forms of words by language.
+type Normalizer struct {
+	lemmatizer *lemmatizer.GermanLemmatizer
+}
+
+func New(lemmatizer *lemmatizer.GermanLemmatizer) *Normalizer {
+	return &Normalizer{lemmatizer: lemmatizer}
+}
+
+// Forms returns the forms of a word:
+//   - Chinese: traditional and simplified characters
+//   - Japanese: katakana is compared as hiragana, kanji spellings are
+//     matched with kana spellings through their reading
+//   - German: every likely lemma, so inflected forms match their
+//     dictionary form even when the lemmatizer can't tell which lemma is
+//     meant. Capitalisation is kept, nouns like Essen aren't the same as
+//     verbs like essen
+func (n *Normalizer) Forms(languageCode string, w Word) Forms {
+	switch languageCode {
+	case "zho", "yue":
+		return Forms{Spellings: unique(w.Token, gojianfan.T2S(w.Token), gojianfan.S2T(w.Token))}
+	case "jpn":
+		f := Forms{
+			Spellings: unique(w.Token, toHiragana(w.Token)),
+			KanaOnly:  isKana(w.Token),
+		}
+		if w.Reading != "" {
+			f.Readings = unique(toHiragana(w.Reading))
+		}
+		return f
+	case "deu":
+		spellings := []string{w.Token}
+		for _, c := range n.lemmatizer.Lemmas(w.Token) {
+			if c.Score >= minLemmaScore {
+				spellings = append(spellings, c.Lemma)
+			}
+		}
+		return Forms{Spellings: unique(spellings...)}
+	default:
+		return Forms{Spellings: []string{w.Token}}
+	}
+}
+
+func unique(values ...string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		res = append(res, v)
+	}
+
+	return res
+}
+
+// toHiragana converts katakana to hiragana, other characters are kept.
+func toHiragana(s string) string {
+	res := []rune(s)
+	for i, r := range res {
+		if r >= 'ァ' && r <= 'ヶ' {
+			res[i] = r - 0x60
+		}
+	}
+
+	return string(res)
+}
+
+func isKana(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		hiragana := r >= 'ぁ' && r <= 'ゖ'
+		katakana := r >= 'ァ' && r <= 'ヺ'
+		if !hiragana && !katakana && r != 'ー' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,43 @@
+package duplicates
+
+import (
+	"testing"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+)
+
+func TestMatches(t *testing.T) {
+	n := New(lemmatizer.NewGermanLemmatizer())
+
+	tests := []struct {
+		name         string
+		languageCode string
+		a, b         Word
+		want         bool
+	}{
+		{name: "traditional and simplified", languageCode: "zho", a: Word{Token: "學生"}, b: Word{Token: "学生"}, want: true},
+		{name: "katakana and hiragana", languageCode: "jpn", a: Word{Token: "カワイイ"}, b: Word{Token: "かわいい"}, want: true},
+		{name: "kana and the reading of kanji", languageCode: "jpn", a: Word{Token: "はし"}, b: Word{Token: "橋", Reading: "はし"}, want: true},
+		{name: "kanji that share a reading", languageCode: "jpn", a: Word{Token: "箸", Reading: "はし"}, b: Word{Token: "橋", Reading: "はし"}, want: false},
+		{name: "inflected german noun", languageCode: "deu", a: Word{Token: "Gärten"}, b: Word{Token: "Garten"}, want: true},
+		{name: "inflected german verb", languageCode: "deu", a: Word{Token: "ging"}, b: Word{Token: "gehen"}, want: true},
+		{
+			// Rechte is a noun of its own, but also the plural of Recht
+			name:         "german lemma that isn't the best candidate",
+			languageCode: "deu",
+			a:            Word{Token: "Rechte"},
+			b:            Word{Token: "Recht"},
+			want:         true,
+		},
+		{name: "german noun and verb", languageCode: "deu", a: Word{Token: "Essen"}, b: Word{Token: "essen"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := n.Forms(tt.languageCode, tt.a), n.Forms(tt.languageCode, tt.b)
+			if got := a.Matches(b); got != tt.want {
+				t.Errorf("got %v, want %v (%+v, %+v)", got, tt.want, a, b)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/cardschema"
+	"github.com/antonve/language-learning-tools/internal/pkg/duplicates"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+)
+
+// Statuses of a provider for a card
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusNotFound  = "not_found"
+	StatusFailed    = "failed"
+)
+
+// ErrNotFound is returned by providers that have no results for a card.
+var ErrNotFound = errors.New("no results")
+
+// Card is the card that is being enriched.
+type Card struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+}
+
+// Sentence returns the line the card was mined from, if any.
+func (c Card) Sentence() string {
+	meta := struct {
+		Sentence json.RawMessage `json:"sentence"`
+	}{}
+
+	if err := json.Unmarshal(c.Meta, &meta); err != nil {
+		return ""
+	}
+
+	return cardschema.SentenceLine(meta.Sentence)
+}
+
+// Fields are meta fields found by a provider, keyed by their name in the meta.
+type Fields map[string]interface{}
+
+// Provider looks up information about a card, e.g. its reading or definitions.
+type Provider interface {
+	Name() string
+	Enrich(ctx context.Context, card Card) (Fields, error)
+}
+
+const (
+	batchSize    = 20
+	pollInterval = time.Minute
+)
+
+// Enricher fills in the meta of new cards in the background. Every provider
+// of the language of a card has a status, so failed lookups can be shown and
+// retried. Fields that are in the meta are never overwritten, so anything
+// the user entered, edited or cleared is kept.
+type Enricher struct {
+	psql       *sql.DB
+	queries    *postgres.Queries
+	providers  map[string][]Provider
+	duplicates *duplicates.Normalizer
+	schemas    *cardschema.Validator
+	wake       chan struct{}
+}
+
+func New(psql *sql.DB, providers map[string][]Provider, duplicates *duplicates.Normalizer, schemas *cardschema.Validator) *Enricher {
+	return &Enricher{
+		psql:       psql,
+		queries:    postgres.New(psql),
+		providers:  providers,
+		duplicates: duplicates,
+		schemas:    schemas,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Schedule marks a card for enrichment by all providers of its language. It
+// takes the queries of the transaction the card is created in, call Notify
+// after committing it.
+func (e *Enricher) Schedule(ctx context.Context, q *postgres.Queries, cardID int64, languageCode string) error {
+	for _, p := range e.providers[languageCode] {
+		if err := q.ScheduleCardEnrichment(ctx, postgres.ScheduleCardEnrichmentParams{
+			CardID:   cardID,
+			Provider: p.Name(),
+		}); err != nil {
+			return errors.Wrapf(err, "could not schedule %s", p.Name())
+		}
+	}
+
+	return nil
+}
+
+// Notify wakes up the enricher to process scheduled cards.
+func (e *Enricher) Notify() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes scheduled cards until the context is cancelled. Cards that
+// were scheduled before a restart are picked up as well.
+func (e *Enricher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.processPending(ctx); err != nil {
+			log.Println("could not enrich cards:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Enricher) processPending(ctx context.Context) error {
+	for {
+		ids, err := e.queries.ListPendingEnrichmentCardIDs(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, id := range ids {
+			if err := e.Enrich(ctx, id); err != nil {
+				log.Printf("could not enrich card %d: %v", id, err)
+
+				// Keep the card from being picked up again right away
+				if err := e.fail(ctx, id, err); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// fail marks the pending providers of a card as failed.
+func (e *Enricher) fail(ctx context.Context, cardID int64, cause error) error {
+	statuses, err := e.queries.ListCardEnrichments(ctx, cardID)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range statuses {
+		if s.Status != StatusPending {
+			continue
+		}
+
+		if err := e.queries.UpdateCardEnrichment(ctx, postgres.UpdateCardEnrichmentParams{
+			Status:   StatusFailed,
+			Error:    cause.Error(),
+			Fields:   json.RawMessage("[]"),
+			CardID:   cardID,
+			Provider: s.Provider,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type result struct {
+	provider string
+	status   string
+	err      string
+	fields   Fields
+}
+
+// Enrich runs the pending providers of a card and merges their results into
+// its meta. Providers run in order, so earlier providers win when several of
+// them find the same field.
+func (e *Enricher) Enrich(ctx context.Context, cardID int64) error {
+	card, err := e.queries.GetCard(ctx, cardID)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := e.queries.ListCardEnrichments(ctx, cardID)
+	if err != nil {
+		return err
+	}
+
+	pending := map[string]bool{}
+	for _, s := range statuses {
+		if s.Status == StatusPending {
+			pending[s.Provider] = true
+		}
+	}
+
+	results := []result{}
+	for _, p := range e.providers[card.LanguageCode] {
+		if !pending[p.Name()] {
+			continue
+		}
+		delete(pending, p.Name())
+
+		fields, err := p.Enrich(ctx, Card{
+			ID:           card.ID,
+			LanguageCode: card.LanguageCode,
+			Token:        card.Token,
+			Meta:         card.Meta,
+		})
+
+		switch {
+		case errors.Is(err, ErrNotFound):
+			results = append(results, result{provider: p.Name(), status: StatusNotFound})
+		case err != nil:
+			log.Printf("could not enrich card %d with %s: %v", cardID, p.Name(), err)
+			results = append(results, result{provider: p.Name(), status: StatusFailed, err: err.Error()})
+		default:
+			results = append(results, result{provider: p.Name(), status: StatusSucceeded, fields: fields})
+		}
+	}
+
+	// Providers that were removed from the configuration would stay pending
+	// forever
+	for name := range pending {
+		results = append(results, result{provider: name, status: StatusFailed, err: "unknown provider"})
+	}
+
+	return e.save(ctx, cardID, results)
+}
+
+// save merges the results into the meta as it is now, the user might have
+// edited the card while the providers were running. Results that would make
+// the meta invalid are left out and their provider is marked as failed.
+func (e *Enricher) save(ctx context.Context, cardID int64, results []result) error {
+	tx, err := e.psql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := e.queries.WithTx(tx)
+
+	card, err := qtx.GetCardForUpdate(ctx, cardID)
+	if err != nil {
+		return err
+	}
+
+	meta, err := decodeMeta(card.Meta)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, r := range results {
+		before := copyMeta(meta)
+
+		filled, err := merge(meta, r.fields)
+		if err != nil {
+			return err
+		}
+
+		if len(filled) > 0 {
+			if err := e.validate(card.LanguageCode, meta); err != nil {
+				log.Printf("could not enrich card %d with %s: %v", cardID, r.provider, err)
+
+				meta, filled = before, []string{}
+				r.status, r.err = StatusFailed, err.Error()
+			}
+		}
+		changed = changed || len(filled) > 0
+
+		fields, err := json.Marshal(filled)
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.UpdateCardEnrichment(ctx, postgres.UpdateCardEnrichmentParams{
+			Status:   r.status,
+			Error:    r.err,
+			Fields:   fields,
+			CardID:   cardID,
+			Provider: r.provider,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if changed {
+		after, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+
+		if err := qtx.UpdateCard(ctx, postgres.UpdateCardParams{
+			Meta: after,
+			ID:   cardID,
+		}); err != nil {
+			return err
+		}
+
+		// The reading is used to find duplicates
+		if err := e.duplicates.SaveCard(ctx, qtx, cardID, card.LanguageCode, card.Token, after); err != nil {
+			return err
+		}
+
+		if err := qtx.CreateCardEvent(ctx, postgres.CreateCardEventParams{
+			CardID:    cardID,
+			EventType: "enriched",
+			Before:    emptyObject(card.Meta),
+			After:     after,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// validate checks the meta against the card schema of the language.
+func (e *Enricher) validate(languageCode string, meta map[string]json.RawMessage) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	errs := e.schemas.Validate(languageCode, data)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	invalid := make([]string, len(errs))
+	for i, fe := range errs {
+		invalid[i] = fe.Field + ": " + fe.Message
+	}
+
+	return errors.Errorf("invalid meta: %s", strings.Join(invalid, ", "))
+}
+
+func copyMeta(meta map[string]json.RawMessage) map[string]json.RawMessage {
+	res := make(map[string]json.RawMessage, len(meta))
+	for k, v := range meta {
+		res[k] = v
+	}
+
+	return res
+}
+
+func decodeMeta(raw json.RawMessage) (map[string]json.RawMessage, error) {
+	meta := map[string]json.RawMessage{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return meta, nil
+	}
+
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, errors.Wrap(err, "could not decode meta")
+	}
+
+	return meta, nil
+}
+
+// merge adds fields that are missing in the meta and returns their names.
+// Fields that are present are kept even when they're empty, the user may
+// have cleared them on purpose. Empty values are skipped.
+func merge(meta map[string]json.RawMessage, fields Fields) ([]string, error) {
+	filled := []string{}
+
+	for key, value := range fields {
+		if _, ok := meta[key]; ok || value == "" || value == nil {
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		meta[key] = data
+		filled = append(filled, key)
+	}
+
+	sort.Strings(filled)
+
+	return filled, nil
+}
+
+func emptyObject(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || string(raw) == "null" {
+		return json.RawMessage("{}")
+	}
+
+	return raw
+}
@@ -0,0 +1,276 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jcramb/cedict"
+	"github.com/pkg/errors"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/cantodict"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+	"github.com/antonve/language-learning-tools/internal/pkg/goo"
+	"github.com/antonve/language-learning-tools/internal/pkg/gtranslate"
+	"github.com/antonve/language-learning-tools/internal/pkg/jisho"
+	"github.com/antonve/language-learning-tools/internal/pkg/storage/postgres"
+	"github.com/antonve/language-learning-tools/internal/pkg/zdic"
+)
+
+// formatDefinitions numbers definitions the same way the frontend does.
+func formatDefinitions(defs []string) string {
+	lines := make([]string, len(defs))
+	for i, d := range defs {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, d)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type jishoProvider struct {
+	jisho jisho.Jisho
+}
+
+// Jisho looks up English definitions of Japanese words.
+func Jisho(j jisho.Jisho) Provider {
+	return &jishoProvider{jisho: j}
+}
+
+func (p *jishoProvider) Name() string {
+	return "jisho"
+}
+
+func (p *jishoProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	res, err := p.jisho.Search(card.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Definitions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	defs := make([]string, len(res.Definitions))
+	for i, d := range res.Definitions {
+		defs[i] = d.Meaning
+	}
+
+	return Fields{"definitionEnglish": formatDefinitions(defs)}, nil
+}
+
+type gooProvider struct {
+	goo goo.Goo
+}
+
+// Goo looks up the reading and Japanese definition of Japanese words.
+func Goo(g goo.Goo) Provider {
+	return &gooProvider{goo: g}
+}
+
+func (p *gooProvider) Name() string {
+	return "goo"
+}
+
+func (p *gooProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	res, err := p.goo.Search(card.Token)
+	if errors.Is(err, goo.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Fields{
+		"reading":                  res.Reading,
+		"definitionTargetLanguage": res.Definition,
+	}, nil
+}
+
+type zdicProvider struct {
+	zdic zdic.Zdic
+}
+
+// Zdic looks up the pinyin, zhuyin, audio and Chinese definition of Chinese
+// words.
+func Zdic(z zdic.Zdic) Provider {
+	return &zdicProvider{zdic: z}
+}
+
+func (p *zdicProvider) Name() string {
+	return "zdic"
+}
+
+func (p *zdicProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	res, err := p.zdic.Search(card.Token)
+	if errors.Is(err, zdic.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Fields{
+		"reading":                  res.Pinyin,
+		"zhuyin":                   res.Zhuyin,
+		"audioUrl":                 res.AudioURL,
+		"definitionTargetLanguage": res.Definition,
+	}, nil
+}
+
+type cedictProvider struct {
+	dict *cedict.Dict
+}
+
+// Cedict looks up the pinyin and English definitions of Chinese words.
+func Cedict(dict *cedict.Dict) Provider {
+	return &cedictProvider{dict: dict}
+}
+
+func (p *cedictProvider) Name() string {
+	return "cedict"
+}
+
+func (p *cedictProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	entries := p.dict.GetAllByHanzi(card.Token)
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+
+	readings := []string{}
+	meanings := []string{}
+	for _, e := range entries {
+		readings = append(readings, cedict.PinyinTones(e.Pinyin))
+		meanings = append(meanings, e.Meanings...)
+	}
+
+	return Fields{
+		"reading":           strings.Join(readings, ", "),
+		"definitionEnglish": formatDefinitions(meanings),
+	}, nil
+}
+
+type cantodictProvider struct {
+	dictionary cantodict.Dictionary
+}
+
+// CantoDict looks up the jyutping and English definitions of Cantonese words.
+func CantoDict(dictionary cantodict.Dictionary) Provider {
+	return &cantodictProvider{dictionary: dictionary}
+}
+
+func (p *cantodictProvider) Name() string {
+	return "cantodict"
+}
+
+func (p *cantodictProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	entries := p.dictionary.Lookup(card.Token)
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+
+	readings := []string{}
+	meanings := []string{}
+	for _, e := range entries {
+		readings = append(readings, e.Jyutping)
+		meanings = append(meanings, e.Meanings...)
+	}
+
+	return Fields{
+		"reading":           strings.Join(readings, ", "),
+		"definitionEnglish": formatDefinitions(meanings),
+	}, nil
+}
+
+type germanDictionaryProvider struct {
+	queries    *postgres.Queries
+	lemmatizer *lemmatizer.GermanLemmatizer
+}
+
+// GermanDictionary looks up the gender, plural and English definitions of
+// German words in the imported Wiktionary extract. Inflected words are looked
+// up by their lemma.
+func GermanDictionary(queries *postgres.Queries, lemmatizer *lemmatizer.GermanLemmatizer) Provider {
+	return &germanDictionaryProvider{queries: queries, lemmatizer: lemmatizer}
+}
+
+func (p *germanDictionaryProvider) Name() string {
+	return "german_dictionary"
+}
+
+func (p *germanDictionaryProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	candidates := []string{card.Token}
+	for _, c := range p.lemmatizer.Lemmas(card.Token) {
+		candidates = append(candidates, c.Lemma)
+	}
+
+	for _, lemma := range candidates {
+		rows, err := p.queries.FindGermanWords(ctx, lemma)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows) == 0 {
+			continue
+		}
+
+		fields := Fields{}
+		senses := []string{}
+
+		for _, row := range rows {
+			s := []string{}
+			if err := json.Unmarshal(row.Senses, &s); err != nil {
+				return nil, errors.Wrapf(err, "could not decode senses of %s", row.Lemma)
+			}
+			senses = append(senses, s...)
+
+			// Gender and plural only make sense for nouns
+			if row.Pos == "noun" && row.Gender != "" && fields["gender"] == nil {
+				fields["gender"] = row.Gender
+				fields["plural"] = row.Plural
+			}
+		}
+
+		fields["definitionEnglish"] = formatDefinitions(senses)
+
+		return fields, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// Language codes for Google Translate
+var translateLanguages = map[string]string{
+	"jpn": "ja",
+	"zho": "zh-TW",
+	"yue": "yue",
+	"deu": "de",
+}
+
+type translateProvider struct {
+	gtranslate *gtranslate.GTranslate
+}
+
+// Translate translates the sentence of a card to English.
+func Translate(g *gtranslate.GTranslate) Provider {
+	return &translateProvider{gtranslate: g}
+}
+
+func (p *translateProvider) Name() string {
+	return "gtranslate"
+}
+
+func (p *translateProvider) Enrich(ctx context.Context, card Card) (Fields, error) {
+	sentence := card.Sentence()
+	source, ok := translateLanguages[card.LanguageCode]
+	if sentence == "" || !ok {
+		return nil, ErrNotFound
+	}
+
+	translation, err := p.gtranslate.Translate(ctx, sentence, source, "en")
+	if err != nil {
+		return nil, err
+	}
+
+	return Fields{"sentenceTranslation": translation}, nil
+}
@@ -0,0 +1,243 @@
+package extract
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/publichttp"
+	"github.com/antonve/language-learning-tools/internal/pkg/textfile"
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrNoContent        = errors.New("page has no content")
+	ErrForbiddenAddress = publichttp.ErrForbiddenAddress
+)
+
+// Pages larger than this aren't articles
+const maxPageSize = 10 << 20
+
+// Article is the text of a page, one paragraph per line.
+type Article struct {
+	URL    string
+	Title  string
+	Author string
+	Lines  []string
+	Ruby   []textfile.Ruby
+}
+
+// Content returns the text of an article as it's stored for the reader.
+func (a *Article) Content() string {
+	return strings.Join(a.Lines, "\n")
+}
+
+// Extractor finds the article on the pages of a site.
+type Extractor interface {
+	// Match reports whether the extractor knows the layout of a page
+	Match(u *url.URL) bool
+	Extract(u *url.URL, doc *html.Node) (*Article, error)
+}
+
+// Registry picks the extractor for a URL, pages of unknown sites are handled
+// by the fallback.
+type Registry struct {
+	client     *http.Client
+	extractors []Extractor
+	fallback   Extractor
+}
+
+func NewRegistry(client *http.Client, fallback Extractor) *Registry {
+	return &Registry{
+		client:   client,
+		fallback: fallback,
+	}
+}
+
+// Default returns a registry with all known sites and the readability
+// fallback.
+func Default() *Registry {
+	r := NewRegistry(publichttp.Client(30*time.Second), Readability{})
+	r.Register(Syosetu{})
+	r.Register(Kakuyomu{})
+	r.Register(Aozora{})
+
+	return r
+}
+
+// Register adds an extractor, extractors are tried in the order they were
+// registered.
+func (r *Registry) Register(e Extractor) {
+	r.extractors = append(r.extractors, e)
+}
+
+// Extractor returns the extractor for a URL.
+func (r *Registry) Extractor(u *url.URL) Extractor {
+	for _, e := range r.extractors {
+		if e.Match(u) {
+			return e
+		}
+	}
+
+	return r.fallback
+}
+
+// Extract downloads a page and extracts its article.
+func (r *Registry) Extract(ctx context.Context, rawURL string) (*Article, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.Wrap(ErrInvalidURL, rawURL)
+	}
+
+	doc, err := r.fetch(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ExtractDocument(u, doc)
+}
+
+// ExtractDocument extracts the article from a page that was already
+// downloaded.
+func (r *Registry) ExtractDocument(u *url.URL, doc *html.Node) (*Article, error) {
+	article, err := r.Extractor(u).Extract(u, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(article.Lines) == 0 {
+		return nil, errors.Wrap(ErrNoContent, u.String())
+	}
+
+	if article.Title == "" {
+		article.Title = pageTitle(doc)
+	}
+	if article.Title == "" {
+		article.Title = u.String()
+	}
+
+	article.URL = u.String()
+
+	return article, nil
+}
+
+func (r *Registry) fetch(ctx context.Context, u *url.URL) (*html.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create request")
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; language-learning-tools)")
+
+	res, err := r.client.Do(req)
+	if errors.Is(err, ErrForbiddenAddress) {
+		return nil, errors.Wrapf(ErrForbiddenAddress, "could not fetch %s: %v", u, err)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "could not fetch %s", u)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not fetch %s: status %d", u, res.StatusCode)
+	}
+
+	// Older Japanese sites like Aozora Bunko are still in Shift JIS
+	body, err := charset.NewReader(io.LimitReader(res.Body, maxPageSize), res.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not detect encoding")
+	}
+
+	doc, err := html.Parse(body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", u)
+	}
+
+	return doc, nil
+}
+
+// xpathExtractor extracts articles with XPath expressions, expressions are
+// tried in order so old and new layouts of a site can be supported.
+type xpathExtractor struct {
+	title  []string
+	author []string
+	body   []string
+
+	// Keep line breaks on empty lines as blank lines
+	blankLines bool
+}
+
+func (e xpathExtractor) Extract(u *url.URL, doc *html.Node) (*Article, error) {
+	body := findOne(doc, e.body)
+	if body == nil {
+		return nil, errors.Wrapf(ErrNoContent, "no article body on %s", u)
+	}
+
+	chapter := textfile.FromNode(body)
+	if e.blankLines {
+		chapter = textfile.FromNodeWithBlankLines(body)
+	}
+
+	return &Article{
+		Title:  innerText(findOne(doc, e.title)),
+		Author: innerText(findOne(doc, e.author)),
+		Lines:  chapter.Lines,
+		Ruby:   chapter.Ruby,
+	}, nil
+}
+
+func findOne(doc *html.Node, exprs []string) *html.Node {
+	for _, expr := range exprs {
+		if n := htmlquery.FindOne(doc, expr); n != nil {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// innerText returns the text of a node without ruby readings.
+func innerText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+
+	return strings.Join(textfile.FromNode(n).Lines, " ")
+}
+
+func pageTitle(doc *html.Node) string {
+	if title := ogTitle(doc); title != "" {
+		return title
+	}
+
+	if n := htmlquery.FindOne(doc, "//title"); n != nil {
+		return strings.Join(strings.Fields(htmlquery.InnerText(n)), " ")
+	}
+
+	return ""
+}
+
+func ogTitle(doc *html.Node) string {
+	if n := htmlquery.FindOne(doc, `//meta[@property="og:title"]`); n != nil {
+		return strings.TrimSpace(htmlquery.SelectAttr(n, "content"))
+	}
+
+	return ""
+}
+
+func hostIs(u *url.URL, hosts ...string) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+
+	return false
+}
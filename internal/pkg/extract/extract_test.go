@@ -0,0 +1,215 @@
+package extract
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/htmlfixture"
+	"github.com/antonve/language-learning-tools/internal/pkg/publichttp"
+	"github.com/antonve/language-learning-tools/internal/pkg/textfile"
+)
+
+// Recorded pages have different text than the hand-written fixtures, update
+// the expected articles after recording them
+var update = flag.Bool("update", false, "record the fixtures of the sites that serve them")
+
+func parseFixture(t *testing.T, name string) *html.Node {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("could not open fixture: %v", err)
+	}
+	defer f.Close()
+
+	doc, err := html.Parse(f)
+	if err != nil {
+		t.Fatalf("could not parse fixture: %v", err)
+	}
+
+	return doc
+}
+
+func TestExtractDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		fixture string
+		// Whether the fixture can be recorded from the url
+		recordable bool
+		article    Article
+	}{
+		{
+			name:       "syosetu",
+			url:        "https://ncode.syosetu.com/n9669bk/1/",
+			fixture:    "syosetu.html",
+			recordable: true,
+			article: Article{
+				Title:  "プロローグ",
+				Author: "理不尽な孫の手",
+				Lines:  []string{"　俺は三十四歳で無職だ。", "　家を追い出された。"},
+				Ruby:   []textfile.Ruby{{Line: 0, Offset: 8, Base: "無職", Reading: "むしょく"}},
+			},
+		},
+		{
+			name:    "syosetu before the redesign",
+			url:     "https://ncode.syosetu.com/n6316bn/1/",
+			fixture: "syosetu_old.html",
+			article: Article{
+				Title:  "第一話　竜との出会い",
+				Author: "伏瀬",
+				Lines:  []string{"　目が覚めると、洞窟の中にいた。", "　何も見えない。"},
+				Ruby:   []textfile.Ruby{{Line: 0, Offset: 8, Base: "洞窟", Reading: "どうくつ"}},
+			},
+		},
+		{
+			name:    "kakuyomu",
+			url:     "https://kakuyomu.jp/works/1177354054881165840/episodes/1177354054881165873",
+			fixture: "kakuyomu.html",
+			article: Article{
+				Title:  "第1話　春の雨",
+				Author: "山田花子",
+				Lines:  []string{"　雨が降っていた。", "　傘を忘れた。"},
+				Ruby:   []textfile.Ruby{{Line: 1, Offset: 1, Base: "傘", Reading: "かさ"}},
+			},
+		},
+		{
+			name:    "news site",
+			url:     "https://www.stadtzeitung.example/lokales/neue-bruecke",
+			fixture: "news.html",
+			article: Article{
+				Title:  "Neue Brücke eröffnet",
+				Author: "Anna Schmidt",
+				Lines: []string{
+					"Nach drei Jahren Bauzeit ist die neue Brücke über den Fluss am Montag eröffnet worden.",
+					"Der Bürgermeister sprach von einem wichtigen Tag für die ganze Stadt.",
+					"Foto: dpa",
+					"Fußgänger und Radfahrer können die Brücke ab sofort nutzen, Autos erst ab Juni.",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("invalid url: %v", err)
+			}
+
+			if *update && tt.recordable {
+				if err := htmlfixture.Record(tt.url, filepath.Join("testdata", tt.fixture)); err != nil {
+					t.Fatalf("could not record fixture: %v", err)
+				}
+			}
+
+			article, err := Default().ExtractDocument(u, parseFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.article.URL = tt.url
+			if len(article.Ruby) == 0 {
+				article.Ruby = nil
+			}
+
+			if !reflect.DeepEqual(*article, tt.article) {
+				t.Errorf("got %+v, want %+v", *article, tt.article)
+			}
+		})
+	}
+}
+
+func TestExtractBlankLines(t *testing.T) {
+	u, _ := url.Parse("https://ncode.syosetu.com/n6316bn/1/")
+
+	article, err := Syosetu{KeepBlankLines: true}.Extract(u, parseFixture(t, "syosetu_old.html"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := []string{"　目が覚めると、洞窟の中にいた。", "", "　何も見えない。"}
+	if !reflect.DeepEqual(article.Lines, lines) {
+		t.Errorf("got lines %q, want %q", article.Lines, lines)
+	}
+}
+
+func TestExtractShiftJIS(t *testing.T) {
+	// The encoding is what's tested, so the page is recorded as it's served
+	if *update {
+		if err := htmlfixture.RecordRaw("https://www.aozora.gr.jp/cards/000148/files/789_14547.html", filepath.Join("testdata", "aozora_sjis.html")); err != nil {
+			t.Fatalf("could not record fixture: %v", err)
+		}
+	}
+
+	page, err := os.ReadFile(filepath.Join("testdata", "aozora_sjis.html"))
+	if err != nil {
+		t.Fatalf("could not read fixture: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write(page)
+	}))
+	defer srv.Close()
+
+	// The test server isn't on Aozora Bunko, so its extractor is the fallback
+	r := NewRegistry(srv.Client(), Aozora{})
+
+	article, err := r.Extract(context.Background(), srv.URL+"/cards/000148/files/789_14547.html")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if article.Title != "吾輩は猫である" || article.Author != "夏目漱石" {
+		t.Errorf("got title %q by %q", article.Title, article.Author)
+	}
+
+	lines := []string{"一", "　吾輩は猫である。名前はまだ無い。", "　どこで生れたかとんと見当がつかぬ。"}
+	if !reflect.DeepEqual(article.Lines, lines) {
+		t.Errorf("got lines %q, want %q", article.Lines, lines)
+	}
+
+	ruby := []textfile.Ruby{
+		{Line: 1, Offset: 1, Base: "吾輩", Reading: "わがはい"},
+		{Line: 2, Offset: 11, Base: "見当", Reading: "けんとう"},
+	}
+	if !reflect.DeepEqual(article.Ruby, ruby) {
+		t.Errorf("got ruby %+v, want %+v", article.Ruby, ruby)
+	}
+}
+
+func TestExtractNoContent(t *testing.T) {
+	u, _ := url.Parse("https://kakuyomu.jp/works/1")
+
+	_, err := Default().ExtractDocument(u, parseFixture(t, "news.html"))
+	if errors.Cause(err) != ErrNoContent {
+		t.Errorf("expected ErrNoContent, got %v", err)
+	}
+}
+
+func TestPublicClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<p>internal</p>"))
+	}))
+	defer srv.Close()
+
+	r := NewRegistry(publichttp.Client(time.Second), Readability{})
+
+	for _, u := range []string{srv.URL, "http://localhost:1/", "http://[::1]:1/"} {
+		if _, err := r.Extract(context.Background(), u); errors.Cause(err) != ErrForbiddenAddress {
+			t.Errorf("%s: expected ErrForbiddenAddress, got %v", u, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package extract
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/textfile"
+)
+
+// Readability finds the article on pages of unknown sites. Like the
+// readability algorithm of browsers, paragraphs score their parent elements by
+// the amount of text they contain and the element with the highest score is
+// the article.
+type Readability struct{}
+
+func (Readability) Match(u *url.URL) bool {
+	return true
+}
+
+var (
+	positiveClass = regexp.MustCompile(`(?i)article|body|content|entry|main|news|post|story|text`)
+	negativeClass = regexp.MustCompile(`(?i)ad-|banner|combx|comment|footer|footnote|menu|meta|nav|related|share|sidebar|social|sponsor|widget`)
+)
+
+// Elements that never contain the article
+var unlikelyElements = map[atom.Atom]bool{
+	atom.Aside:    true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Header:   true,
+	atom.Nav:      true,
+	atom.Noscript: true,
+	atom.Script:   true,
+	atom.Style:    true,
+}
+
+// Paragraphs shorter than this are most likely captions or links
+const minParagraphLength = 10
+
+func (Readability) Extract(u *url.URL, doc *html.Node) (*Article, error) {
+	scores := map[*html.Node]float64{}
+
+	for _, p := range htmlquery.Find(doc, "//p") {
+		if unlikely(p) {
+			continue
+		}
+
+		length := utf8.RuneCountInString(strings.TrimSpace(htmlquery.InnerText(p)))
+		if length < minParagraphLength {
+			continue
+		}
+
+		score := 1 + float64(length)/100
+		if parent := p.Parent; parent != nil {
+			scores[parent] += score
+			if grandparent := parent.Parent; grandparent != nil {
+				scores[grandparent] += score / 2
+			}
+		}
+	}
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score *= classWeight(n)
+		if score > bestScore || (score == bestScore && best != nil && contains(n, best)) {
+			best, bestScore = n, score
+		}
+	}
+
+	if best == nil {
+		return nil, errors.Wrapf(ErrNoContent, "no article on %s", u)
+	}
+
+	chapter := textfile.FromNode(best)
+
+	article := &Article{
+		Lines: chapter.Lines,
+		Ruby:  chapter.Ruby,
+	}
+
+	// The title tag often contains the name of the site as well
+	article.Title = ogTitle(doc)
+	if h1 := htmlquery.FindOne(doc, "//h1"); article.Title == "" && h1 != nil && !unlikely(h1) {
+		article.Title = innerText(h1)
+	}
+
+	if n := htmlquery.FindOne(doc, `//meta[@name="author"]`); n != nil {
+		article.Author = strings.TrimSpace(htmlquery.SelectAttr(n, "content"))
+	}
+
+	return article, nil
+}
+
+// unlikely reports whether a node is part of the navigation, comments or
+// another part of the page that isn't the article.
+func unlikely(n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+
+		if unlikelyElements[n.DataAtom] {
+			return true
+		}
+
+		if n.DataAtom != atom.Body && n.DataAtom != atom.Html && classWeight(n) < 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func classWeight(n *html.Node) float64 {
+	names := htmlquery.SelectAttr(n, "class") + " " + htmlquery.SelectAttr(n, "id")
+
+	weight := 1.0
+	if negativeClass.MatchString(names) {
+		weight -= 0.5
+	}
+	if positiveClass.MatchString(names) {
+		weight += 0.25
+	}
+
+	return weight
+}
+
+func contains(parent, child *html.Node) bool {
+	for n := child; n != nil; n = n.Parent {
+		if n == parent {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"net/url"
+
+	"golang.org/x/net/html"
+)
+
+// Syosetu extracts chapters of web novels on ncode.syosetu.com and
+// novel18.syosetu.com.
+type Syosetu struct {
+	// Keep the blank lines between paragraphs, the reader doesn't show them
+	// but they separate scenes in the scraped text files
+	KeepBlankLines bool
+}
+
+func (Syosetu) Match(u *url.URL) bool {
+	return hostIs(u, "syosetu.com")
+}
+
+func (s Syosetu) Extract(u *url.URL, doc *html.Node) (*Article, error) {
+	return xpathExtractor{
+		title: []string{
+			`//p[@class="novel_subtitle"]`,
+			`//h1[contains(@class, "p-novel__title")]`,
+		},
+		author: []string{
+			`//div[@class="contents1"]/a[contains(@href, "mypage")]`,
+			`//div[contains(@class, "c-announce")]/a[contains(@href, "mypage")]`,
+		},
+		body: []string{
+			`//div[@id="novel_honbun"]`,
+			`//div[contains(@class, "p-novel__text") and not(contains(@class, "p-novel__text--preface")) and not(contains(@class, "p-novel__text--afterword"))]`,
+		},
+		blankLines: s.KeepBlankLines,
+	}.Extract(u, doc)
+}
+
+// Kakuyomu extracts episodes of web novels on kakuyomu.jp.
+type Kakuyomu struct{}
+
+func (Kakuyomu) Match(u *url.URL) bool {
+	return hostIs(u, "kakuyomu.jp")
+}
+
+func (Kakuyomu) Extract(u *url.URL, doc *html.Node) (*Article, error) {
+	return xpathExtractor{
+		title: []string{
+			`//p[contains(@class, "widget-episodeTitle")]`,
+		},
+		author: []string{
+			`//p[@id="contentMain-header-author"]`,
+		},
+		body: []string{
+			`//div[contains(@class, "widget-episodeBody")]`,
+		},
+	}.Extract(u, doc)
+}
+
+// Aozora extracts works on Aozora Bunko, the ruby of the work is kept.
+type Aozora struct{}
+
+func (Aozora) Match(u *url.URL) bool {
+	return hostIs(u, "aozora.gr.jp")
+}
+
+func (Aozora) Extract(u *url.URL, doc *html.Node) (*Article, error) {
+	return xpathExtractor{
+		title: []string{
+			`//h1[@class="title"]`,
+		},
+		author: []string{
+			`//h2[@class="author"]`,
+		},
+		body: []string{
+			`//div[@class="main_text"]`,
+		},
+	}.Extract(u, doc)
+}
@@ -0,0 +1,115 @@
+package frequency
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// List ranks the words of a language by how often they occur in a corpus,
+// the most common word has rank 1.
+type List struct {
+	ranks map[string]int
+}
+
+// Lists are the frequency lists by language code.
+type Lists map[string]*List
+
+// Load reads the frequency lists in a directory, one file per language named
+// after its language code, e.g. `jpn.txt`. Every line contains a word, most
+// common words first. Anything after a tab is ignored so lists with counts
+// can be used as is. A missing directory means there are no lists.
+func Load(path string) (Lists, error) {
+	res := Lists{}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.txt"))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find frequency lists")
+	}
+
+	for _, file := range files {
+		list, err := loadList(file)
+		if err != nil {
+			return nil, err
+		}
+
+		res[strings.TrimSuffix(filepath.Base(file), ".txt")] = list
+	}
+
+	return res, nil
+}
+
+func loadList(path string) (*List, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open frequency list: "+path)
+	}
+	defer f.Close()
+
+	list := &List{ranks: map[string]int{}}
+	rank := 0
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word, _, _ := strings.Cut(scanner.Text(), "\t")
+		word = strings.TrimSpace(strings.TrimPrefix(word, "\ufeff"))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+
+		rank++
+
+		// Keep the best rank when a word is listed more than once
+		if _, ok := list.ranks[word]; !ok {
+			list.ranks[word] = rank
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read frequency list: "+path)
+	}
+
+	return list, nil
+}
+
+// Rank returns the rank of the first of the given spellings that's in the
+// list, words that aren't listed have rank 0. Lists that are lowercased are
+// supported as well, e.g. for German nouns.
+func (l *List) Rank(spellings ...string) int {
+	if l == nil {
+		return 0
+	}
+
+	for _, s := range spellings {
+		if rank, ok := l.ranks[s]; ok {
+			return rank
+		}
+	}
+
+	for _, s := range spellings {
+		if rank, ok := l.ranks[strings.ToLower(s)]; ok {
+			return rank
+		}
+	}
+
+	return 0
+}
+
+// Has reports whether a word is in the list.
+func (l *List) Has(word string) bool {
+	if l == nil {
+		return false
+	}
+
+	_, ok := l.ranks[word]
+	return ok
+}
+
+// Rank returns the rank of a word in the list of a language, 0 when there's
+// no list for the language or the word isn't listed.
+func (l Lists) Rank(languageCode string, spellings ...string) int {
+	return l[languageCode].Rank(spellings...)
+}
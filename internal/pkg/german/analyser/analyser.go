@@ -0,0 +1,302 @@
+package analyser
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/german/compound"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+)
+
+// Part of speech guesses, they're based on capitalisation, a list of function
+// words and the lemma dictionary so they can be wrong.
+const (
+	POSNoun        = lemmatizer.POSNoun
+	POSVerb        = lemmatizer.POSVerb
+	POSAdjective   = lemmatizer.POSAdjective
+	POSAdverb      = "adverb"
+	POSDeterminer  = "determiner"
+	POSPronoun     = "pronoun"
+	POSPreposition = "preposition"
+	POSConjunction = "conjunction"
+	POSParticle    = "particle"
+	POSNumber      = "number"
+	POSPunctuation = "punctuation"
+	POSUnknown     = "unknown"
+)
+
+var functionWords = map[string]string{}
+
+func init() {
+	add := func(pos string, words string) {
+		for _, w := range strings.Fields(words) {
+			functionWords[w] = pos
+		}
+	}
+
+	add(POSDeterminer, "der die das des dem den ein eine einer eines einem einen kein keine keiner keines keinem keinen "+
+		"mein meine meiner meines meinem meinen dein deine deiner deines deinem deinen sein seine seiner seines seinem seinen "+
+		"ihr ihre ihrer ihres ihrem ihren unser unsere unserer unseres unserem unseren euer eure eurer eures eurem euren "+
+		"dieser diese dieses diesem diesen jeder jede jedes jedem jeden")
+	add(POSPronoun, "ich du er sie es wir mich dich sich uns euch mir dir ihn ihm ihnen man jemand niemand etwas nichts "+
+		"wer was wem wen wessen")
+	add(POSPreposition, "in im ins an am ans auf aus bei beim mit nach seit von vom zu zum zur durch für gegen ohne um "+
+		"über unter vor hinter neben zwischen wegen trotz während statt bis ab")
+	add(POSConjunction, "und oder aber denn sondern dass ob weil wenn als da damit obwohl während bevor nachdem "+
+		"sobald falls doch")
+	add(POSParticle, "nicht ja nein auch noch schon nur sehr mal gern gerne eben halt")
+	add(POSAdverb, "heute morgen gestern jetzt dann bald immer nie oft hier dort dahin wieder sofort vielleicht "+
+		"leider bereits zuerst danach")
+	add(POSNumber, "null eins zwei drei vier fünf sechs sieben acht neun zehn elf zwölf zwanzig dreißig hundert tausend")
+}
+
+// Conjunctions that start a new clause, the finite verb of a main clause
+// never has its prefix after one of them
+var clauseConjunctions = map[string]bool{}
+
+func init() {
+	for _, c := range strings.Fields("und oder aber denn sondern dass ob weil wenn als da damit obwohl während bevor " +
+		"nachdem sobald falls") {
+		clauseConjunctions[c] = true
+	}
+}
+
+// Prefixes that split off from their verb in main clauses, e.g. anrufen → ruft
+// ... an
+var separablePrefixes = map[string]bool{}
+
+func init() {
+	for _, p := range strings.Fields("ab an auf aus bei dar ein empor fest fort her herab heran herauf heraus herein " +
+		"herüber herum herunter hervor heim hin hinab hinauf hinaus hinein hinzu los mit nach nieder statt teil vor vorbei " +
+		"voran voraus vorbei weg weiter zu zurecht zurück zusammen") {
+		separablePrefixes[p] = true
+	}
+}
+
+var (
+	tokenPattern    = regexp.MustCompile(`[\p{L}\p{M}]+(?:[-'’][\p{L}\p{M}]+)*|\p{N}+(?:[.,]\p{N}+)*|[^\s\p{L}\p{M}\p{N}]`)
+	sentenceEndings = map[string]bool{".": true, "!": true, "?": true, "…": true}
+)
+
+// Analyser splits German text into sentences and tokens and lemmatises them.
+type Analyser struct {
+	lemmatizer *lemmatizer.GermanLemmatizer
+	splitter   *compound.Splitter
+
+	// Separable verbs from the German dictionary, in addition to the ones the
+	// lemmatizer knows
+	separableVerbs map[string]bool
+}
+
+// New creates an analyser. Separable verbs are only linked when the lemmatizer
+// or the list of separable verbs knows the combined verb, e.g. the verbs in
+// the imported German dictionary.
+func New(lemmatizer *lemmatizer.GermanLemmatizer, splitter *compound.Splitter, separableVerbs []string) *Analyser {
+	verbs := make(map[string]bool, len(separableVerbs))
+	for _, v := range separableVerbs {
+		verbs[v] = true
+	}
+
+	return &Analyser{
+		lemmatizer:     lemmatizer,
+		splitter:       splitter,
+		separableVerbs: verbs,
+	}
+}
+
+type Sentence struct {
+	Text   string
+	Start  int
+	End    int
+	Tokens []Token
+}
+
+type Token struct {
+	Text       string
+	Start      int
+	End        int
+	Lemma      string
+	Candidates []lemmatizer.Candidate
+	POS        string
+
+	// Partner is the index of the other part of a separable verb within the
+	// sentence, or -1 when the token isn't part of one
+	Partner int
+}
+
+// Analyse returns the sentences of a text. Offsets are byte offsets into the
+// original text.
+func (a *Analyser) Analyse(text string) []Sentence {
+	res := []Sentence{}
+	current := []Token{}
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+
+		start, end := current[0].Start, current[len(current)-1].End
+		a.linkSeparableVerbs(current)
+
+		res = append(res, Sentence{
+			Text:   text[start:end],
+			Start:  start,
+			End:    end,
+			Tokens: current,
+		})
+		current = []Token{}
+	}
+
+	lastEnd := 0
+	for _, loc := range tokenPattern.FindAllStringIndex(text, -1) {
+		// Sentences never continue over a blank line
+		if strings.Count(text[lastEnd:loc[0]], "\n") > 1 {
+			flush()
+		}
+		lastEnd = loc[1]
+
+		t := Token{
+			Text:    text[loc[0]:loc[1]],
+			Start:   loc[0],
+			End:     loc[1],
+			Partner: -1,
+		}
+		a.annotate(&t, len(current) == 0)
+		current = append(current, t)
+
+		if sentenceEndings[t.Text] {
+			flush()
+		}
+	}
+	flush()
+
+	return res
+}
+
+func (a *Analyser) annotate(t *Token, sentenceStart bool) {
+	r, _ := utf8.DecodeRuneInString(t.Text)
+
+	switch {
+	case unicode.IsNumber(r):
+		t.POS = POSNumber
+		return
+	case !unicode.IsLetter(r):
+		t.POS = POSPunctuation
+		return
+	}
+
+	lower := strings.ToLower(t.Text)
+	capitalised := unicode.IsUpper(r)
+
+	if pos, ok := functionWords[lower]; ok && !(capitalised && !sentenceStart) {
+		t.POS = pos
+		t.Lemma = lower
+
+		// Articles are looked up by their nominative form
+		if pos == POSDeterminer {
+			t.Lemma = a.lemmatizer.Lemma(lower)
+		}
+
+		t.Candidates = []lemmatizer.Candidate{{Lemma: t.Lemma, POS: pos, Score: 1}}
+
+		return
+	}
+
+	if !a.lemmatizer.InDict(t.Text) {
+		t.Lemma = t.Text
+		t.Candidates = []lemmatizer.Candidate{}
+
+		t.POS = POSUnknown
+		if capitalised {
+			t.POS = POSNoun
+		}
+
+		if splits := a.splitter.Split(t.Text); len(splits) > 0 {
+			t.Lemma = splits[0].Lemma()
+
+			t.Candidates = append(t.Candidates, lemmatizer.Candidate{Lemma: t.Lemma, POS: t.POS, Score: splits[0].Score})
+		}
+
+		return
+	}
+
+	if sentenceStart {
+		t.Candidates = a.lemmatizer.LemmasAtSentenceStart(t.Text)
+	} else {
+		t.Candidates = a.lemmatizer.Lemmas(t.Text)
+	}
+
+	t.Lemma, t.POS = lower, POSUnknown
+	if len(t.Candidates) > 0 {
+		t.Lemma, t.POS = t.Candidates[0].Lemma, t.Candidates[0].POS
+	}
+}
+
+// linkSeparableVerbs finds clause-final prefixes like in "Er ruft morgen an"
+// and links them to the finite verb of the clause. Clauses end at punctuation
+// and at conjunctions.
+func (a *Analyser) linkSeparableVerbs(tokens []Token) {
+	clauseStart := 0
+
+	for i, t := range tokens {
+		if t.POS != POSPunctuation && !(t.POS == POSConjunction && clauseConjunctions[strings.ToLower(t.Text)]) {
+			continue
+		}
+
+		a.linkSeparableVerb(tokens, clauseStart, i)
+		clauseStart = i + 1
+	}
+
+	a.linkSeparableVerb(tokens, clauseStart, len(tokens))
+}
+
+func (a *Analyser) linkSeparableVerb(tokens []Token, start, end int) {
+	if end-start < 2 {
+		return
+	}
+
+	last := end - 1
+	prefix := tokens[last].Text
+	if !separablePrefixes[prefix] {
+		return
+	}
+
+	for i := start; i < last; i++ {
+		for _, l := range verbCandidates(tokens[i], i == start) {
+			verb := prefix + l
+			if !a.lemmatizer.InDict(verb) && !a.separableVerbs[verb] {
+				continue
+			}
+
+			t := &tokens[i]
+			t.Lemma, t.POS, t.Partner = verb, POSVerb, last
+			tokens[last].Lemma, tokens[last].POS, tokens[last].Partner = verb, POSVerb, i
+
+			return
+		}
+	}
+}
+
+// verbCandidates returns the infinitives a token could belong to. Imperatives
+// like "Ruf" aren't always in the dictionary so their stem is tried as well.
+func verbCandidates(t Token, clauseStart bool) []string {
+	// Only the first word of a clause can be a capitalised verb
+	if !clauseStart && t.Text != strings.ToLower(t.Text) {
+		return nil
+	}
+
+	if functionWords[strings.ToLower(t.Text)] != "" {
+		return nil
+	}
+
+	res := []string{}
+	for _, c := range t.Candidates {
+		if c.POS == POSVerb {
+			res = append(res, c.Lemma)
+		}
+	}
+
+	return append(res, strings.ToLower(t.Text)+"en")
+}
@@ -0,0 +1,129 @@
+package analyser
+
+import (
+	"testing"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/german/compound"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/lemmatizer"
+)
+
+var testLemmatizer = lemmatizer.NewGermanLemmatizer()
+
+func newTestAnalyser(separableVerbs ...string) *Analyser {
+	return New(testLemmatizer, compound.New(testLemmatizer), separableVerbs)
+}
+
+// token finds the first token with the given text in the sentences
+func token(t *testing.T, sentences []Sentence, text string) Token {
+	t.Helper()
+
+	for _, s := range sentences {
+		for _, tok := range s.Tokens {
+			if tok.Text == text {
+				return tok
+			}
+		}
+	}
+
+	t.Fatalf("token %q not found", text)
+	return Token{}
+}
+
+func TestSeparableVerbs(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		separableVerbs []string
+		// Lemma and part of speech of every token that's checked
+		lemmas map[string]string
+		pos    map[string]string
+	}{
+		{
+			name:   "prefix at the end of the sentence",
+			text:   "Er ruft morgen an.",
+			lemmas: map[string]string{"ruft": "anrufen", "an": "anrufen"},
+			pos:    map[string]string{"ruft": POSVerb, "an": POSVerb},
+		},
+		{
+			name:   "clauses end at a comma",
+			text:   "Er ruft an, sie schläft.",
+			lemmas: map[string]string{"ruft": "anrufen", "schläft": "schlafen"},
+		},
+		{
+			name:           "clauses end at coordinating conjunctions",
+			text:           "Das Auto fährt ab und wir gehen mit.",
+			separableVerbs: []string{"mitgehen"},
+			lemmas:         map[string]string{"fährt": "abfahren", "ab": "abfahren", "gehen": "mitgehen", "mit": "mitgehen"},
+		},
+		{
+			name:   "clauses end at subordinating conjunctions",
+			text:   "Er fährt ab weil sie ruft an.",
+			lemmas: map[string]string{"fährt": "abfahren", "ruft": "anrufen"},
+		},
+		{
+			name:   "combinations that aren't in a dictionary aren't linked",
+			text:   "Das Auto fährt ab und wir gehen mit.",
+			lemmas: map[string]string{"fährt": "abfahren", "gehen": "gehen", "mit": "mit"},
+			pos:    map[string]string{"mit": POSPreposition},
+		},
+		{
+			name:   "unknown verbs aren't made up from a prefix",
+			text:   "Sie schläft auf.",
+			lemmas: map[string]string{"schläft": "schlafen", "auf": "auf"},
+			pos:    map[string]string{"auf": POSPreposition},
+		},
+		{
+			name:   "numbers",
+			text:   "Sie hat sieben Katzen.",
+			lemmas: map[string]string{"sieben": "sieben", "Katzen": "Katze"},
+			pos:    map[string]string{"sieben": POSNumber, "Katzen": POSNoun},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentences := newTestAnalyser(tt.separableVerbs...).Analyse(tt.text)
+
+			for text, lemma := range tt.lemmas {
+				if got := token(t, sentences, text).Lemma; got != lemma {
+					t.Errorf("%s: got lemma %q, want %q", text, got, lemma)
+				}
+			}
+
+			for text, pos := range tt.pos {
+				if got := token(t, sentences, text).POS; got != pos {
+					t.Errorf("%s: got part of speech %q, want %q", text, got, pos)
+				}
+			}
+		})
+	}
+}
+
+func TestPartners(t *testing.T) {
+	sentences := newTestAnalyser().Analyse("Er ruft morgen an.")
+	tokens := sentences[0].Tokens
+
+	if tokens[1].Partner != 3 || tokens[3].Partner != 1 {
+		t.Errorf("got partners %d and %d, want 3 and 1", tokens[1].Partner, tokens[3].Partner)
+	}
+
+	if tokens[0].Partner != -1 || tokens[2].Partner != -1 {
+		t.Errorf("expected tokens that aren't part of a verb to have no partner")
+	}
+}
+
+func TestSentences(t *testing.T) {
+	text := "Er kommt. Sie geht!\n\nNeuer Absatz ohne Punkt\n\nEnde"
+	sentences := newTestAnalyser().Analyse(text)
+
+	want := []string{"Er kommt.", "Sie geht!", "Neuer Absatz ohne Punkt", "Ende"}
+	if len(sentences) != len(want) {
+		t.Fatalf("got %d sentences, want %d", len(sentences), len(want))
+	}
+
+	for i, s := range sentences {
+		if s.Text != want[i] || text[s.Start:s.End] != want[i] {
+			t.Errorf("sentence %d: got %q, want %q", i, s.Text, want[i])
+		}
+	}
+}
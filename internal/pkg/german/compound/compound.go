@@ -0,0 +1,286 @@
+package compound
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dictionary is used to check whether the parts of a compound are words.
+type Dictionary interface {
+	InDict(word string) bool
+	Lemma(word string) string
+}
+
+// Fugenelemente that can link the parts of a compound, e.g. Arbeit-s-zimmer.
+// Longer elements come first so they're tried before their suffixes.
+var linkingElements = []string{"es", "en", "er", "s", "n"}
+
+const (
+	// Parts shorter than this create too many false positives, e.g. `an`
+	minPartLength = 3
+	maxSplits     = 10
+
+	linkingElementPenalty = 0.9
+	inflectedPartPenalty  = 0.8
+)
+
+// Splitter proposes decompositions of German compound words.
+type Splitter struct {
+	dict Dictionary
+}
+
+func New(dict Dictionary) *Splitter {
+	return &Splitter{dict: dict}
+}
+
+type Part struct {
+	// Surface is the part as it appears in the compound without its linking
+	// element
+	Surface        string
+	Lemma          string
+	LinkingElement string
+}
+
+type Split struct {
+	Parts []Part
+	Score float64
+
+	// The compound was capitalised, its lemma and head are capitalised as well
+	capitalised bool
+}
+
+// Head returns the last part of a compound, it determines the gender and
+// meaning of the whole word.
+func (s Split) Head() Part {
+	return s.Parts[len(s.Parts)-1]
+}
+
+// Lemma returns the lemma of the whole compound, only the head is inflected.
+// The head is written in lowercase unless it follows a hyphen.
+func (s Split) Lemma() string {
+	b := &strings.Builder{}
+	for _, p := range s.Parts[:len(s.Parts)-1] {
+		b.WriteString(p.Surface + p.LinkingElement)
+	}
+
+	head := s.Head().Lemma
+	if len(s.Parts) > 1 && s.Parts[len(s.Parts)-2].LinkingElement != "-" {
+		head = strings.ToLower(head)
+	}
+	b.WriteString(head)
+
+	if s.capitalised {
+		return capitalise(b.String())
+	}
+
+	return b.String()
+}
+
+// Split returns possible decompositions of a word ordered by their score. The
+// result is empty when the word can't be split. The word is split in
+// lowercase, the lemma and head of a capitalised word like a noun are
+// capitalised again.
+func (s *Splitter) Split(word string) []Split {
+	word = strings.TrimSpace(word)
+	lower := strings.ToLower(word)
+	if lower == "" {
+		return []Split{}
+	}
+
+	r, _ := utf8.DecodeRuneInString(word)
+	capitalised := unicode.IsUpper(r)
+
+	var candidates [][]Part
+	if strings.Contains(lower, "-") {
+		candidates = s.splitHyphenated(word)
+	} else {
+		candidates = s.splitWord(lower)
+	}
+
+	splits := make([]Split, 0, len(candidates))
+	seen := map[string]bool{}
+
+	for _, parts := range candidates {
+		if capitalised {
+			head := &parts[len(parts)-1]
+			head.Lemma = capitalise(head.Lemma)
+		}
+
+		split := Split{Parts: parts, Score: score(lower, parts), capitalised: capitalised}
+
+		key := splitKey(split)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		splits = append(splits, split)
+	}
+
+	sort.SliceStable(splits, func(i, j int) bool {
+		if splits[i].Score != splits[j].Score {
+			return splits[i].Score > splits[j].Score
+		}
+		return splitKey(splits[i]) < splitKey(splits[j])
+	})
+
+	if len(splits) > maxSplits {
+		splits = splits[:maxSplits]
+	}
+
+	return splits
+}
+
+// splitHyphenated splits a word at its hyphens, the segments keep their
+// capitalisation, e.g. E-Mail-Adresse.
+func (s *Splitter) splitHyphenated(word string) [][]Part {
+	parts := []Part{}
+	for _, segment := range strings.Split(word, "-") {
+		if segment == "" {
+			continue
+		}
+
+		lemma := s.dict.Lemma(strings.ToLower(segment))
+		if r, _ := utf8.DecodeRuneInString(segment); unicode.IsUpper(r) {
+			lemma = capitalise(lemma)
+		}
+
+		parts = append(parts, Part{Surface: segment, Lemma: lemma, LinkingElement: "-"})
+	}
+
+	if len(parts) < 2 {
+		return nil
+	}
+
+	// The head isn't followed by a hyphen
+	parts[len(parts)-1].LinkingElement = ""
+
+	return [][]Part{parts}
+}
+
+func (s *Splitter) splitWord(word string) [][]Part {
+	res := [][]Part{}
+	memo := map[string][][]Part{}
+
+	for i := range word {
+		if i == 0 {
+			continue
+		}
+
+		modifiers, head := word[:i], word[i:]
+		if utf8.RuneCountInString(head) < minPartLength || !s.dict.InDict(head) {
+			continue
+		}
+
+		headPart := Part{Surface: head, Lemma: s.dict.Lemma(head)}
+
+		for _, parts := range s.splitModifiers(modifiers, memo) {
+			res = append(res, append(append([]Part{}, parts...), headPart))
+		}
+	}
+
+	return res
+}
+
+// splitModifiers returns all ways a segment can be covered by the non-head
+// parts of a compound.
+func (s *Splitter) splitModifiers(segment string, memo map[string][][]Part) [][]Part {
+	if res, ok := memo[segment]; ok {
+		return res
+	}
+
+	res := [][]Part{}
+	for _, p := range s.modifierOptions(segment) {
+		res = append(res, []Part{p})
+	}
+
+	for i := range segment {
+		if i == 0 {
+			continue
+		}
+
+		options := s.modifierOptions(segment[i:])
+		if len(options) == 0 {
+			continue
+		}
+
+		for _, rest := range s.splitModifiers(segment[:i], memo) {
+			for _, p := range options {
+				res = append(res, append(append([]Part{}, rest...), p))
+			}
+		}
+	}
+
+	memo[segment] = res
+
+	return res
+}
+
+// modifierOptions returns the ways a segment can be a single non-head part,
+// either as a word on its own or as a word followed by a linking element.
+func (s *Splitter) modifierOptions(segment string) []Part {
+	res := []Part{}
+
+	if utf8.RuneCountInString(segment) >= minPartLength && s.dict.InDict(segment) {
+		res = append(res, Part{Surface: segment, Lemma: s.dict.Lemma(segment)})
+	}
+
+	for _, l := range linkingElements {
+		stem := strings.TrimSuffix(segment, l)
+		if stem == segment || utf8.RuneCountInString(stem) < minPartLength {
+			continue
+		}
+
+		if s.dict.InDict(stem) {
+			res = append(res, Part{Surface: stem, Lemma: s.dict.Lemma(stem), LinkingElement: l})
+		}
+	}
+
+	return res
+}
+
+// score prefers decompositions with few and long parts. Linking elements and
+// inflected modifiers are less likely and are penalized.
+func score(word string, parts []Part) float64 {
+	total := float64(utf8.RuneCountInString(word))
+	res := 0.0
+
+	for _, p := range parts {
+		length := float64(utf8.RuneCountInString(p.Surface))
+		res += (length * length) / (total * total)
+	}
+
+	for _, p := range parts[:len(parts)-1] {
+		if p.LinkingElement != "" {
+			res *= linkingElementPenalty
+		}
+
+		if p.Lemma != p.Surface {
+			res *= inflectedPartPenalty
+		}
+	}
+
+	return res
+}
+
+// capitalise writes the first letter of a word in uppercase and keeps the
+// rest as it is.
+func capitalise(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+func splitKey(s Split) string {
+	parts := make([]string, len(s.Parts))
+	for i, p := range s.Parts {
+		parts[i] = p.Surface + "+" + p.LinkingElement
+	}
+
+	return strings.Join(parts, "|")
+}
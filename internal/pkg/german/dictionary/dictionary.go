@@ -0,0 +1,297 @@
+package dictionary
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnsupportedEntry = errors.New("unsupported dictionary entry")
+
+// Word is a German dictionary entry with its grammatical information.
+type Word struct {
+	Lemma string
+	POS   string
+	// Gender is m, f or n for nouns
+	Gender   string
+	Plural   string
+	Genitive string
+	// Auxiliary is haben or sein for verbs
+	Auxiliary       string
+	SeparablePrefix string
+	Forms           []Form
+	Senses          []string
+}
+
+type Form struct {
+	Form string   `json:"form"`
+	Tags []string `json:"tags"`
+}
+
+func (f Form) HasTags(tags ...string) bool {
+	for _, t := range tags {
+		found := false
+		for _, ft := range f.Tags {
+			if ft == t {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Tags that mark forms that aren't actual inflections of the word
+var ignoredTags = []string{"table-tags", "inflection-template", "class", "romanization"}
+
+// supportedPOS are the parts of speech that are imported
+var supportedPOS = map[string]bool{
+	"noun":   true,
+	"name":   true,
+	"verb":   true,
+	"adj":    true,
+	"adv":    true,
+	"prep":   true,
+	"pron":   true,
+	"conj":   true,
+	"num":    true,
+	"det":    true,
+	"intj":   true,
+	"phrase": true,
+}
+
+// kaikkiEntry is a single line of a Wiktionary extract in the kaikki.org
+// format, see https://kaikki.org/dictionary/German/
+type kaikkiEntry struct {
+	Word          string `json:"word"`
+	POS           string `json:"pos"`
+	LangCode      string `json:"lang_code"`
+	Forms         []Form `json:"forms"`
+	HeadTemplates []struct {
+		Name string            `json:"name"`
+		Args map[string]string `json:"args"`
+	} `json:"head_templates"`
+	Senses []struct {
+		Glosses []string `json:"glosses"`
+		Tags    []string `json:"tags"`
+	} `json:"senses"`
+}
+
+// ParseKaikki parses a single JSON line of a kaikki.org German dictionary
+// extract.
+func ParseKaikki(line []byte) (*Word, error) {
+	entry := &kaikkiEntry{}
+	if err := json.Unmarshal(line, entry); err != nil {
+		return nil, errors.Wrap(err, "could not parse dictionary entry")
+	}
+
+	if entry.LangCode != "" && entry.LangCode != "de" {
+		return nil, ErrUnsupportedEntry
+	}
+
+	if entry.Word == "" || !supportedPOS[entry.POS] {
+		return nil, ErrUnsupportedEntry
+	}
+
+	w := &Word{
+		Lemma:  entry.Word,
+		POS:    entry.POS,
+		Forms:  []Form{},
+		Senses: []string{},
+	}
+
+	for _, f := range entry.Forms {
+		if f.Form == "" || f.Form == "-" || hasAnyTag(f.Tags, ignoredTags) {
+			continue
+		}
+
+		w.Forms = append(w.Forms, f)
+	}
+
+	for _, s := range entry.Senses {
+		if len(s.Glosses) > 0 {
+			w.Senses = append(w.Senses, s.Glosses[len(s.Glosses)-1])
+		}
+	}
+
+	if w.POS == "noun" || w.POS == "name" {
+		w.Gender = findGender(entry)
+		w.Plural = findForm(w.Forms, "nominative", "plural")
+		if w.Plural == "" {
+			w.Plural = findForm(w.Forms, "plural")
+		}
+		w.Genitive = findForm(w.Forms, "genitive", "singular")
+		if w.Genitive == "" {
+			w.Genitive = findForm(w.Forms, "genitive")
+		}
+	}
+
+	if w.POS == "verb" {
+		w.Auxiliary = findForm(w.Forms, "auxiliary")
+		w.SeparablePrefix = findSeparablePrefix(w)
+	}
+
+	return w, nil
+}
+
+func hasAnyTag(tags []string, search []string) bool {
+	for _, t := range tags {
+		for _, s := range search {
+			if t == s {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func findForm(forms []Form, tags ...string) string {
+	for _, f := range forms {
+		if f.HasTags(tags...) && !f.HasTags("article") {
+			return f.Form
+		}
+	}
+
+	return ""
+}
+
+func findGender(entry *kaikkiEntry) string {
+	// The de-noun template starts with the gender, e.g. `n,es,Häuser`
+	for _, t := range entry.HeadTemplates {
+		if t.Name != "de-noun" && t.Name != "de-proper noun" {
+			continue
+		}
+
+		for _, key := range []string{"g", "1"} {
+			if g := parseGender(strings.TrimSpace(t.Args[key])); g != "" {
+				return g
+			}
+		}
+	}
+
+	genders := map[string]string{"masculine": "m", "feminine": "f", "neuter": "n"}
+
+	for _, f := range entry.Forms {
+		for _, t := range f.Tags {
+			if g, ok := genders[t]; ok && f.HasTags("singular") {
+				return g
+			}
+		}
+	}
+
+	for _, s := range entry.Senses {
+		for _, t := range s.Tags {
+			if g, ok := genders[t]; ok {
+				return g
+			}
+		}
+	}
+
+	return ""
+}
+
+func parseGender(arg string) string {
+	if arg == "" {
+		return ""
+	}
+
+	switch arg[:1] {
+	case "m", "f", "n":
+		// Ignore plurale tantum nouns (p)
+		return arg[:1]
+	}
+
+	return ""
+}
+
+// findSeparablePrefix detects separable verbs by their finite forms, e.g.
+// `ruft an` for anrufen.
+func findSeparablePrefix(w *Word) string {
+	for _, f := range w.Forms {
+		if !f.HasTags("present") && !f.HasTags("past") {
+			continue
+		}
+
+		parts := strings.Fields(f.Form)
+		if len(parts) != 2 {
+			continue
+		}
+
+		prefix := parts[1]
+		if strings.HasPrefix(w.Lemma, prefix) && len(prefix) < len(w.Lemma) {
+			return prefix
+		}
+	}
+
+	return ""
+}
+
+// Article returns the definite article for a gender.
+func Article(gender string) string {
+	switch gender {
+	case "m":
+		return "der"
+	case "f":
+		return "die"
+	case "n":
+		return "das"
+	}
+
+	return ""
+}
+
+var (
+	Cases   = []string{"nominative", "genitive", "dative", "accusative"}
+	Numbers = []string{"singular", "plural"}
+)
+
+// Declension builds a case/number table of a noun's forms.
+func Declension(forms []Form) map[string]map[string]string {
+	res := map[string]map[string]string{}
+
+	for _, c := range Cases {
+		for _, n := range Numbers {
+			form := findForm(forms, c, n)
+			if form == "" {
+				continue
+			}
+
+			if _, ok := res[c]; !ok {
+				res[c] = map[string]string{}
+			}
+
+			res[c][n] = form
+		}
+	}
+
+	return res
+}
+
+// PrincipalParts returns the present (third person singular), preterite
+// (first person singular) and past participle of a verb.
+func PrincipalParts(forms []Form) map[string]string {
+	res := map[string]string{}
+
+	if f := findForm(forms, "present", "singular", "third-person"); f != "" {
+		res["present"] = f
+	}
+
+	if f := findForm(forms, "past", "singular", "first-person"); f != "" {
+		res["preterite"] = f
+	} else if f := findForm(forms, "preterite", "singular", "first-person"); f != "" {
+		res["preterite"] = f
+	}
+
+	if f := findForm(forms, "participle", "past"); f != "" {
+		res["past_participle"] = f
+	}
+
+	return res
+}
@@ -0,0 +1,126 @@
+package dictionary
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseKaikki(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		word *Word
+		err  error
+	}{
+		{
+			name: "noun",
+			line: `{"word":"Haus","pos":"noun","lang_code":"de","head_templates":[{"name":"de-noun","args":{"1":"n,es,Häuser"}}],"forms":[{"form":"de-ndecl","tags":["table-tags"]},{"form":"des","tags":["article","genitive","singular"]},{"form":"Hauses","tags":["genitive","singular"]},{"form":"Häuser","tags":["nominative","plural"]},{"form":"-","tags":["diminutive"]}],"senses":[{"glosses":["house"]},{"glosses":["building","home"]}]}`,
+			word: &Word{
+				Lemma:    "Haus",
+				POS:      "noun",
+				Gender:   "n",
+				Plural:   "Häuser",
+				Genitive: "Hauses",
+				Forms: []Form{
+					{Form: "des", Tags: []string{"article", "genitive", "singular"}},
+					{Form: "Hauses", Tags: []string{"genitive", "singular"}},
+					{Form: "Häuser", Tags: []string{"nominative", "plural"}},
+				},
+				Senses: []string{"house", "home"},
+			},
+		},
+		{
+			name: "gender from the forms",
+			line: `{"word":"Katze","pos":"noun","lang_code":"de","forms":[{"form":"Katze","tags":["feminine","nominative","singular"]},{"form":"Katzen","tags":["plural"]}],"senses":[{"glosses":["cat"]}]}`,
+			word: &Word{
+				Lemma:  "Katze",
+				POS:    "noun",
+				Gender: "f",
+				Plural: "Katzen",
+				Forms: []Form{
+					{Form: "Katze", Tags: []string{"feminine", "nominative", "singular"}},
+					{Form: "Katzen", Tags: []string{"plural"}},
+				},
+				Senses: []string{"cat"},
+			},
+		},
+		{
+			name: "separable verb",
+			line: `{"word":"anrufen","pos":"verb","lang_code":"de","forms":[{"form":"de-conj","tags":["inflection-template"]},{"form":"haben","tags":["auxiliary"]},{"form":"ruft an","tags":["present","singular","third-person"]},{"form":"angerufen","tags":["participle","past"]}],"senses":[{"glosses":["to call"]}]}`,
+			word: &Word{
+				Lemma:           "anrufen",
+				POS:             "verb",
+				Auxiliary:       "haben",
+				SeparablePrefix: "an",
+				Forms: []Form{
+					{Form: "haben", Tags: []string{"auxiliary"}},
+					{Form: "ruft an", Tags: []string{"present", "singular", "third-person"}},
+					{Form: "angerufen", Tags: []string{"participle", "past"}},
+				},
+				Senses: []string{"to call"},
+			},
+		},
+		{
+			name: "inseparable verb",
+			line: `{"word":"verstehen","pos":"verb","lang_code":"de","forms":[{"form":"haben","tags":["auxiliary"]},{"form":"versteht","tags":["present","singular","third-person"]}]}`,
+			word: &Word{
+				Lemma:     "verstehen",
+				POS:       "verb",
+				Auxiliary: "haben",
+				Forms: []Form{
+					{Form: "haben", Tags: []string{"auxiliary"}},
+					{Form: "versteht", Tags: []string{"present", "singular", "third-person"}},
+				},
+				Senses: []string{},
+			},
+		},
+		{
+			name: "unsupported part of speech",
+			line: `{"word":"-chen","pos":"suffix","lang_code":"de"}`,
+			err:  ErrUnsupportedEntry,
+		},
+		{
+			name: "other language",
+			line: `{"word":"house","pos":"noun","lang_code":"en"}`,
+			err:  ErrUnsupportedEntry,
+		},
+		{
+			name: "without a word",
+			line: `{"pos":"noun","lang_code":"de"}`,
+			err:  ErrUnsupportedEntry,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			word, err := ParseKaikki([]byte(tt.line))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(word, tt.word) {
+				t.Errorf("got %+v, want %+v", word, tt.word)
+			}
+		})
+	}
+}
+
+func TestParseKaikkiInvalid(t *testing.T) {
+	if _, err := ParseKaikki([]byte(`{"word":"Haus",`)); err == nil || errors.Is(err, ErrUnsupportedEntry) {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+}
+
+func TestPrincipalParts(t *testing.T) {
+	forms := []Form{
+		{Form: "geht", Tags: []string{"present", "singular", "third-person"}},
+		{Form: "ging", Tags: []string{"past", "singular", "first-person"}},
+		{Form: "gegangen", Tags: []string{"participle", "past"}},
+	}
+
+	want := map[string]string{"present": "geht", "preterite": "ging", "past_participle": "gegangen"}
+	if got := PrincipalParts(forms); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
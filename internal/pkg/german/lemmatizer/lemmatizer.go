@@ -1,7 +1,11 @@
 package lemmatizer
 
 import (
+	"math"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/aaaton/golem/v4"
 	"github.com/aaaton/golem/v4/dicts/de"
@@ -24,48 +28,282 @@ func NewGermanLemmatizer() *GermanLemmatizer {
 	}
 }
 
-func (l *GermanLemmatizer) Lemmas(input string) []string {
-	results := []string{}
+// Part of speech hints for lemma candidates
+const (
+	POSNoun      = "noun"
+	POSVerb      = "verb"
+	POSAdjective = "adjective"
+)
+
+type Candidate struct {
+	Lemma string
+	POS   string
+
+	// Score between 0 and 1, higher is more likely
+	Score float64
+}
+
+// Lemmas returns the lemma candidates of a word ordered by their score. Nouns
+// are capitalised, capitalisation of the input is used as a hint for its part
+// of speech.
+func (l *GermanLemmatizer) Lemmas(input string) []Candidate {
+	return l.candidates(input, true)
+}
 
-	if found := l.lemmatizer.InDict(input); found {
-		words := l.lemmatizer.Lemmas(input)
-		results = append(results, words...)
+// LemmasAtSentenceStart works like Lemmas for the first word of a sentence,
+// where capitalisation doesn't say anything about the part of speech.
+func (l *GermanLemmatizer) LemmasAtSentenceStart(input string) []Candidate {
+	return l.candidates(input, false)
+}
+
+const (
+	scoreExpected   = 0.8
+	scoreUnexpected = 0.3
+	scoreUnknown    = 0.7
+
+	// Bonuses on top of the base score
+	exactMatchBonus      = 0.1
+	adjectiveEndingBonus = 0.15
+	verbBonus            = 0.03
+	multipleEntriesBonus = 0.05
+
+	// Spelling with ß instead of ss is a guess
+	eszettPenalty = 0.8
+)
+
+var adjectiveEndings = []string{"e", "er", "es", "en", "em"}
+
+func (l *GermanLemmatizer) candidates(input string, capitalisationKnown bool) []Candidate {
+	results := map[string]Candidate{}
+
+	add := func(c Candidate) {
+		c.Score = math.Min(c.Score, 1)
+
+		key := c.POS + ":" + c.Lemma
+		if existing, ok := results[key]; !ok || existing.Score < c.Score {
+			results[key] = c
+		}
 	}
 
-	inputWithEszett := strings.ReplaceAll(input, "ss", "ß")
-	if found := l.lemmatizer.InDict(inputWithEszett); found && inputWithEszett != input {
-		words := l.lemmatizer.Lemmas(inputWithEszett)
-		results = append(results, words...)
+	capitalised := startsWithUpper(input)
+	// Verbs and adjectives are written in lowercase, unless they start a
+	// sentence or are used as a noun
+	lowercase := !capitalisationKnown || !capitalised
+
+	base := func(pos string) float64 {
+		switch {
+		case !capitalisationKnown:
+			return scoreUnknown
+		case capitalised == (pos == POSNoun):
+			return scoreExpected
+		default:
+			return scoreUnexpected
+		}
 	}
 
-	results = formatResults(results)
+	// Nouns of lowercase words, they're only suggested when there are no
+	// other candidates
+	nouns := []Candidate{}
 
-	return results
+	variants := []string{input}
+	if withEszett := strings.ReplaceAll(input, "ss", "ß"); withEszett != input {
+		variants = append(variants, withEszett)
+	}
+
+	for i, variant := range variants {
+		if !l.lemmatizer.InDict(variant) {
+			continue
+		}
+
+		penalty := 1.0
+		if i > 0 {
+			penalty = eszettPenalty
+		}
+
+		lower := strings.ToLower(variant)
+		lemmas, counts := uniqueLemmas(l.lemmatizer.Lemmas(variant))
+
+		for _, lemma := range lemmas {
+			exact := 0.0
+			if lemma == lower {
+				exact = exactMatchBonus
+			}
+
+			if isInfinitive(lemma) {
+				// Capitalised forms like Wagen are nouns that look like a verb
+				if lowercase {
+					add(Candidate{Lemma: lemma, POS: POSVerb, Score: (base(POSVerb) + verbBonus + exact) * penalty})
+				}
+
+				// Infinitives can be used as a noun, e.g. das Essen, and
+				// capitalised words are inflected nouns, e.g. Gärten
+				if lemma == lower || capitalised {
+					add(Candidate{Lemma: capitalise(lemma), POS: POSNoun, Score: (base(POSNoun) + exact) * penalty})
+				}
+
+				continue
+			}
+
+			// Lowercase words are only suggested as a noun when nothing else
+			// matches, see below
+			if capitalised || !capitalisationKnown {
+				noun := base(POSNoun) + exact
+
+				// Words listed more than once in the dictionary are often nouns as well
+				if counts[lemma] > 1 {
+					noun += multipleEntriesBonus
+				}
+
+				add(Candidate{Lemma: capitalise(lemma), POS: POSNoun, Score: noun * penalty})
+			} else {
+				nouns = append(nouns, Candidate{Lemma: capitalise(lemma), POS: POSNoun, Score: (base(POSNoun) + exact) * penalty})
+			}
+
+			adjective, ok := l.adjectiveLemma(lemma)
+			if !ok {
+				continue
+			}
+
+			// Capitalised adjectives are only likely when they're inflected
+			// like one, e.g. die Kleine
+			ending := hasAdjectiveEnding(lower, adjective)
+			if lowercase || ending {
+				score := base(POSAdjective)
+				if lower == adjective {
+					score += exactMatchBonus
+				}
+				if ending {
+					score += adjectiveEndingBonus
+				}
+
+				add(Candidate{Lemma: adjective, POS: POSAdjective, Score: score * penalty})
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		for _, c := range nouns {
+			add(c)
+		}
+	}
+
+	res := make([]Candidate, 0, len(results))
+	for _, c := range results {
+		res = append(res, c)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		if res[i].Lemma != res[j].Lemma {
+			return res[i].Lemma < res[j].Lemma
+		}
+		return res[i].POS < res[j].POS
+	})
+
+	return res
+}
+
+// adjectiveLemma returns the adjective a lemma belongs to. The dictionary
+// doesn't know parts of speech, so adjectives are recognised by their
+// inflection with -em, which nouns don't have. The dictionary lists some
+// inflected adjectives as their own lemma, e.g. kleine, these return the
+// uninflected adjective.
+func (l *GermanLemmatizer) adjectiveLemma(lemma string) (string, bool) {
+	stems := []string{lemma}
+
+	// Adjectives ending in -e, -el or -er drop their e when inflected, e.g.
+	// müde → müdem, dunkel → dunklem, teuer → teurem
+	switch {
+	case lemma == "hoch":
+		stems = append(stems, "hoh")
+	case strings.HasSuffix(lemma, "e"):
+		stems = append(stems, strings.TrimSuffix(lemma, "e"))
+	case strings.HasSuffix(lemma, "el"), strings.HasSuffix(lemma, "er"):
+		stems = append(stems, lemma[:len(lemma)-2]+lemma[len(lemma)-1:])
+	}
+
+	for _, ending := range adjectiveEndings {
+		if stem := strings.TrimSuffix(lemma, ending); stem != lemma {
+			stems = append(stems, stem)
+		}
+	}
+
+	for _, stem := range stems {
+		form := stem + "em"
+		if !l.lemmatizer.InDict(form) {
+			continue
+		}
+
+		if lemmas := l.lemmatizer.Lemmas(form); len(lemmas) > 0 {
+			return strings.ToLower(lemmas[0]), true
+		}
+	}
+
+	return "", false
+}
+
+// InDict checks whether a word or one of its inflections is known.
+func (l *GermanLemmatizer) InDict(input string) bool {
+	return l.lemmatizer.InDict(input)
+}
+
+// Lemma returns a single lemma of a word, or the word itself when it's not in
+// the dictionary. Words that are a lemma themselves are returned as-is.
+func (l *GermanLemmatizer) Lemma(input string) string {
+	lower := strings.ToLower(input)
+	for _, lemma := range l.lemmatizer.Lemmas(input) {
+		if lemma == lower {
+			return lemma
+		}
+	}
+
+	return l.lemmatizer.Lemma(input)
 }
 
 var caser = cases.Title(language.German)
 
-func formatResults(results []string) []string {
-	newResults := map[string]bool{}
+func capitalise(word string) string {
+	return caser.String(word)
+}
+
+func startsWithUpper(word string) bool {
+	r, _ := utf8.DecodeRuneInString(word)
+	return unicode.IsUpper(r)
+}
 
-	for _, word := range results {
-		if _, ok := newResults[word]; ok {
-			withCaps := caser.String(strings.ToLower(word))
-			if _, ok := newResults[withCaps]; !ok {
-				newResults[withCaps] = true
-			}
-		} else {
-			newResults[word] = true
+func hasAdjectiveEnding(word, lemma string) bool {
+	for _, ending := range adjectiveEndings {
+		if word == lemma+ending {
+			return true
 		}
 	}
 
-	keys := make([]string, len(newResults))
+	return false
+}
+
+func isInfinitive(word string) bool {
+	if word == "sein" || word == "tun" {
+		return true
+	}
+
+	return strings.HasSuffix(word, "en") || strings.HasSuffix(word, "eln") || strings.HasSuffix(word, "ern")
+}
 
-	i := 0
-	for k := range newResults {
-		keys[i] = k
-		i++
+// uniqueLemmas lowercases the lemmas from the dictionary and counts how often
+// every lemma is listed.
+func uniqueLemmas(lemmas []string) ([]string, map[string]int) {
+	res := []string{}
+	counts := map[string]int{}
+
+	for _, lemma := range lemmas {
+		lemma = strings.ToLower(lemma)
+		if counts[lemma] == 0 {
+			res = append(res, lemma)
+		}
+		counts[lemma]++
 	}
 
-	return keys
+	return res, counts
 }
@@ -0,0 +1,118 @@
+package lemmatizer
+
+import "testing"
+
+var testLemmatizer = NewGermanLemmatizer()
+
+func TestLemmas(t *testing.T) {
+	tests := []struct {
+		input string
+		// The most likely candidate
+		best Candidate
+		// Candidates that mustn't be suggested
+		absent []Candidate
+	}{
+		{
+			// Inflected nouns whose lemma looks like an infinitive
+			input:  "Gärten",
+			best:   Candidate{Lemma: "Garten", POS: POSNoun},
+			absent: []Candidate{{Lemma: "garten", POS: POSVerb}},
+		},
+		{
+			input: "Häfen",
+			best:  Candidate{Lemma: "Hafen", POS: POSNoun},
+		},
+		{
+			input: "Mädchens",
+			best:  Candidate{Lemma: "Mädchen", POS: POSNoun},
+		},
+		{
+			input: "Essen",
+			best:  Candidate{Lemma: "Essen", POS: POSNoun},
+		},
+		{
+			input: "essen",
+			best:  Candidate{Lemma: "essen", POS: POSVerb},
+		},
+		{
+			input:  "Hauses",
+			best:   Candidate{Lemma: "Haus", POS: POSNoun},
+			absent: []Candidate{{Lemma: "haus", POS: POSAdjective}},
+		},
+		{
+			// Lowercase words are only nouns when nothing else matches
+			input: "haus",
+			best:  Candidate{Lemma: "Haus", POS: POSNoun},
+		},
+		{
+			input:  "schnell",
+			best:   Candidate{Lemma: "schnell", POS: POSAdjective},
+			absent: []Candidate{{Lemma: "Schnell", POS: POSNoun}},
+		},
+		{
+			input:  "sieben",
+			best:   Candidate{Lemma: "sieben", POS: POSVerb},
+			absent: []Candidate{{Lemma: "sieb", POS: POSAdjective}},
+		},
+		{
+			// The dictionary has kleine as its own lemma
+			input: "kleine",
+			best:  Candidate{Lemma: "klein", POS: POSAdjective},
+		},
+		{
+			input: "dunklen",
+			best:  Candidate{Lemma: "dunklen", POS: POSVerb},
+		},
+		{
+			input:  "teure",
+			best:   Candidate{Lemma: "teuer", POS: POSAdjective},
+			absent: []Candidate{{Lemma: "Teure", POS: POSNoun}},
+		},
+		{
+			input: "läuft",
+			best:  Candidate{Lemma: "laufen", POS: POSVerb},
+		},
+		{
+			input: "Strasse",
+			best:  Candidate{Lemma: "Straße", POS: POSNoun},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			candidates := testLemmatizer.Lemmas(tt.input)
+			if len(candidates) == 0 {
+				t.Fatalf("got no candidates")
+			}
+
+			best := candidates[0]
+			if best.Lemma != tt.best.Lemma || best.POS != tt.best.POS {
+				t.Errorf("got %s (%s), want %s (%s), all candidates: %+v", best.Lemma, best.POS, tt.best.Lemma, tt.best.POS, candidates)
+			}
+
+			for _, c := range candidates {
+				for _, a := range tt.absent {
+					if c.Lemma == a.Lemma && c.POS == a.POS {
+						t.Errorf("unexpected candidate %s (%s)", c.Lemma, c.POS)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLemmasAtSentenceStart(t *testing.T) {
+	// The capitalisation of the first word doesn't say it's a noun
+	candidates := testLemmatizer.LemmasAtSentenceStart("Gehen")
+
+	found := map[string]bool{}
+	for _, c := range candidates {
+		found[c.Lemma+":"+c.POS] = true
+	}
+
+	for _, want := range []string{"gehen:" + POSVerb, "Gehen:" + POSNoun} {
+		if !found[want] {
+			t.Errorf("expected candidate %s, got %+v", want, candidates)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package htmlfixture records web pages as test fixtures, so parsers of
+// third party pages are tested against the markup those sites really serve.
+// Tests record their fixtures again when they run with `-update`.
+package htmlfixture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// maxPageSize is the largest page that will be recorded
+const maxPageSize = 10 << 20
+
+// dropped are the elements that are removed from recorded pages, none of the
+// parsers read them and they make up most of a page
+var dropped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Link:     true,
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Record downloads the page at the url and writes it to path with scripts,
+// styles and comments removed. The page is written in UTF-8.
+func Record(url, path string) error {
+	body, contentType, err := fetch(url)
+	if err != nil {
+		return err
+	}
+
+	r, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return errors.Wrap(err, "could not decode page")
+	}
+
+	doc, err := html.Parse(r)
+	if err != nil {
+		return errors.Wrap(err, "could not parse page")
+	}
+
+	trim(doc)
+
+	b := &bytes.Buffer{}
+	if err := html.Render(b, doc); err != nil {
+		return errors.Wrap(err, "could not render page")
+	}
+
+	return errors.Wrap(os.WriteFile(path, b.Bytes(), 0644), "could not write fixture")
+}
+
+// RecordRaw writes the page as it was served, for pages whose encoding is
+// part of what's tested.
+func RecordRaw(url, path string) error {
+	body, _, err := fetch(url)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(os.WriteFile(path, body, 0644), "could not write fixture")
+}
+
+func fetch(url string) ([]byte, string, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "could not download page")
+	}
+	defer res.Body.Close()
+
+	// Pages that aren't found are recorded too, parsers have to recognise them
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return nil, "", errors.Errorf("could not download page: %s", res.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxPageSize))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "could not download page")
+	}
+
+	return body, res.Header.Get("Content-Type"), nil
+}
+
+// trim removes the dropped elements and comments. The page is rendered in
+// UTF-8, so the charset it declares is changed to match.
+func trim(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		switch {
+		case c.Type == html.CommentNode, c.Type == html.ElementNode && dropped[c.DataAtom]:
+			n.RemoveChild(c)
+		case c.Type == html.ElementNode && c.DataAtom == atom.Meta:
+			setCharset(c)
+		default:
+			trim(c)
+		}
+
+		c = next
+	}
+}
+
+func setCharset(meta *html.Node) {
+	for i, a := range meta.Attr {
+		switch {
+		case a.Key == "charset":
+			meta.Attr[i].Val = "utf-8"
+		case a.Key == "content" && strings.Contains(strings.ToLower(a.Val), "charset="):
+			meta.Attr[i].Val = "text/html; charset=utf-8"
+		}
+	}
+}
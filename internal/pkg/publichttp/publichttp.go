@@ -0,0 +1,83 @@
+// Package publichttp downloads URLs that come from users, like pages to import
+// or audio of cards, without letting them reach the API's own network.
+package publichttp
+
+import (
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// Client returns a client that only connects to public addresses. The address
+// is checked when connecting, after the host was resolved, which covers
+// redirects too.
+func Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !PublicIP(ip) {
+				return errors.Wrap(ErrForbiddenAddress, host)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would connect to the address instead of the dialer
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Ranges that aren't covered by the methods of net.IP
+var reserved = []*net.IPNet{
+	// "This network", 0.0.0.0 reaches the host itself on Linux
+	mustParseCIDR("0.0.0.0/8"),
+	// Carrier grade NAT, used for internal networks by some cloud providers
+	mustParseCIDR("100.64.0.0/10"),
+	// NAT64 embeds IPv4 addresses, including private ones
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+// PublicIP reports whether an address can be reached from the internet.
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return false
+	}
+
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,61 @@
+package publichttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"127.0.0.1", false},
+		{"10.0.0.8", false},
+		{"172.17.0.2", false},
+		{"192.168.1.10", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"224.0.0.251", false},
+		{"239.255.255.250", false},
+		{"ff02::1", false},
+		{"ff05::2", false},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::7f00:1", false},
+		{"100.128.0.1", true},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"93.184.216.34", true},
+		{"2606:4700::6810:84e5", true},
+	}
+
+	for _, tt := range tests {
+		if got := PublicIP(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("PublicIP(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer srv.Close()
+
+	client := Client(time.Second)
+
+	for _, u := range []string{srv.URL, "http://localhost:1/", "http://[::1]:1/"} {
+		if _, err := client.Get(u); !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("%s: expected ErrForbiddenAddress, got %v", u, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package readingstats
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+const dateLayout = "2006-01-02"
+
+// Session is a finished or ongoing reading session. Sessions that are still
+// open have no end time and don't count towards the reading speed.
+type Session struct {
+	StartedAt      time.Time
+	EndedAt        *time.Time
+	CharactersRead int
+	Lookups        int
+	WordsMined     int
+}
+
+// Minutes returns how long a session lasted, open sessions last 0 minutes.
+func (s Session) Minutes() float64 {
+	if s.EndedAt == nil || s.EndedAt.Before(s.StartedAt) {
+		return 0
+	}
+
+	return s.EndedAt.Sub(s.StartedAt).Minutes()
+}
+
+// Day is the reading done on a single day, sessions count on the day they
+// were started. Days are in UTC like the timestamps in the database.
+type Day struct {
+	Date           string  `json:"date"`
+	Sessions       int     `json:"sessions"`
+	Minutes        float64 `json:"minutes"`
+	CharactersRead int     `json:"characters_read"`
+	Lookups        int     `json:"lookups"`
+	WordsMined     int     `json:"words_mined"`
+}
+
+// Stats summarizes reading sessions.
+type Stats struct {
+	Sessions       int     `json:"sessions"`
+	Minutes        float64 `json:"minutes"`
+	CharactersRead int     `json:"characters_read"`
+	Lookups        int     `json:"lookups"`
+	WordsMined     int     `json:"words_mined"`
+	// Only finished sessions are used for the reading speed
+	CharactersPerMinute float64 `json:"characters_per_minute"`
+	CurrentStreak       int     `json:"current_streak"`
+	LongestStreak       int     `json:"longest_streak"`
+	Days                []Day   `json:"days"`
+}
+
+// Summarize totals sessions per day and calculates the streaks, sessions
+// are expected in chronological order.
+func Summarize(sessions []Session, now time.Time) Stats {
+	stats := Stats{Days: days(sessions)}
+
+	finishedMinutes := 0.0
+	finishedCharacters := 0
+
+	for _, s := range sessions {
+		stats.Sessions++
+		stats.Minutes += s.Minutes()
+		stats.CharactersRead += s.CharactersRead
+		stats.Lookups += s.Lookups
+		stats.WordsMined += s.WordsMined
+
+		if s.EndedAt != nil {
+			finishedMinutes += s.Minutes()
+			finishedCharacters += s.CharactersRead
+		}
+	}
+
+	stats.CharactersPerMinute = CharactersPerMinute(finishedCharacters, finishedMinutes)
+	stats.CurrentStreak, stats.LongestStreak = streaks(stats.Days, now)
+
+	return stats
+}
+
+// Streaks returns the current and longest streak of consecutive days with
+// reading. The current streak is kept until the end of the day after the last
+// session, so it doesn't reset before today's reading.
+func Streaks(sessions []Session, now time.Time) (int, int) {
+	return streaks(days(sessions), now)
+}
+
+func days(sessions []Session) []Day {
+	days := []Day{}
+
+	for _, s := range sessions {
+		date := s.StartedAt.UTC().Format(dateLayout)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, Day{Date: date})
+		}
+
+		day := &days[len(days)-1]
+		day.Sessions++
+		day.Minutes += s.Minutes()
+		day.CharactersRead += s.CharactersRead
+		day.Lookups += s.Lookups
+		day.WordsMined += s.WordsMined
+	}
+
+	return days
+}
+
+// CharactersPerMinute returns the reading speed, sessions shorter than a
+// second don't have a meaningful speed.
+func CharactersPerMinute(characters int, minutes float64) float64 {
+	if minutes < 1.0/60 {
+		return 0
+	}
+
+	return float64(characters) / minutes
+}
+
+// Days without characters read don't count towards a streak, e.g. a session
+// that was started and stopped right away.
+func streaks(days []Day, now time.Time) (int, int) {
+	current, longest := 0, 0
+	var last time.Time
+
+	for _, d := range days {
+		if d.CharactersRead == 0 {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, d.Date)
+		if err != nil {
+			continue
+		}
+
+		if !last.IsZero() && date.Equal(last.AddDate(0, 0, 1)) {
+			current++
+		} else {
+			current = 1
+		}
+		last = date
+
+		longest = max(longest, current)
+	}
+
+	today, _ := time.Parse(dateLayout, now.UTC().Format(dateLayout))
+	if last.IsZero() || last.Before(today.AddDate(0, 0, -1)) {
+		current = 0
+	}
+
+	return current, longest
+}
+
+// CharactersBetween counts the characters in the lines of a text from one
+// reading position up to another, whitespace isn't counted. Lines are split
+// like the reader splits them, blank lines aren't lines.
+func CharactersBetween(content string, from, to int) int {
+	lines := strings.FieldsFunc(content, func(c rune) bool {
+		return c == '\n'
+	})
+	to = min(max(to, 0), len(lines))
+	from = min(max(from, 0), to)
+
+	count := 0
+	for _, line := range lines[from:to] {
+		for _, r := range line {
+			if !unicode.IsSpace(r) {
+				count++
+			}
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,114 @@
+package readingstats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStreaks(t *testing.T) {
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 3, d, hour, 0, 0, 0, time.UTC)
+	}
+	session := func(d, hour, characters int) Session {
+		return Session{StartedAt: day(d, hour), CharactersRead: characters}
+	}
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+
+	tests := []struct {
+		name     string
+		sessions []Session
+		now      time.Time
+		current  int
+		longest  int
+	}{
+		{
+			name:     "no sessions",
+			sessions: []Session{},
+			now:      day(5, 12),
+			current:  0,
+			longest:  0,
+		},
+		{
+			name:     "read today",
+			sessions: []Session{session(3, 8, 100), session(4, 8, 100), session(5, 8, 100)},
+			now:      day(5, 12),
+			current:  3,
+			longest:  3,
+		},
+		{
+			name:     "not read yet today",
+			sessions: []Session{session(3, 8, 100), session(4, 8, 100)},
+			now:      day(5, 12),
+			current:  2,
+			longest:  2,
+		},
+		{
+			name:     "missed a day",
+			sessions: []Session{session(1, 8, 100), session(2, 8, 100), session(4, 8, 100)},
+			now:      day(5, 12),
+			current:  1,
+			longest:  2,
+		},
+		{
+			name:     "days without characters read don't count",
+			sessions: []Session{session(3, 8, 100), session(4, 8, 0), session(5, 8, 100)},
+			now:      day(5, 12),
+			current:  1,
+			longest:  1,
+		},
+		{
+			// 02:00 in Tokyo is still the 4th in UTC
+			name:     "local time is compared in UTC",
+			sessions: []Session{session(2, 8, 100), session(3, 8, 100)},
+			now:      time.Date(2024, 3, 5, 2, 0, 0, 0, tokyo),
+			current:  2,
+			longest:  2,
+		},
+		{
+			name: "sessions in another zone are counted on their UTC day",
+			sessions: []Session{
+				session(3, 20, 100),
+				{StartedAt: time.Date(2024, 3, 5, 1, 0, 0, 0, tokyo), CharactersRead: 100},
+			},
+			now:     day(4, 12),
+			current: 2,
+			longest: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := Streaks(tt.sessions, tt.now)
+			if current != tt.current || longest != tt.longest {
+				t.Errorf("streaks %d, %d, want %d, %d", current, longest, tt.current, tt.longest)
+			}
+		})
+	}
+}
+
+func TestCharactersBetween(t *testing.T) {
+	// The reader skips blank lines, positions are counted without them
+	content := "第一章\n\n吾輩は 猫である。\n\n\n名前はまだ無い。\n"
+
+	tests := []struct {
+		name     string
+		from, to int
+		want     int
+	}{
+		{name: "first line", from: 0, to: 1, want: 3},
+		{name: "blank lines aren't positions", from: 1, to: 3, want: 16},
+		{name: "whole text", from: 0, to: 3, want: 19},
+		{name: "past the last line", from: 2, to: 10, want: 8},
+		{name: "backwards", from: 3, to: 1, want: 0},
+		{name: "negative", from: -1, to: 1, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CharactersBetween(content, tt.from, tt.to); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package srs
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Grades follow SM-2: 0-2 means the answer was wrong, 3 correct with
+// difficulty, 4 correct after some hesitation and 5 perfect recall.
+const (
+	GradeBlackout = 0
+	GradeWrong    = 1
+	GradeAlmost   = 2
+	GradeHard     = 3
+	GradeGood     = 4
+	GradePerfect  = 5
+
+	MinGrade = GradeBlackout
+	MaxGrade = GradePerfect
+)
+
+const (
+	passingGrade   = GradeHard
+	initialEase    = 2.5
+	minEaseFactor  = 1.3
+	firstInterval  = 1
+	secondInterval = 6
+)
+
+var ErrInvalidGrade = errors.New("grade must be between 0 and 5")
+
+// Ratings of word tokens, a word is considered mature after three weeks like
+// in Anki.
+const (
+	RatingNew         = 0
+	RatingLearned     = 1
+	RatingComfortable = 2
+	RatingMature      = 3
+
+	comfortableInterval = 7
+	matureInterval      = 21
+)
+
+// State is the scheduling state of a card. Interval is in days.
+type State struct {
+	Repetitions int
+	Interval    int
+	EaseFactor  float64
+	Due         time.Time
+}
+
+// New returns the state of a card that was never reviewed, it's due right
+// away.
+func New(now time.Time) State {
+	return State{
+		EaseFactor: initialEase,
+		Due:        now,
+	}
+}
+
+// Review schedules the next review with SM-2. Cards that are answered
+// correctly come back after 1 day, 6 days and then the previous interval
+// times the ease factor, e.g. 1, 6, 15, 38 days for an ease factor of 2.5.
+// Wrong answers start the card over without changing the ease factor.
+func Review(s State, grade int, now time.Time) (State, error) {
+	if grade < MinGrade || grade > MaxGrade {
+		return s, ErrInvalidGrade
+	}
+
+	if s.EaseFactor == 0 {
+		s.EaseFactor = initialEase
+	}
+
+	if grade < passingGrade {
+		s.Repetitions = 0
+		s.Interval = firstInterval
+	} else {
+		switch s.Repetitions {
+		case 0:
+			s.Interval = firstInterval
+		case 1:
+			s.Interval = secondInterval
+		default:
+			s.Interval = int(math.Round(float64(s.Interval) * s.EaseFactor))
+		}
+		s.Repetitions++
+
+		q := float64(MaxGrade - grade)
+		s.EaseFactor = math.Max(minEaseFactor, s.EaseFactor+0.1-q*(0.08+q*0.02))
+	}
+
+	s.Due = now.AddDate(0, 0, s.Interval)
+
+	return s, nil
+}
+
+// Rating returns how well a word is known, based on the state of its card.
+// Cards that were answered wrong start over, so they're new again.
+func Rating(s State) int {
+	switch {
+	case s.Repetitions == 0:
+		return RatingNew
+	case s.Interval >= matureInterval:
+		return RatingMature
+	case s.Interval >= comfortableInterval:
+		return RatingComfortable
+	default:
+		return RatingLearned
+	}
+}
+
+// WordRating returns how well a word is known when it has several cards, e.g.
+// when it was mined from different sentences. The best known card counts,
+// words without reviewed cards are new.
+func WordRating(states []State) int {
+	rating := RatingNew
+	for _, s := range states {
+		rating = max(rating, Rating(s))
+	}
+
+	return rating
+}
@@ -0,0 +1,155 @@
+package srs
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReview(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		state  State
+		grades []int
+		// Interval and ease factor after every review
+		intervals []int
+		eases     []float64
+	}{
+		{
+			name:      "good answers keep the ease factor",
+			state:     New(now),
+			grades:    []int{GradeGood, GradeGood, GradeGood, GradeGood},
+			intervals: []int{1, 6, 15, 38},
+			eases:     []float64{2.5, 2.5, 2.5, 2.5},
+		},
+		{
+			name:      "perfect answers increase the ease factor",
+			state:     New(now),
+			grades:    []int{GradePerfect, GradePerfect, GradePerfect},
+			intervals: []int{1, 6, 16},
+			eases:     []float64{2.6, 2.7, 2.8},
+		},
+		{
+			name:      "hard answers decrease the ease factor",
+			state:     New(now),
+			grades:    []int{GradeHard, GradeHard, GradeHard},
+			intervals: []int{1, 6, 13},
+			eases:     []float64{2.36, 2.22, 2.08},
+		},
+		{
+			name:      "ease factor doesn't go below the minimum",
+			state:     State{Repetitions: 2, Interval: 10, EaseFactor: 1.35},
+			grades:    []int{GradeHard, GradeHard},
+			intervals: []int{14, 18},
+			eases:     []float64{1.3, 1.3},
+		},
+		{
+			name:      "wrong answers start over without changing the ease factor",
+			state:     State{Repetitions: 3, Interval: 15, EaseFactor: 2.5},
+			grades:    []int{GradeAlmost, GradeGood, GradeGood},
+			intervals: []int{1, 1, 6},
+			eases:     []float64{2.5, 2.5, 2.5},
+		},
+		{
+			name:      "blackout",
+			state:     State{Repetitions: 5, Interval: 60, EaseFactor: 1.8},
+			grades:    []int{GradeBlackout},
+			intervals: []int{1},
+			eases:     []float64{1.8},
+		},
+		{
+			name:      "missing ease factor starts at the initial ease",
+			state:     State{},
+			grades:    []int{GradeGood},
+			intervals: []int{1},
+			eases:     []float64{2.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.state
+
+			for i, grade := range tt.grades {
+				next, err := Review(s, grade, now)
+				if err != nil {
+					t.Fatalf("review %d: unexpected error: %v", i+1, err)
+				}
+				s = next
+
+				if s.Interval != tt.intervals[i] {
+					t.Errorf("review %d: interval %d, want %d", i+1, s.Interval, tt.intervals[i])
+				}
+
+				if math.Abs(s.EaseFactor-tt.eases[i]) > 1e-9 {
+					t.Errorf("review %d: ease factor %v, want %v", i+1, s.EaseFactor, tt.eases[i])
+				}
+
+				if due := now.AddDate(0, 0, s.Interval); !s.Due.Equal(due) {
+					t.Errorf("review %d: due %v, want %v", i+1, s.Due, due)
+				}
+			}
+		})
+	}
+}
+
+func TestReviewInvalidGrade(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, grade := range []int{MinGrade - 1, MaxGrade + 1} {
+		if _, err := Review(New(now), grade, now); err != ErrInvalidGrade {
+			t.Errorf("grade %d: expected ErrInvalidGrade, got %v", grade, err)
+		}
+	}
+}
+
+func TestRating(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  State
+		rating int
+	}{
+		{"never reviewed", State{EaseFactor: 2.5}, RatingNew},
+		{"lapsed", State{Repetitions: 0, Interval: 1, EaseFactor: 2.5}, RatingNew},
+		{"first review", State{Repetitions: 1, Interval: 1}, RatingLearned},
+		{"second review", State{Repetitions: 2, Interval: 6}, RatingLearned},
+		{"a week", State{Repetitions: 3, Interval: 7}, RatingComfortable},
+		{"three weeks", State{Repetitions: 4, Interval: 21}, RatingMature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Rating(tt.state); got != tt.rating {
+				t.Errorf("rating %d, want %d", got, tt.rating)
+			}
+		})
+	}
+}
+
+func TestWordRating(t *testing.T) {
+	learned := State{Repetitions: 1, Interval: 1}
+	mature := State{Repetitions: 5, Interval: 40}
+	lapsed := State{Repetitions: 0, Interval: 1}
+
+	tests := []struct {
+		name   string
+		states []State
+		want   int
+	}{
+		{name: "no reviewed cards", states: nil, want: RatingNew},
+		{name: "one card", states: []State{learned}, want: RatingLearned},
+		{name: "the best known card counts", states: []State{mature, learned}, want: RatingMature},
+		{name: "a lapsed card doesn't make the word new", states: []State{lapsed, mature}, want: RatingMature},
+		{name: "lapsed cards only", states: []State{lapsed}, want: RatingNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WordRating(tt.states); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,21 +7,85 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 )
 
+const archiveCard = `-- name: ArchiveCard :execrows
+update pending_cards
+set
+  updated_at = now(),
+  archived_at = now()
+where id = $1
+`
+
+func (q *Queries) ArchiveCard(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, archiveCard, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countCards = `-- name: CountCards :one
+select count(*)
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+`
+
+type CountCardsParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+}
+
+func (q *Queries) CountCards(ctx context.Context, arg CountCardsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCards,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPendingCard = `-- name: CreatePendingCard :one
 insert into pending_cards (
   language_code,
   token,
-  source_image,
-  meta
+  image_key,
+  thumbnail_key,
+  meta,
+  text_id,
+  text_offset,
+  annotation_id
 ) values (
   $1,
   $2,
-  decode($3::text, 'base64'),
-  $4
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8
 )
 returning id
 `
@@ -29,33 +93,411 @@ returning id
 type CreatePendingCardParams struct {
 	LanguageCode string
 	Token        string
-	SourceImage  string
+	ImageKey     sql.NullString
+	ThumbnailKey sql.NullString
 	Meta         json.RawMessage
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
 }
 
 func (q *Queries) CreatePendingCard(ctx context.Context, arg CreatePendingCardParams) (int64, error) {
 	row := q.db.QueryRowContext(ctx, createPendingCard,
 		arg.LanguageCode,
 		arg.Token,
-		arg.SourceImage,
+		arg.ImageKey,
+		arg.ThumbnailKey,
 		arg.Meta,
+		arg.TextID,
+		arg.TextOffset,
+		arg.AnnotationID,
 	)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
 
+const deleteCard = `-- name: DeleteCard :execrows
+delete from pending_cards
+where id = $1
+`
+
+func (q *Queries) DeleteCard(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCard, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCard = `-- name: GetCard :one
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at
+from pending_cards
+where id = $1
+`
+
+type GetCardRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+}
+
+func (q *Queries) GetCard(ctx context.Context, id int64) (GetCardRow, error) {
+	row := q.db.QueryRowContext(ctx, getCard, id)
+	var i GetCardRow
+	err := row.Scan(
+		&i.ID,
+		&i.LanguageCode,
+		&i.Token,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExportedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const getCardForUpdate = `-- name: GetCardForUpdate :one
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at
+from pending_cards
+where id = $1
+for update
+`
+
+type GetCardForUpdateRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+}
+
+func (q *Queries) GetCardForUpdate(ctx context.Context, id int64) (GetCardForUpdateRow, error) {
+	row := q.db.QueryRowContext(ctx, getCardForUpdate, id)
+	var i GetCardForUpdateRow
+	err := row.Scan(
+		&i.ID,
+		&i.LanguageCode,
+		&i.Token,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExportedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
 const getImageFromPendingCard = `-- name: GetImageFromPendingCard :one
-select source_image
+select
+  image_key,
+  thumbnail_key,
+  source_image
 from pending_cards
 where id = $1
 `
 
-func (q *Queries) GetImageFromPendingCard(ctx context.Context, id int64) ([]byte, error) {
+type GetImageFromPendingCardRow struct {
+	ImageKey     sql.NullString
+	ThumbnailKey sql.NullString
+	SourceImage  []byte
+}
+
+func (q *Queries) GetImageFromPendingCard(ctx context.Context, id int64) (GetImageFromPendingCardRow, error) {
 	row := q.db.QueryRowContext(ctx, getImageFromPendingCard, id)
-	var source_image []byte
-	err := row.Scan(&source_image)
-	return source_image, err
+	var i GetImageFromPendingCardRow
+	err := row.Scan(&i.ImageKey, &i.ThumbnailKey, &i.SourceImage)
+	return i, err
+}
+
+const listCards = `-- name: ListCards :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  ($1::varchar is null or language_code = $1)
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+order by created_at asc
+`
+
+type ListCardsParams struct {
+	LanguageCode  sql.NullString
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+}
+
+type ListCardsRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) ListCards(ctx context.Context, arg ListCardsParams) ([]ListCardsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCards,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCardsRow
+	for rows.Next() {
+		var i ListCardsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCardsWithImages = `-- name: ListCardsWithImages :many
+select
+  id,
+  language_code,
+  token,
+  image_key,
+  source_image,
+  meta,
+  created_at,
+  exported_at,
+  archived_at
+from pending_cards
+where
+  ($1::varchar is null or language_code = $1)
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+order by created_at asc
+`
+
+type ListCardsWithImagesParams struct {
+	LanguageCode sql.NullString
+	Exported     sql.NullBool
+	Archived     sql.NullBool
+}
+
+type ListCardsWithImagesRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	ImageKey     sql.NullString
+	SourceImage  []byte
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+}
+
+func (q *Queries) ListCardsWithImages(ctx context.Context, arg ListCardsWithImagesParams) ([]ListCardsWithImagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCardsWithImages, arg.LanguageCode, arg.Exported, arg.Archived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCardsWithImagesRow
+	for rows.Next() {
+		var i ListCardsWithImagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.ImageKey,
+			&i.SourceImage,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCardsWithLegacyImages = `-- name: ListCardsWithLegacyImages :many
+select
+  id,
+  source_image
+from pending_cards
+where
+  source_image is not null
+  and image_key is null
+order by id asc
+limit $1
+`
+
+type ListCardsWithLegacyImagesRow struct {
+	ID          int64
+	SourceImage []byte
+}
+
+func (q *Queries) ListCardsWithLegacyImages(ctx context.Context, limit int32) ([]ListCardsWithLegacyImagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCardsWithLegacyImages, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCardsWithLegacyImagesRow
+	for rows.Next() {
+		var i ListCardsWithLegacyImagesRow
+		if err := rows.Scan(&i.ID, &i.SourceImage); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCardTextOffsets = `-- name: ListCardTextOffsets :many
+select
+  id,
+  text_offset
+from pending_cards
+where
+  text_id = $1
+  and text_offset is not null
+`
+
+type ListCardTextOffsetsRow struct {
+	ID         int64
+	TextOffset sql.NullInt32
+}
+
+func (q *Queries) ListCardTextOffsets(ctx context.Context, textID sql.NullInt64) ([]ListCardTextOffsetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCardTextOffsets, textID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCardTextOffsetsRow
+	for rows.Next() {
+		var i ListCardTextOffsetsRow
+		if err := rows.Scan(&i.ID, &i.TextOffset); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCardTokensForLanguage = `-- name: ListCardTokensForLanguage :many
+select distinct token
+from pending_cards
+where
+  language_code = $1
+order by token
+`
+
+func (q *Queries) ListCardTokensForLanguage(ctx context.Context, languageCode string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listCardTokensForLanguage, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, err
+		}
+		items = append(items, token)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const listPendingCards = `-- name: ListPendingCards :many
@@ -69,6 +511,7 @@ select
 from pending_cards
 where
   exported_at is null
+  and archived_at is null
   and language_code = $1
 order by created_at asc
 `
@@ -112,17 +555,829 @@ func (q *Queries) ListPendingCards(ctx context.Context, languageCode string) ([]
 	return items, nil
 }
 
-const markCardAsExported = `-- name: MarkCardAsExported :exec
-update pending_cards
-set
-  updated_at = now(),
-  exported_at = now()
-where id = $1
-`
-
-func (q *Queries) MarkCardAsExported(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, markCardAsExported, id)
-	return err
+const listPendingCardsForExport = `-- name: ListPendingCardsForExport :many
+select
+  id,
+  language_code,
+  token,
+  image_key,
+  source_image,
+  meta,
+  updated_at
+from pending_cards
+where
+  exported_at is null
+  and archived_at is null
+  and language_code = $1
+order by created_at asc
+`
+
+type ListPendingCardsForExportRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	ImageKey     sql.NullString
+	SourceImage  []byte
+	Meta         json.RawMessage
+	UpdatedAt    time.Time
+}
+
+func (q *Queries) ListPendingCardsForExport(ctx context.Context, languageCode string) ([]ListPendingCardsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingCardsForExport, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingCardsForExportRow
+	for rows.Next() {
+		var i ListPendingCardsForExportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.ImageKey,
+			&i.SourceImage,
+			&i.Meta,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPendingCardsForExport = `-- name: LockPendingCardsForExport :many
+select
+  id,
+  updated_at
+from pending_cards
+where
+  exported_at is null
+  and archived_at is null
+  and language_code = $1
+order by created_at asc
+for update
+`
+
+type LockPendingCardsForExportRow struct {
+	ID        int64
+	UpdatedAt time.Time
+}
+
+func (q *Queries) LockPendingCardsForExport(ctx context.Context, languageCode string) ([]LockPendingCardsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, lockPendingCardsForExport, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LockPendingCardsForExportRow
+	for rows.Next() {
+		var i LockPendingCardsForExportRow
+		if err := rows.Scan(&i.ID, &i.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markCardAsExported = `-- name: MarkCardAsExported :exec
+update pending_cards
+set
+  updated_at = now(),
+  exported_at = now()
+where id = $1
+`
+
+func (q *Queries) MarkCardAsExported(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markCardAsExported, id)
+	return err
+}
+
+const searchCardsByCreatedAt = `-- name: SearchCardsByCreatedAt :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+  and (
+    $7::bigint is null
+    or (created_at, id) > ($8::timestamp, $7)
+  )
+order by created_at asc, id asc
+limit $9
+`
+
+type SearchCardsByCreatedAtParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+	AfterID       sql.NullInt64
+	AfterTime     sql.NullTime
+	Limit         sql.NullInt32
+}
+
+type SearchCardsByCreatedAtRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) SearchCardsByCreatedAt(ctx context.Context, arg SearchCardsByCreatedAtParams) ([]SearchCardsByCreatedAtRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCardsByCreatedAt,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCardsByCreatedAtRow
+	for rows.Next() {
+		var i SearchCardsByCreatedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchCardsByCreatedAtDesc = `-- name: SearchCardsByCreatedAtDesc :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+  and (
+    $7::bigint is null
+    or (created_at, id) < ($8::timestamp, $7)
+  )
+order by created_at desc, id desc
+limit $9
+`
+
+type SearchCardsByCreatedAtDescParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+	AfterID       sql.NullInt64
+	AfterTime     sql.NullTime
+	Limit         sql.NullInt32
+}
+
+type SearchCardsByCreatedAtDescRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) SearchCardsByCreatedAtDesc(ctx context.Context, arg SearchCardsByCreatedAtDescParams) ([]SearchCardsByCreatedAtDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCardsByCreatedAtDesc,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCardsByCreatedAtDescRow
+	for rows.Next() {
+		var i SearchCardsByCreatedAtDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchCardsByToken = `-- name: SearchCardsByToken :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+  and (
+    $7::bigint is null
+    or (token, id) > ($8::text, $7)
+  )
+order by token asc, id asc
+limit $9
+`
+
+type SearchCardsByTokenParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+	AfterID       sql.NullInt64
+	AfterText     sql.NullString
+	Limit         sql.NullInt32
+}
+
+type SearchCardsByTokenRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) SearchCardsByToken(ctx context.Context, arg SearchCardsByTokenParams) ([]SearchCardsByTokenRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCardsByToken,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+		arg.AfterID,
+		arg.AfterText,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCardsByTokenRow
+	for rows.Next() {
+		var i SearchCardsByTokenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchCardsByTokenDesc = `-- name: SearchCardsByTokenDesc :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+  and (
+    $7::bigint is null
+    or (token, id) < ($8::text, $7)
+  )
+order by token desc, id desc
+limit $9
+`
+
+type SearchCardsByTokenDescParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+	AfterID       sql.NullInt64
+	AfterText     sql.NullString
+	Limit         sql.NullInt32
+}
+
+type SearchCardsByTokenDescRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) SearchCardsByTokenDesc(ctx context.Context, arg SearchCardsByTokenDescParams) ([]SearchCardsByTokenDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCardsByTokenDesc,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+		arg.AfterID,
+		arg.AfterText,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCardsByTokenDescRow
+	for rows.Next() {
+		var i SearchCardsByTokenDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchCardsByUpdatedAt = `-- name: SearchCardsByUpdatedAt :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+  and (
+    $7::bigint is null
+    or (updated_at, id) > ($8::timestamp, $7)
+  )
+order by updated_at asc, id asc
+limit $9
+`
+
+type SearchCardsByUpdatedAtParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+	AfterID       sql.NullInt64
+	AfterTime     sql.NullTime
+	Limit         sql.NullInt32
+}
+
+type SearchCardsByUpdatedAtRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) SearchCardsByUpdatedAt(ctx context.Context, arg SearchCardsByUpdatedAtParams) ([]SearchCardsByUpdatedAtRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCardsByUpdatedAt,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCardsByUpdatedAtRow
+	for rows.Next() {
+		var i SearchCardsByUpdatedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchCardsByUpdatedAtDesc = `-- name: SearchCardsByUpdatedAtDesc :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = $1
+  and ($2::boolean is null or (exported_at is not null) = $2)
+  and ($3::boolean is null or (archived_at is not null) = $3)
+  and ($4::timestamp is null or created_at >= $4)
+  and ($5::timestamp is null or created_at < $5)
+  and (
+    $6::text is null
+    or token ilike $6
+    or card_meta_values(meta) ilike $6
+  )
+  and (
+    $7::bigint is null
+    or (updated_at, id) < ($8::timestamp, $7)
+  )
+order by updated_at desc, id desc
+limit $9
+`
+
+type SearchCardsByUpdatedAtDescParams struct {
+	LanguageCode  string
+	Exported      sql.NullBool
+	Archived      sql.NullBool
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	Pattern       sql.NullString
+	AfterID       sql.NullInt64
+	AfterTime     sql.NullTime
+	Limit         sql.NullInt32
+}
+
+type SearchCardsByUpdatedAtDescRow struct {
+	ID           int64
+	LanguageCode string
+	Token        string
+	Meta         json.RawMessage
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
+}
+
+func (q *Queries) SearchCardsByUpdatedAtDesc(ctx context.Context, arg SearchCardsByUpdatedAtDescParams) ([]SearchCardsByUpdatedAtDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchCardsByUpdatedAtDesc,
+		arg.LanguageCode,
+		arg.Exported,
+		arg.Archived,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.Pattern,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchCardsByUpdatedAtDescRow
+	for rows.Next() {
+		var i SearchCardsByUpdatedAtDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Token,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExportedAt,
+			&i.ArchivedAt,
+			&i.TextID,
+			&i.TextOffset,
+			&i.AnnotationID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCardImageKeys = `-- name: SetCardImageKeys :exec
+update pending_cards
+set
+  image_key = $1,
+  thumbnail_key = $2,
+  source_image = null
+where id = $3
+`
+
+type SetCardImageKeysParams struct {
+	ImageKey     sql.NullString
+	ThumbnailKey sql.NullString
+	ID           int64
+}
+
+func (q *Queries) SetCardImageKeys(ctx context.Context, arg SetCardImageKeysParams) error {
+	_, err := q.db.ExecContext(ctx, setCardImageKeys, arg.ImageKey, arg.ThumbnailKey, arg.ID)
+	return err
+}
+
+const setCardTextOffset = `-- name: SetCardTextOffset :exec
+update pending_cards
+set text_offset = $1
+where id = $2
+`
+
+type SetCardTextOffsetParams struct {
+	TextOffset sql.NullInt32
+	ID         int64
+}
+
+func (q *Queries) SetCardTextOffset(ctx context.Context, arg SetCardTextOffsetParams) error {
+	_, err := q.db.ExecContext(ctx, setCardTextOffset, arg.TextOffset, arg.ID)
+	return err
+}
+
+const setCardTimestamps = `-- name: SetCardTimestamps :exec
+update pending_cards
+set
+  created_at = coalesce($1, created_at),
+  exported_at = $2,
+  archived_at = $3
+where id = $4
+`
+
+type SetCardTimestampsParams struct {
+	CreatedAt  sql.NullTime
+	ExportedAt sql.NullTime
+	ArchivedAt sql.NullTime
+	ID         int64
+}
+
+func (q *Queries) SetCardTimestamps(ctx context.Context, arg SetCardTimestampsParams) error {
+	_, err := q.db.ExecContext(ctx, setCardTimestamps, arg.CreatedAt, arg.ExportedAt, arg.ArchivedAt, arg.ID)
+	return err
+}
+
+const unarchiveCard = `-- name: UnarchiveCard :execrows
+update pending_cards
+set
+  updated_at = now(),
+  archived_at = null
+where id = $1
+`
+
+func (q *Queries) UnarchiveCard(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unarchiveCard, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unmarkCardAsExported = `-- name: UnmarkCardAsExported :execrows
+update pending_cards
+set
+  updated_at = now(),
+  exported_at = null
+where id = $1
+`
+
+func (q *Queries) UnmarkCardAsExported(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unmarkCardAsExported, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const updateCard = `-- name: UpdateCard :exec
@@ -0,0 +1,3 @@
+drop index if exists word_tokens_language_code_token_key;
+drop table if exists review_logs;
+drop table if exists reviews;
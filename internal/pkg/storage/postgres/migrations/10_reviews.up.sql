@@ -0,0 +1,40 @@
+-- Scheduling state of cards reviewed in the API, cards without a row are new
+create table reviews (
+  card_id bigint primary key references pending_cards (id) on delete cascade,
+  repetitions integer not null default 0,
+  interval_days integer not null default 0,
+  ease_factor double precision not null default 2.5,
+  due_at timestamp not null default now(),
+
+  created_at timestamp not null default now(),
+  updated_at timestamp not null default now()
+);
+
+create index reviews_due_at_idx on reviews (due_at);
+
+create table review_logs (
+  id bigserial primary key,
+  card_id bigint not null references pending_cards (id) on delete cascade,
+  -- Grade: 0-5 following SM-2
+  grade smallint not null,
+  -- State after the review
+  repetitions integer not null,
+  interval_days integer not null,
+  ease_factor double precision not null,
+  due_at timestamp not null,
+
+  reviewed_at timestamp not null default now()
+);
+
+create index review_logs_card_id_idx on review_logs (card_id, reviewed_at);
+
+-- Ratings are saved per word, so a word must only be tracked once. Words that
+-- were added twice keep their most recently updated row.
+delete from word_tokens
+using word_tokens newer
+where
+  word_tokens.language_code = newer.language_code
+  and word_tokens.token = newer.token
+  and (word_tokens.updated_at, word_tokens.id) < (newer.updated_at, newer.id);
+
+create unique index word_tokens_language_code_token_key on word_tokens (language_code, token);
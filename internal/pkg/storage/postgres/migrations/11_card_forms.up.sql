@@ -0,0 +1,15 @@
+-- Normalised forms of the token and reading of cards, used to find
+-- duplicates without comparing every card
+create table card_forms (
+  card_id bigint not null references pending_cards (id) on delete cascade,
+  language_code varchar(10) not null,
+  -- spelling or reading
+  kind varchar(10) not null,
+  form text not null,
+  -- Readings are only compared to cards written in kana
+  kana_only boolean not null default false,
+
+  primary key (card_id, kind, form)
+);
+
+create index card_forms_language_code_form_idx on card_forms (language_code, form);
@@ -0,0 +1,13 @@
+drop index texts_language_code_title_idx;
+drop index texts_language_code_updated_at_idx;
+drop index texts_language_code_created_at_idx;
+drop index pending_cards_language_code_token_idx;
+drop index pending_cards_language_code_updated_at_idx;
+drop index pending_cards_language_code_created_at_idx;
+drop index texts_title_trgm_idx;
+drop index pending_cards_meta_values_trgm_idx;
+drop index pending_cards_token_trgm_idx;
+
+drop function card_meta_values(jsonb);
+
+drop extension if exists pg_trgm;
@@ -0,0 +1,23 @@
+create extension if not exists pg_trgm;
+
+-- The string values in the meta of a card, so searching the meta doesn't
+-- match its keys
+create function card_meta_values(meta jsonb) returns text
+language sql immutable parallel safe
+as $$
+  select coalesce(string_agg(value #>> '{}', ' '), '')
+  from jsonb_path_query(meta, 'strict $.** ? (@.type() == "string")') as value
+$$;
+
+-- Substring search on cards and texts
+create index pending_cards_token_trgm_idx on pending_cards using gin (token gin_trgm_ops);
+create index pending_cards_meta_values_trgm_idx on pending_cards using gin (card_meta_values(meta) gin_trgm_ops);
+create index texts_title_trgm_idx on texts using gin (title gin_trgm_ops);
+
+-- Listings are sorted by one of these columns with the id as a tie breaker
+create index pending_cards_language_code_created_at_idx on pending_cards (language_code, created_at, id);
+create index pending_cards_language_code_updated_at_idx on pending_cards (language_code, updated_at, id);
+create index pending_cards_language_code_token_idx on pending_cards (language_code, token, id);
+create index texts_language_code_created_at_idx on texts (language_code, created_at, id);
+create index texts_language_code_updated_at_idx on texts (language_code, updated_at, id);
+create index texts_language_code_title_idx on texts (language_code, title, id);
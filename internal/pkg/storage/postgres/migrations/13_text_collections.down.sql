@@ -0,0 +1,5 @@
+drop table if exists text_tags;
+drop index if exists texts_collection_id_idx;
+alter table texts drop column if exists collection_order;
+alter table texts drop column if exists collection_id;
+drop table if exists collections;
@@ -0,0 +1,26 @@
+create table collections (
+  id bigserial primary key,
+  language_code varchar(3) not null,
+  title text not null,
+
+  created_at timestamp not null default now(),
+  updated_at timestamp not null default now()
+);
+
+create index collections_language_code_idx on collections (language_code, title);
+
+-- Texts are kept when their collection is deleted
+alter table texts add column collection_id bigint default NULL references collections (id) on delete set null;
+-- Order of the text in its collection, e.g. the chapter of a book
+alter table texts add column collection_order integer default NULL;
+
+create index texts_collection_id_idx on texts (collection_id, collection_order);
+
+create table text_tags (
+  text_id bigint not null references texts (id) on delete cascade,
+  tag text not null,
+
+  primary key (text_id, tag)
+);
+
+create index text_tags_tag_idx on text_tags (tag);
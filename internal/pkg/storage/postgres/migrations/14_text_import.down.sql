@@ -0,0 +1,2 @@
+alter table collections drop column if exists author;
+alter table texts drop column if exists ruby;
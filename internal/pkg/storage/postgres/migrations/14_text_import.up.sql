@@ -0,0 +1,4 @@
+-- Ruby annotations of a text, see the textfile package
+alter table texts add column ruby jsonb not null default '[]';
+
+alter table collections add column author text not null default '';
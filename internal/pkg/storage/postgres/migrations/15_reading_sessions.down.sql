@@ -0,0 +1 @@
+drop table if exists reading_sessions;
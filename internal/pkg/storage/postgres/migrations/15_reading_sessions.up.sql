@@ -0,0 +1,19 @@
+create table reading_sessions (
+  id bigserial primary key,
+  -- Sessions are kept for the statistics when their text is deleted
+  text_id bigint default NULL references texts (id) on delete set null,
+  language_code varchar(3) not null,
+  -- Reading positions (lines) at the start and end of the session
+  start_position integer not null default 0,
+  end_position integer default NULL,
+  characters_read integer not null default 0,
+  lookups integer not null default 0,
+
+  started_at timestamp not null default now(),
+  ended_at timestamp default NULL
+);
+
+create index reading_sessions_language_code_idx on reading_sessions (language_code, started_at);
+
+-- Cards are attributed to the reading session of their text
+create index reading_sessions_text_id_started_at_idx on reading_sessions (text_id, started_at);
@@ -0,0 +1,5 @@
+alter table pending_cards drop column if exists annotation_id;
+alter table pending_cards drop column if exists text_offset;
+alter table pending_cards drop column if exists text_id;
+
+drop table if exists annotations;
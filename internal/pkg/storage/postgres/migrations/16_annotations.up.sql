@@ -0,0 +1,28 @@
+create table annotations (
+  id bigserial primary key,
+  text_id bigint not null references texts (id) on delete cascade,
+  -- highlight, note, translation or bookmark
+  kind varchar(20) not null,
+  -- Character offsets in texts.content, the end is exclusive
+  start_offset integer not null,
+  end_offset integer not null,
+  -- The annotated text, used to attach the annotation again when the content
+  -- of the text changes
+  quote text not null default '',
+  body text not null default '',
+  -- The quote couldn't be found in the content after an edit
+  orphaned boolean not null default false,
+
+  created_at timestamp not null default now(),
+  updated_at timestamp not null default now()
+);
+
+create index annotations_text_id_idx on annotations (text_id, start_offset);
+
+-- Where in a text a card was mined
+alter table pending_cards add column text_id bigint default NULL references texts (id) on delete set null;
+alter table pending_cards add column text_offset integer default NULL;
+alter table pending_cards add column annotation_id bigint default NULL references annotations (id) on delete set null;
+
+-- Cards are also attributed to the reading session they were mined in
+create index pending_cards_text_id_created_at_idx on pending_cards (text_id, created_at);
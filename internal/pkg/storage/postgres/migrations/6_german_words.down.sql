@@ -0,0 +1 @@
+drop table if exists german_words;
\ No newline at end of file
@@ -0,0 +1,18 @@
+create table german_words (
+  id bigserial primary key,
+  lemma text not null,
+  pos text not null,
+  -- Gender: m, f, n or empty when unknown or not a noun
+  gender varchar(1) not null default '',
+  plural text not null default '',
+  genitive text not null default '',
+  -- Auxiliary verb for the perfect tense: haben or sein
+  auxiliary text not null default '',
+  separable_prefix text not null default '',
+  forms jsonb not null default '[]',
+  senses jsonb not null default '[]',
+
+  created_at timestamp not null default now()
+);
+
+create index german_words_lemma_idx on german_words (lemma);
@@ -0,0 +1,2 @@
+drop table if exists card_events;
+alter table pending_cards drop column if exists archived_at;
\ No newline at end of file
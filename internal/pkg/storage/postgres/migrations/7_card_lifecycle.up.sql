@@ -0,0 +1,16 @@
+alter table pending_cards add column archived_at timestamp default NULL;
+
+create table card_events (
+  id bigserial primary key,
+  -- Not a foreign key, events are kept after a card is deleted
+  card_id bigint not null,
+  -- Event type: created, meta_updated, exported, unexported, archived, unarchived or deleted
+  event_type varchar(20) not null,
+  -- Meta for meta changes, otherwise the state of the card
+  before jsonb not null default '{}',
+  after jsonb not null default '{}',
+
+  created_at timestamp not null default now()
+);
+
+create index card_events_card_id_idx on card_events (card_id, created_at);
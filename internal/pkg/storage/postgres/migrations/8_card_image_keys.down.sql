@@ -0,0 +1,2 @@
+alter table pending_cards drop column if exists thumbnail_key;
+alter table pending_cards drop column if exists image_key;
@@ -0,0 +1,4 @@
+-- Images are kept in the blob store, run cmd/migrate_card_images to move
+-- existing source_image data out of the table
+alter table pending_cards add column image_key varchar(64) default NULL;
+alter table pending_cards add column thumbnail_key varchar(64) default NULL;
@@ -0,0 +1,18 @@
+create table card_enrichments (
+  card_id bigint not null references pending_cards (id) on delete cascade,
+  -- Lookup provider, e.g. jisho, goo, zdic, cedict or gtranslate
+  provider varchar(20) not null,
+  -- Status: pending, succeeded, not_found or failed
+  status varchar(20) not null default 'pending',
+  error text not null default '',
+  -- Meta keys that were filled by the provider
+  fields jsonb not null default '[]',
+  attempts integer not null default 0,
+
+  created_at timestamp not null default now(),
+  updated_at timestamp not null default now(),
+
+  primary key (card_id, provider)
+);
+
+create index card_enrichments_status_idx on card_enrichments (status);
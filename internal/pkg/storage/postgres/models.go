@@ -12,6 +12,70 @@ import (
 	"github.com/google/uuid"
 )
 
+type Annotation struct {
+	ID          int64
+	TextID      int64
+	Kind        string
+	StartOffset int32
+	EndOffset   int32
+	Quote       string
+	Body        string
+	Orphaned    bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type CardEnrichment struct {
+	CardID    int64
+	Provider  string
+	Status    string
+	Error     string
+	Fields    json.RawMessage
+	Attempts  int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type CardForm struct {
+	CardID       int64
+	LanguageCode string
+	Kind         string
+	Form         string
+	KanaOnly     bool
+}
+
+type CardEvent struct {
+	ID        int64
+	CardID    int64
+	EventType string
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+type Collection struct {
+	ID           int64
+	LanguageCode string
+	Title        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Author       string
+}
+
+type GermanWord struct {
+	ID              int64
+	Lemma           string
+	Pos             string
+	Gender          string
+	Plural          string
+	Genitive        string
+	Auxiliary       string
+	SeparablePrefix string
+	Forms           json.RawMessage
+	Senses          json.RawMessage
+	CreatedAt       time.Time
+}
+
 type PendingCard struct {
 	ID           int64
 	LanguageCode string
@@ -21,16 +85,63 @@ type PendingCard struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	ExportedAt   sql.NullTime
+	ArchivedAt   sql.NullTime
+	ImageKey     sql.NullString
+	ThumbnailKey sql.NullString
+	TextID       sql.NullInt64
+	TextOffset   sql.NullInt32
+	AnnotationID sql.NullInt64
 }
 
-type Text struct {
-	ID           int64
-	LanguageCode string
-	Title        string
-	Content      string
+type ReadingSession struct {
+	ID             int64
+	TextID         sql.NullInt64
+	LanguageCode   string
+	StartPosition  int32
+	EndPosition    sql.NullInt32
+	CharactersRead int32
+	Lookups        int32
+	StartedAt      time.Time
+	EndedAt        sql.NullTime
+}
+
+type Review struct {
+	CardID       int64
+	Repetitions  int32
+	IntervalDays int32
+	EaseFactor   float64
+	DueAt        time.Time
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
-	LastPosition int32
+}
+
+type ReviewLog struct {
+	ID           int64
+	CardID       int64
+	Grade        int16
+	Repetitions  int32
+	IntervalDays int32
+	EaseFactor   float64
+	DueAt        time.Time
+	ReviewedAt   time.Time
+}
+
+type Text struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	Content         string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	LastPosition    int32
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Ruby            json.RawMessage
+}
+
+type TextTag struct {
+	TextID int64
+	Tag    string
 }
 
 type Translation struct {
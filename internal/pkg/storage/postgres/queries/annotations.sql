@@ -0,0 +1,82 @@
+-- name: ListAnnotations :many
+select
+  id,
+  text_id,
+  kind,
+  start_offset,
+  end_offset,
+  quote,
+  body,
+  orphaned,
+  (
+    select coalesce(jsonb_agg(pending_cards.id order by pending_cards.id), '[]'::jsonb)
+    from pending_cards
+    where pending_cards.annotation_id = annotations.id
+  )::jsonb as card_ids,
+  created_at,
+  updated_at
+from annotations
+where text_id = sqlc.arg('text_id')
+order by start_offset asc, id asc;
+
+-- name: GetAnnotation :one
+select
+  id,
+  text_id,
+  kind,
+  start_offset,
+  end_offset,
+  quote,
+  body,
+  orphaned,
+  created_at,
+  updated_at
+from annotations
+where id = sqlc.arg('id');
+
+-- name: CreateAnnotation :one
+insert into annotations (
+  text_id,
+  kind,
+  start_offset,
+  end_offset,
+  quote,
+  body
+) values (
+  sqlc.arg('text_id'),
+  sqlc.arg('kind'),
+  sqlc.arg('start_offset'),
+  sqlc.arg('end_offset'),
+  sqlc.arg('quote'),
+  sqlc.arg('body')
+)
+returning id;
+
+-- name: UpdateAnnotation :execrows
+update annotations
+set
+  kind = sqlc.arg('kind'),
+  start_offset = sqlc.arg('start_offset'),
+  end_offset = sqlc.arg('end_offset'),
+  quote = sqlc.arg('quote'),
+  body = sqlc.arg('body'),
+  orphaned = false,
+  updated_at = now()
+where
+  id = sqlc.arg('id')
+  and text_id = sqlc.arg('text_id');
+
+-- name: SetAnnotationAnchor :exec
+update annotations
+set
+  start_offset = sqlc.arg('start_offset'),
+  end_offset = sqlc.arg('end_offset'),
+  orphaned = sqlc.arg('orphaned'),
+  updated_at = now()
+where id = sqlc.arg('id');
+
+-- name: DeleteAnnotation :execrows
+delete from annotations
+where
+  id = sqlc.arg('id')
+  and text_id = sqlc.arg('text_id');
@@ -0,0 +1,56 @@
+-- name: ScheduleCardEnrichment :exec
+insert into card_enrichments (
+  card_id,
+  provider
+) values (
+  sqlc.arg('card_id'),
+  sqlc.arg('provider')
+)
+on conflict (card_id, provider) do update
+set
+  status = 'pending',
+  error = '',
+  updated_at = now();
+
+-- name: ListPendingEnrichmentCardIDs :many
+select distinct card_id
+from card_enrichments
+where status = 'pending'
+order by card_id asc
+limit sqlc.arg('limit');
+
+-- name: ListCardEnrichments :many
+select
+  card_id,
+  provider,
+  status,
+  error,
+  fields,
+  attempts,
+  created_at,
+  updated_at
+from card_enrichments
+where card_id = sqlc.arg('card_id')
+order by created_at asc, provider asc;
+
+-- name: UpdateCardEnrichment :exec
+update card_enrichments
+set
+  status = sqlc.arg('status'),
+  error = sqlc.arg('error'),
+  fields = sqlc.arg('fields'),
+  attempts = attempts + 1,
+  updated_at = now()
+where
+  card_id = sqlc.arg('card_id')
+  and provider = sqlc.arg('provider');
+
+-- name: RetryCardEnrichments :execrows
+update card_enrichments
+set
+  status = 'pending',
+  error = '',
+  updated_at = now()
+where
+  card_id = sqlc.arg('card_id')
+  and status in ('failed', 'not_found');
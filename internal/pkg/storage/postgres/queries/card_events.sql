@@ -0,0 +1,24 @@
+-- name: CreateCardEvent :exec
+insert into card_events (
+  card_id,
+  event_type,
+  before,
+  after
+) values (
+  sqlc.arg('card_id'),
+  sqlc.arg('event_type'),
+  sqlc.arg('before'),
+  sqlc.arg('after')
+);
+
+-- name: ListCardEvents :many
+select
+  id,
+  card_id,
+  event_type,
+  before,
+  after,
+  created_at
+from card_events
+where card_id = sqlc.arg('card_id')
+order by created_at asc, id asc;
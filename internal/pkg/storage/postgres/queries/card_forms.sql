@@ -0,0 +1,68 @@
+-- name: LockCardForms :exec
+select pg_advisory_xact_lock(hashtext('card_forms:' || sqlc.arg('language_code')::varchar));
+
+-- name: DeleteCardForms :exec
+delete from card_forms
+where card_id = sqlc.arg('card_id');
+
+-- name: CreateCardForm :exec
+insert into card_forms (
+  card_id,
+  language_code,
+  kind,
+  form,
+  kana_only
+) values (
+  sqlc.arg('card_id'),
+  sqlc.arg('language_code'),
+  sqlc.arg('kind'),
+  sqlc.arg('form'),
+  sqlc.arg('kana_only')
+)
+on conflict do nothing;
+
+-- name: FindCardsByForms :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  archived_at is null
+  and id in (
+    select card_id
+    from card_forms
+    where
+      language_code = sqlc.arg('language_code')
+      and (
+        (kind = 'spelling' and form = any(sqlc.arg('spellings')::varchar[]))
+        or (kind = 'reading' and sqlc.arg('kana_only')::boolean and form = any(sqlc.arg('spellings')::varchar[]))
+        or (kind = 'spelling' and kana_only and form = any(sqlc.arg('readings')::varchar[]))
+      )
+  )
+order by created_at asc;
+
+-- name: ListCardsWithoutForms :many
+select
+  id,
+  language_code,
+  token,
+  meta
+from pending_cards
+where
+  id > sqlc.arg('after_id')
+  and not exists (
+    select 1
+    from card_forms
+    where card_forms.card_id = pending_cards.id
+  )
+order by id
+limit sqlc.arg('limit');
@@ -9,11 +9,15 @@ select
 from pending_cards
 where
   exported_at is null
+  and archived_at is null
   and language_code = sqlc.arg('language_code')
 order by created_at asc;
 
 -- name: GetImageFromPendingCard :one
-select source_image
+select
+  image_key,
+  thumbnail_key,
+  source_image
 from pending_cards
 where id = sqlc.arg('id');
 
@@ -21,13 +25,21 @@ where id = sqlc.arg('id');
 insert into pending_cards (
   language_code,
   token,
-  source_image,
-  meta
+  image_key,
+  thumbnail_key,
+  meta,
+  text_id,
+  text_offset,
+  annotation_id
 ) values (
   sqlc.arg('language_code'),
   sqlc.arg('token'),
-  decode(sqlc.arg('source_image')::text, 'base64'),
-  sqlc.arg('meta')
+  sqlc.narg('image_key'),
+  sqlc.narg('thumbnail_key'),
+  sqlc.arg('meta'),
+  sqlc.narg('text_id'),
+  sqlc.narg('text_offset'),
+  sqlc.narg('annotation_id')
 )
 returning id;
 
@@ -43,4 +55,379 @@ update pending_cards
 set
   updated_at = now(),
   exported_at = now()
-where id = sqlc.arg('id');
\ No newline at end of file
+where id = sqlc.arg('id');
+
+-- name: SetCardTimestamps :exec
+update pending_cards
+set
+  created_at = coalesce(sqlc.narg('created_at'), created_at),
+  exported_at = sqlc.narg('exported_at'),
+  archived_at = sqlc.narg('archived_at')
+where id = sqlc.arg('id');
+
+-- name: ListCardTokensForLanguage :many
+select distinct token
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+order by token;
+
+-- name: ListPendingCardsForExport :many
+select
+  id,
+  language_code,
+  token,
+  image_key,
+  source_image,
+  meta,
+  updated_at
+from pending_cards
+where
+  exported_at is null
+  and archived_at is null
+  and language_code = sqlc.arg('language_code')
+order by created_at asc;
+
+-- name: LockPendingCardsForExport :many
+select
+  id,
+  updated_at
+from pending_cards
+where
+  exported_at is null
+  and archived_at is null
+  and language_code = sqlc.arg('language_code')
+order by created_at asc
+for update;
+
+-- name: ListCardsWithImages :many
+select
+  id,
+  language_code,
+  token,
+  image_key,
+  source_image,
+  meta,
+  created_at,
+  exported_at,
+  archived_at
+from pending_cards
+where
+  (sqlc.narg('language_code')::varchar is null or language_code = sqlc.narg('language_code'))
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+order by created_at asc;
+
+-- name: ListCards :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  (sqlc.narg('language_code')::varchar is null or language_code = sqlc.narg('language_code'))
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+order by created_at asc;
+
+-- name: GetCard :one
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at
+from pending_cards
+where id = sqlc.arg('id');
+
+-- name: GetCardForUpdate :one
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at
+from pending_cards
+where id = sqlc.arg('id')
+for update;
+
+-- name: DeleteCard :execrows
+delete from pending_cards
+where id = sqlc.arg('id');
+
+-- name: ArchiveCard :execrows
+update pending_cards
+set
+  updated_at = now(),
+  archived_at = now()
+where id = sqlc.arg('id');
+
+-- name: UnarchiveCard :execrows
+update pending_cards
+set
+  updated_at = now(),
+  archived_at = null
+where id = sqlc.arg('id');
+
+-- name: UnmarkCardAsExported :execrows
+update pending_cards
+set
+  updated_at = now(),
+  exported_at = null
+where id = sqlc.arg('id');
+
+-- name: ListCardsWithLegacyImages :many
+select
+  id,
+  source_image
+from pending_cards
+where
+  source_image is not null
+  and image_key is null
+order by id asc
+limit sqlc.arg('limit');
+
+-- name: SetCardImageKeys :exec
+update pending_cards
+set
+  image_key = sqlc.narg('image_key'),
+  thumbnail_key = sqlc.narg('thumbnail_key'),
+  source_image = null
+where id = sqlc.arg('id');
+
+-- name: SearchCardsByCreatedAt :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  )
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (created_at, id) > (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by created_at asc, id asc
+limit sqlc.narg('limit');
+
+-- name: SearchCardsByCreatedAtDesc :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  )
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (created_at, id) < (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by created_at desc, id desc
+limit sqlc.narg('limit');
+
+-- name: SearchCardsByUpdatedAt :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  )
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (updated_at, id) > (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by updated_at asc, id asc
+limit sqlc.narg('limit');
+
+-- name: SearchCardsByUpdatedAtDesc :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  )
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (updated_at, id) < (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by updated_at desc, id desc
+limit sqlc.narg('limit');
+
+-- name: SearchCardsByToken :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  )
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (token, id) > (sqlc.narg('after_text')::text, sqlc.narg('after_id'))
+  )
+order by token asc, id asc
+limit sqlc.narg('limit');
+
+-- name: SearchCardsByTokenDesc :many
+select
+  id,
+  language_code,
+  token,
+  meta,
+  created_at,
+  updated_at,
+  exported_at,
+  archived_at,
+  text_id,
+  text_offset,
+  annotation_id
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  )
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (token, id) < (sqlc.narg('after_text')::text, sqlc.narg('after_id'))
+  )
+order by token desc, id desc
+limit sqlc.narg('limit');
+
+-- name: CountCards :one
+select count(*)
+from pending_cards
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('exported')::boolean is null or (exported_at is not null) = sqlc.narg('exported'))
+  and (sqlc.narg('archived')::boolean is null or (archived_at is not null) = sqlc.narg('archived'))
+  and (sqlc.narg('created_after')::timestamp is null or created_at >= sqlc.narg('created_after'))
+  and (sqlc.narg('created_before')::timestamp is null or created_at < sqlc.narg('created_before'))
+  and (
+    sqlc.narg('pattern')::text is null
+    or token ilike sqlc.narg('pattern')
+    or card_meta_values(meta) ilike sqlc.narg('pattern')
+  );
+
+-- name: ListCardTextOffsets :many
+select
+  id,
+  text_offset
+from pending_cards
+where
+  text_id = sqlc.arg('text_id')
+  and text_offset is not null;
+
+-- name: SetCardTextOffset :exec
+update pending_cards
+set text_offset = sqlc.arg('text_offset')
+where id = sqlc.arg('id');
@@ -0,0 +1,57 @@
+-- name: CreateCollection :one
+insert into collections (
+  language_code,
+  title,
+  author
+) values (
+  sqlc.arg('language_code'),
+  sqlc.arg('title'),
+  sqlc.arg('author')
+)
+returning id;
+
+-- name: ListCollections :many
+select
+  id,
+  language_code,
+  title,
+  author,
+  (select count(*) from texts where collection_id = collections.id) as text_count,
+  created_at,
+  updated_at
+from collections
+where language_code = sqlc.arg('language_code')
+order by title;
+
+-- name: GetCollection :one
+select
+  id,
+  language_code,
+  title,
+  created_at,
+  updated_at,
+  author
+from collections
+where id = sqlc.arg('id');
+
+-- name: UpdateCollection :execrows
+update collections
+set
+  title = sqlc.arg('title'),
+  author = sqlc.arg('author'),
+  updated_at = now()
+where id = sqlc.arg('id');
+
+-- name: DeleteCollection :execrows
+delete from collections
+where id = sqlc.arg('id');
+
+-- name: ListTextsInCollection :many
+select
+  id,
+  title,
+  collection_order,
+  last_position
+from texts
+where collection_id = sqlc.arg('collection_id')
+order by collection_order, id;
@@ -0,0 +1,50 @@
+-- name: FindGermanWords :many
+select
+  id,
+  lemma,
+  pos,
+  gender,
+  plural,
+  genitive,
+  auxiliary,
+  separable_prefix,
+  forms,
+  senses
+from german_words
+where
+  lemma = sqlc.arg('lemma')
+order by id asc;
+
+-- name: CreateGermanWord :exec
+insert into german_words (
+  lemma,
+  pos,
+  gender,
+  plural,
+  genitive,
+  auxiliary,
+  separable_prefix,
+  forms,
+  senses
+) values (
+  sqlc.arg('lemma'),
+  sqlc.arg('pos'),
+  sqlc.arg('gender'),
+  sqlc.arg('plural'),
+  sqlc.arg('genitive'),
+  sqlc.arg('auxiliary'),
+  sqlc.arg('separable_prefix'),
+  sqlc.arg('forms'),
+  sqlc.arg('senses')
+);
+
+-- name: DeleteGermanWords :exec
+delete from german_words;
+
+-- name: ListGermanSeparableVerbs :many
+select distinct lemma
+from german_words
+where
+  pos = 'verb'
+  and separable_prefix <> ''
+order by lemma asc;
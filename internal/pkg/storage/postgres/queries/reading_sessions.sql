@@ -0,0 +1,78 @@
+-- name: StartReadingSession :one
+insert into reading_sessions (
+  text_id,
+  language_code,
+  start_position
+) values (
+  sqlc.arg('text_id'),
+  sqlc.arg('language_code'),
+  sqlc.arg('start_position')
+)
+returning id;
+
+-- name: GetReadingSession :one
+select
+  id,
+  text_id,
+  language_code,
+  start_position,
+  end_position,
+  characters_read,
+  lookups,
+  started_at,
+  ended_at
+from reading_sessions
+where id = sqlc.arg('id')
+for update;
+
+-- name: StopReadingSession :execrows
+update reading_sessions
+set
+  end_position = sqlc.arg('end_position'),
+  characters_read = sqlc.arg('characters_read'),
+  lookups = lookups + sqlc.arg('lookups'),
+  ended_at = now()
+where
+  id = sqlc.arg('id')
+  and ended_at is null;
+
+-- name: AddReadingSessionLookup :execrows
+update reading_sessions
+set lookups = lookups + 1
+where
+  id = sqlc.arg('id')
+  and ended_at is null;
+
+-- name: ListReadingSessions :many
+with mined as (
+  -- A card counts for the latest session of its text that was running when
+  -- the card was created, sessions that weren't stopped run for 3 hours at
+  -- most
+  select (
+    select reading_sessions.id
+    from reading_sessions
+    where
+      reading_sessions.text_id = pending_cards.text_id
+      and reading_sessions.started_at <= pending_cards.created_at
+      and pending_cards.created_at < coalesce(reading_sessions.ended_at, reading_sessions.started_at + interval '3 hours')
+    order by reading_sessions.started_at desc
+    limit 1
+  ) as session_id
+  from pending_cards
+  where
+    pending_cards.language_code = sqlc.arg('language_code')
+    and pending_cards.text_id is not null
+)
+select
+  reading_sessions.id,
+  reading_sessions.text_id,
+  texts.title,
+  reading_sessions.characters_read,
+  reading_sessions.lookups,
+  reading_sessions.started_at,
+  reading_sessions.ended_at,
+  (select count(*) from mined where mined.session_id = reading_sessions.id) as words_mined
+from reading_sessions
+left join texts on texts.id = reading_sessions.text_id
+where reading_sessions.language_code = sqlc.arg('language_code')
+order by reading_sessions.started_at asc;
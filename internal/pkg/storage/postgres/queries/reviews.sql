@@ -0,0 +1,105 @@
+-- name: ListDueReviews :many
+select
+  pending_cards.id,
+  pending_cards.language_code,
+  pending_cards.token,
+  pending_cards.meta,
+  coalesce(reviews.repetitions, 0)::integer as repetitions,
+  coalesce(reviews.interval_days, 0)::integer as interval_days,
+  coalesce(reviews.ease_factor, 2.5)::double precision as ease_factor,
+  reviews.due_at
+from pending_cards
+left join reviews on reviews.card_id = pending_cards.id
+where
+  pending_cards.language_code = sqlc.arg('language_code')
+  and pending_cards.archived_at is null
+  and (reviews.due_at is null or reviews.due_at <= sqlc.arg('now'))
+order by reviews.due_at asc nulls last, pending_cards.created_at asc
+limit sqlc.arg('limit');
+
+-- name: CountDueReviews :one
+select
+  count(*) filter (where reviews.card_id is null) as new_cards,
+  count(*) filter (where reviews.card_id is not null) as due_cards
+from pending_cards
+left join reviews on reviews.card_id = pending_cards.id
+where
+  pending_cards.language_code = sqlc.arg('language_code')
+  and pending_cards.archived_at is null
+  and (reviews.due_at is null or reviews.due_at <= sqlc.arg('now'));
+
+-- name: GetReview :one
+select
+  card_id,
+  repetitions,
+  interval_days,
+  ease_factor,
+  due_at,
+  created_at,
+  updated_at
+from reviews
+where card_id = sqlc.arg('card_id')
+for update;
+
+-- name: SaveReview :exec
+insert into reviews (
+  card_id,
+  repetitions,
+  interval_days,
+  ease_factor,
+  due_at
+) values (
+  sqlc.arg('card_id'),
+  sqlc.arg('repetitions'),
+  sqlc.arg('interval_days'),
+  sqlc.arg('ease_factor'),
+  sqlc.arg('due_at')
+)
+on conflict (card_id) do update
+set
+  repetitions = excluded.repetitions,
+  interval_days = excluded.interval_days,
+  ease_factor = excluded.ease_factor,
+  due_at = excluded.due_at,
+  updated_at = now();
+
+-- name: CreateReviewLog :exec
+insert into review_logs (
+  card_id,
+  grade,
+  repetitions,
+  interval_days,
+  ease_factor,
+  due_at
+) values (
+  sqlc.arg('card_id'),
+  sqlc.arg('grade'),
+  sqlc.arg('repetitions'),
+  sqlc.arg('interval_days'),
+  sqlc.arg('ease_factor'),
+  sqlc.arg('due_at')
+);
+
+-- name: ListReviewLogs :many
+select
+  id,
+  card_id,
+  grade,
+  repetitions,
+  interval_days,
+  ease_factor,
+  due_at,
+  reviewed_at
+from review_logs
+where card_id = sqlc.arg('card_id')
+order by reviewed_at asc, id asc;
+
+-- name: ListReviewsForToken :many
+select
+  reviews.repetitions,
+  reviews.interval_days
+from reviews
+join pending_cards on pending_cards.id = reviews.card_id
+where
+  pending_cards.language_code = sqlc.arg('language_code')
+  and pending_cards.token = sqlc.arg('token');
@@ -23,6 +23,24 @@ insert into texts (
 )
 returning id;
 
+-- name: CreateImportedText :one
+insert into texts (
+  language_code,
+  title,
+  content,
+  ruby,
+  collection_id,
+  collection_order
+) values (
+  sqlc.arg('language_code'),
+  sqlc.arg('title'),
+  sqlc.arg('content'),
+  sqlc.arg('ruby'),
+  sqlc.narg('collection_id'),
+  sqlc.narg('collection_order')
+)
+returning id;
+
 -- name: GetText :one
 select
   id,
@@ -30,6 +48,10 @@ select
   title,
   content,
   last_position,
+  collection_id,
+  collection_order,
+  ruby,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
   created_at,
   updated_at
 from texts
@@ -42,3 +64,220 @@ set last_position = sqlc.arg('last_position')
 where
   id = sqlc.arg('id')
   and last_position < sqlc.arg('last_position');
+
+-- name: SearchTextsByCreatedAt :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'))
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (created_at, id) > (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by created_at asc, id asc
+limit sqlc.narg('limit');
+
+-- name: SearchTextsByCreatedAtDesc :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'))
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (created_at, id) < (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by created_at desc, id desc
+limit sqlc.narg('limit');
+
+-- name: SearchTextsByUpdatedAt :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'))
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (updated_at, id) > (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by updated_at asc, id asc
+limit sqlc.narg('limit');
+
+-- name: SearchTextsByUpdatedAtDesc :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'))
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (updated_at, id) < (sqlc.narg('after_time')::timestamp, sqlc.narg('after_id'))
+  )
+order by updated_at desc, id desc
+limit sqlc.narg('limit');
+
+-- name: SearchTextsByTitle :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'))
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (title, id) > (sqlc.narg('after_text')::text, sqlc.narg('after_id'))
+  )
+order by title asc, id asc
+limit sqlc.narg('limit');
+
+-- name: SearchTextsByTitleDesc :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'))
+  and (
+    sqlc.narg('after_id')::bigint is null
+    or (title, id) < (sqlc.narg('after_text')::text, sqlc.narg('after_id'))
+  )
+order by title desc, id desc
+limit sqlc.narg('limit');
+
+-- name: CountTexts :one
+select count(*)
+from texts
+where
+  language_code = sqlc.arg('language_code')
+  and (sqlc.narg('pattern')::text is null or title ilike sqlc.narg('pattern'))
+  and (sqlc.narg('tag')::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = sqlc.narg('tag')))
+  and (sqlc.narg('collection_id')::bigint is null or collection_id = sqlc.narg('collection_id'));
+
+-- name: UpdateText :execrows
+update texts
+set
+  title = sqlc.arg('title'),
+  content = sqlc.arg('content'),
+  -- The text can have fewer lines than before
+  last_position = least(last_position, sqlc.arg('line_count')),
+  updated_at = now()
+where id = sqlc.arg('id');
+
+-- name: DeleteText :execrows
+delete from texts
+where id = sqlc.arg('id');
+
+-- name: SetReadingPositionOfText :execrows
+update texts
+set last_position = sqlc.arg('last_position')
+where id = sqlc.arg('id');
+
+-- name: SetTextCollection :exec
+update texts
+set
+  collection_id = sqlc.narg('collection_id'),
+  collection_order = case
+    when sqlc.narg('collection_id')::bigint is null then null
+    else (
+      select coalesce(max(t.collection_order), 0) + 1
+      from texts t
+      where t.collection_id = sqlc.narg('collection_id')
+    )
+  end,
+  updated_at = now()
+where
+  id = sqlc.arg('id')
+  and collection_id is distinct from sqlc.narg('collection_id');
+
+-- name: MoveTextToCollection :execrows
+update texts
+set
+  collection_id = sqlc.arg('collection_id'),
+  collection_order = sqlc.arg('collection_order'),
+  updated_at = now()
+where
+  id = sqlc.arg('id')
+  and language_code = sqlc.arg('language_code');
+
+-- name: AddTextTag :exec
+insert into text_tags (
+  text_id,
+  tag
+) values (
+  sqlc.arg('text_id'),
+  sqlc.arg('tag')
+)
+on conflict do nothing;
+
+-- name: DeleteTextTags :exec
+delete from text_tags
+where text_id = sqlc.arg('text_id');
+
+-- name: ListTextTags :many
+select
+  tag,
+  count(*) as text_count
+from text_tags
+join texts on texts.id = text_tags.text_id
+where texts.language_code = sqlc.arg('language_code')
+group by tag
+order by tag;
@@ -12,4 +12,45 @@ from word_tokens
 where
   language_code = sqlc.arg('language_code')
   and token = any(sqlc.arg('tokens')::varchar(255))
-order by created_at desc;
\ No newline at end of file
+order by created_at desc;
+
+-- name: ListTokensForLanguage :many
+select distinct token
+from word_tokens
+where
+  language_code = sqlc.arg('language_code')
+order by token;
+
+-- name: LockWordToken :exec
+select pg_advisory_xact_lock(hashtext('word_tokens:' || sqlc.arg('language_code')::varchar || ':' || sqlc.arg('token')::varchar));
+
+-- name: SaveWordTokenRating :exec
+insert into word_tokens (
+  language_code,
+  token,
+  rating
+) values (
+  sqlc.arg('language_code'),
+  sqlc.arg('token'),
+  sqlc.arg('rating')
+)
+on conflict (language_code, token) do update
+set
+  rating = excluded.rating,
+  updated_at = now();
+
+-- name: ListTokenRatingsForLanguage :many
+select
+  token,
+  rating
+from word_tokens
+where
+  language_code = sqlc.arg('language_code');
+
+-- name: FindWordTokensByForms :many
+select distinct token
+from word_tokens
+where
+  language_code = sqlc.arg('language_code')
+  and token = any(sqlc.arg('forms')::varchar[])
+order by token;
@@ -7,9 +7,98 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+const addTextTag = `-- name: AddTextTag :exec
+insert into text_tags (
+  text_id,
+  tag
+) values (
+  $1,
+  $2
+)
+on conflict do nothing
+`
+
+type AddTextTagParams struct {
+	TextID int64
+	Tag    string
+}
+
+func (q *Queries) AddTextTag(ctx context.Context, arg AddTextTagParams) error {
+	_, err := q.db.ExecContext(ctx, addTextTag, arg.TextID, arg.Tag)
+	return err
+}
+
+const countTexts = `-- name: CountTexts :one
+select count(*)
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+`
+
+type CountTextsParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+}
+
+func (q *Queries) CountTexts(ctx context.Context, arg CountTextsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTexts, arg.LanguageCode, arg.Pattern, arg.Tag, arg.CollectionID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createImportedText = `-- name: CreateImportedText :one
+insert into texts (
+  language_code,
+  title,
+  content,
+  ruby,
+  collection_id,
+  collection_order
+) values (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6
+)
+returning id
+`
+
+type CreateImportedTextParams struct {
+	LanguageCode    string
+	Title           string
+	Content         string
+	Ruby            json.RawMessage
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+}
+
+func (q *Queries) CreateImportedText(ctx context.Context, arg CreateImportedTextParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createImportedText,
+		arg.LanguageCode,
+		arg.Title,
+		arg.Content,
+		arg.Ruby,
+		arg.CollectionID,
+		arg.CollectionOrder,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const createText = `-- name: CreateText :one
 insert into texts (
   language_code,
@@ -36,6 +125,29 @@ func (q *Queries) CreateText(ctx context.Context, arg CreateTextParams) (int64,
 	return id, err
 }
 
+const deleteText = `-- name: DeleteText :execrows
+delete from texts
+where id = $1
+`
+
+func (q *Queries) DeleteText(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteText, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteTextTags = `-- name: DeleteTextTags :exec
+delete from text_tags
+where text_id = $1
+`
+
+func (q *Queries) DeleteTextTags(ctx context.Context, textID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTextTags, textID)
+	return err
+}
+
 const getText = `-- name: GetText :one
 select
   id,
@@ -43,6 +155,10 @@ select
   title,
   content,
   last_position,
+  collection_id,
+  collection_order,
+  ruby,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
   created_at,
   updated_at
 from texts
@@ -51,13 +167,17 @@ where
 `
 
 type GetTextRow struct {
-	ID           int64
-	LanguageCode string
-	Title        string
-	Content      string
-	LastPosition int32
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID              int64
+	LanguageCode    string
+	Title           string
+	Content         string
+	LastPosition    int32
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Ruby            json.RawMessage
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 func (q *Queries) GetText(ctx context.Context, id int64) (GetTextRow, error) {
@@ -69,6 +189,10 @@ func (q *Queries) GetText(ctx context.Context, id int64) (GetTextRow, error) {
 		&i.Title,
 		&i.Content,
 		&i.LastPosition,
+		&i.CollectionID,
+		&i.CollectionOrder,
+		&i.Ruby,
+		&i.Tags,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -125,6 +249,628 @@ func (q *Queries) GetTextsForLanguage(ctx context.Context, languageCode string)
 	return items, nil
 }
 
+const listTextTags = `-- name: ListTextTags :many
+select
+  tag,
+  count(*) as text_count
+from text_tags
+join texts on texts.id = text_tags.text_id
+where texts.language_code = $1
+group by tag
+order by tag
+`
+
+type ListTextTagsRow struct {
+	Tag       string
+	TextCount int64
+}
+
+func (q *Queries) ListTextTags(ctx context.Context, languageCode string) ([]ListTextTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTextTags, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTextTagsRow
+	for rows.Next() {
+		var i ListTextTagsRow
+		if err := rows.Scan(&i.Tag, &i.TextCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveTextToCollection = `-- name: MoveTextToCollection :execrows
+update texts
+set
+  collection_id = $1,
+  collection_order = $2,
+  updated_at = now()
+where
+  id = $3
+  and language_code = $4
+`
+
+type MoveTextToCollectionParams struct {
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	ID              int64
+	LanguageCode    string
+}
+
+func (q *Queries) MoveTextToCollection(ctx context.Context, arg MoveTextToCollectionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveTextToCollection, arg.CollectionID, arg.CollectionOrder, arg.ID, arg.LanguageCode)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchTextsByCreatedAt = `-- name: SearchTextsByCreatedAt :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+  and (
+    $5::bigint is null
+    or (created_at, id) > ($6::timestamp, $5)
+  )
+order by created_at asc, id asc
+limit $7
+`
+
+type SearchTextsByCreatedAtParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+	AfterID      sql.NullInt64
+	AfterTime    sql.NullTime
+	Limit        sql.NullInt32
+}
+
+type SearchTextsByCreatedAtRow struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SearchTextsByCreatedAt(ctx context.Context, arg SearchTextsByCreatedAtParams) ([]SearchTextsByCreatedAtRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTextsByCreatedAt,
+		arg.LanguageCode,
+		arg.Pattern,
+		arg.Tag,
+		arg.CollectionID,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTextsByCreatedAtRow
+	for rows.Next() {
+		var i SearchTextsByCreatedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Title,
+			&i.CollectionID,
+			&i.CollectionOrder,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTextsByCreatedAtDesc = `-- name: SearchTextsByCreatedAtDesc :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+  and (
+    $5::bigint is null
+    or (created_at, id) < ($6::timestamp, $5)
+  )
+order by created_at desc, id desc
+limit $7
+`
+
+type SearchTextsByCreatedAtDescParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+	AfterID      sql.NullInt64
+	AfterTime    sql.NullTime
+	Limit        sql.NullInt32
+}
+
+type SearchTextsByCreatedAtDescRow struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SearchTextsByCreatedAtDesc(ctx context.Context, arg SearchTextsByCreatedAtDescParams) ([]SearchTextsByCreatedAtDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTextsByCreatedAtDesc,
+		arg.LanguageCode,
+		arg.Pattern,
+		arg.Tag,
+		arg.CollectionID,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTextsByCreatedAtDescRow
+	for rows.Next() {
+		var i SearchTextsByCreatedAtDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Title,
+			&i.CollectionID,
+			&i.CollectionOrder,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTextsByTitle = `-- name: SearchTextsByTitle :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+  and (
+    $5::bigint is null
+    or (title, id) > ($6::text, $5)
+  )
+order by title asc, id asc
+limit $7
+`
+
+type SearchTextsByTitleParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+	AfterID      sql.NullInt64
+	AfterText    sql.NullString
+	Limit        sql.NullInt32
+}
+
+type SearchTextsByTitleRow struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SearchTextsByTitle(ctx context.Context, arg SearchTextsByTitleParams) ([]SearchTextsByTitleRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTextsByTitle,
+		arg.LanguageCode,
+		arg.Pattern,
+		arg.Tag,
+		arg.CollectionID,
+		arg.AfterID,
+		arg.AfterText,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTextsByTitleRow
+	for rows.Next() {
+		var i SearchTextsByTitleRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Title,
+			&i.CollectionID,
+			&i.CollectionOrder,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTextsByTitleDesc = `-- name: SearchTextsByTitleDesc :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+  and (
+    $5::bigint is null
+    or (title, id) < ($6::text, $5)
+  )
+order by title desc, id desc
+limit $7
+`
+
+type SearchTextsByTitleDescParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+	AfterID      sql.NullInt64
+	AfterText    sql.NullString
+	Limit        sql.NullInt32
+}
+
+type SearchTextsByTitleDescRow struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SearchTextsByTitleDesc(ctx context.Context, arg SearchTextsByTitleDescParams) ([]SearchTextsByTitleDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTextsByTitleDesc,
+		arg.LanguageCode,
+		arg.Pattern,
+		arg.Tag,
+		arg.CollectionID,
+		arg.AfterID,
+		arg.AfterText,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTextsByTitleDescRow
+	for rows.Next() {
+		var i SearchTextsByTitleDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Title,
+			&i.CollectionID,
+			&i.CollectionOrder,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTextsByUpdatedAt = `-- name: SearchTextsByUpdatedAt :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+  and (
+    $5::bigint is null
+    or (updated_at, id) > ($6::timestamp, $5)
+  )
+order by updated_at asc, id asc
+limit $7
+`
+
+type SearchTextsByUpdatedAtParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+	AfterID      sql.NullInt64
+	AfterTime    sql.NullTime
+	Limit        sql.NullInt32
+}
+
+type SearchTextsByUpdatedAtRow struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SearchTextsByUpdatedAt(ctx context.Context, arg SearchTextsByUpdatedAtParams) ([]SearchTextsByUpdatedAtRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTextsByUpdatedAt,
+		arg.LanguageCode,
+		arg.Pattern,
+		arg.Tag,
+		arg.CollectionID,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTextsByUpdatedAtRow
+	for rows.Next() {
+		var i SearchTextsByUpdatedAtRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Title,
+			&i.CollectionID,
+			&i.CollectionOrder,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchTextsByUpdatedAtDesc = `-- name: SearchTextsByUpdatedAtDesc :many
+select
+  id,
+  language_code,
+  title,
+  collection_id,
+  collection_order,
+  (select coalesce(jsonb_agg(tag order by tag), '[]') from text_tags where text_id = texts.id)::jsonb as tags,
+  created_at,
+  updated_at
+from texts
+where
+  language_code = $1
+  and ($2::text is null or title ilike $2)
+  and ($3::text is null or exists (select 1 from text_tags where text_id = texts.id and tag = $3))
+  and ($4::bigint is null or collection_id = $4)
+  and (
+    $5::bigint is null
+    or (updated_at, id) < ($6::timestamp, $5)
+  )
+order by updated_at desc, id desc
+limit $7
+`
+
+type SearchTextsByUpdatedAtDescParams struct {
+	LanguageCode string
+	Pattern      sql.NullString
+	Tag          sql.NullString
+	CollectionID sql.NullInt64
+	AfterID      sql.NullInt64
+	AfterTime    sql.NullTime
+	Limit        sql.NullInt32
+}
+
+type SearchTextsByUpdatedAtDescRow struct {
+	ID              int64
+	LanguageCode    string
+	Title           string
+	CollectionID    sql.NullInt64
+	CollectionOrder sql.NullInt32
+	Tags            json.RawMessage
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SearchTextsByUpdatedAtDesc(ctx context.Context, arg SearchTextsByUpdatedAtDescParams) ([]SearchTextsByUpdatedAtDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchTextsByUpdatedAtDesc,
+		arg.LanguageCode,
+		arg.Pattern,
+		arg.Tag,
+		arg.CollectionID,
+		arg.AfterID,
+		arg.AfterTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTextsByUpdatedAtDescRow
+	for rows.Next() {
+		var i SearchTextsByUpdatedAtDescRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LanguageCode,
+			&i.Title,
+			&i.CollectionID,
+			&i.CollectionOrder,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setReadingPositionOfText = `-- name: SetReadingPositionOfText :execrows
+update texts
+set last_position = $1
+where id = $2
+`
+
+type SetReadingPositionOfTextParams struct {
+	LastPosition int32
+	ID           int64
+}
+
+func (q *Queries) SetReadingPositionOfText(ctx context.Context, arg SetReadingPositionOfTextParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setReadingPositionOfText, arg.LastPosition, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setTextCollection = `-- name: SetTextCollection :exec
+update texts
+set
+  collection_id = $1,
+  collection_order = case
+    when $1::bigint is null then null
+    else (
+      select coalesce(max(t.collection_order), 0) + 1
+      from texts t
+      where t.collection_id = $1
+    )
+  end,
+  updated_at = now()
+where
+  id = $2
+  and collection_id is distinct from $1
+`
+
+type SetTextCollectionParams struct {
+	CollectionID sql.NullInt64
+	ID           int64
+}
+
+func (q *Queries) SetTextCollection(ctx context.Context, arg SetTextCollectionParams) error {
+	_, err := q.db.ExecContext(ctx, setTextCollection, arg.CollectionID, arg.ID)
+	return err
+}
+
 const updateReadingPositionOfText = `-- name: UpdateReadingPositionOfText :exec
 update texts
 set last_position = $1
@@ -142,3 +888,29 @@ func (q *Queries) UpdateReadingPositionOfText(ctx context.Context, arg UpdateRea
 	_, err := q.db.ExecContext(ctx, updateReadingPositionOfText, arg.LastPosition, arg.ID)
 	return err
 }
+
+const updateText = `-- name: UpdateText :execrows
+update texts
+set
+  title = $1,
+  content = $2,
+  -- The text can have fewer lines than before
+  last_position = least(last_position, $3),
+  updated_at = now()
+where id = $4
+`
+
+type UpdateTextParams struct {
+	Title     string
+	Content   string
+	LineCount int32
+	ID        int64
+}
+
+func (q *Queries) UpdateText(ctx context.Context, arg UpdateTextParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateText, arg.Title, arg.Content, arg.LineCount, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -78,3 +78,149 @@ func (q *Queries) FindMatchingTokens(ctx context.Context, arg FindMatchingTokens
 	}
 	return items, nil
 }
+
+const findWordTokensByForms = `-- name: FindWordTokensByForms :many
+select distinct token
+from word_tokens
+where
+  language_code = $1
+  and token = any($2::varchar[])
+order by token
+`
+
+type FindWordTokensByFormsParams struct {
+	LanguageCode string
+	Forms        []string
+}
+
+func (q *Queries) FindWordTokensByForms(ctx context.Context, arg FindWordTokensByFormsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, findWordTokensByForms, arg.LanguageCode, arg.Forms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, err
+		}
+		items = append(items, token)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTokenRatingsForLanguage = `-- name: ListTokenRatingsForLanguage :many
+select
+  token,
+  rating
+from word_tokens
+where
+  language_code = $1
+`
+
+type ListTokenRatingsForLanguageRow struct {
+	Token  string
+	Rating sql.NullInt16
+}
+
+func (q *Queries) ListTokenRatingsForLanguage(ctx context.Context, languageCode string) ([]ListTokenRatingsForLanguageRow, error) {
+	rows, err := q.db.QueryContext(ctx, listTokenRatingsForLanguage, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTokenRatingsForLanguageRow
+	for rows.Next() {
+		var i ListTokenRatingsForLanguageRow
+		if err := rows.Scan(&i.Token, &i.Rating); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTokensForLanguage = `-- name: ListTokensForLanguage :many
+select distinct token
+from word_tokens
+where
+  language_code = $1
+order by token
+`
+
+func (q *Queries) ListTokensForLanguage(ctx context.Context, languageCode string) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listTokensForLanguage, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, err
+		}
+		items = append(items, token)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockWordToken = `-- name: LockWordToken :exec
+select pg_advisory_xact_lock(hashtext('word_tokens:' || $1::varchar || ':' || $2::varchar))
+`
+
+type LockWordTokenParams struct {
+	LanguageCode string
+	Token        string
+}
+
+func (q *Queries) LockWordToken(ctx context.Context, arg LockWordTokenParams) error {
+	_, err := q.db.ExecContext(ctx, lockWordToken, arg.LanguageCode, arg.Token)
+	return err
+}
+
+const saveWordTokenRating = `-- name: SaveWordTokenRating :exec
+insert into word_tokens (
+  language_code,
+  token,
+  rating
+) values (
+  $1,
+  $2,
+  $3
+)
+on conflict (language_code, token) do update
+set
+  rating = excluded.rating,
+  updated_at = now()
+`
+
+type SaveWordTokenRatingParams struct {
+	LanguageCode string
+	Token        string
+	Rating       sql.NullInt16
+}
+
+func (q *Queries) SaveWordTokenRating(ctx context.Context, arg SaveWordTokenRatingParams) error {
+	_, err := q.db.ExecContext(ctx, saveWordTokenRating, arg.LanguageCode, arg.Token, arg.Rating)
+	return err
+}
@@ -0,0 +1,205 @@
+package textfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/kapmahc/epub"
+	"github.com/pkg/errors"
+)
+
+// Unpacked files larger than these are rejected, an EPUB is limited by the
+// size of the upload but its files can be compressed far below their size
+const (
+	maxEPUBFileSize  = 32 << 20
+	maxEPUBTotalSize = 256 << 20
+)
+
+// archive reads the files of an EPUB and keeps track of how much was
+// unpacked.
+type archive struct {
+	files map[string]*zip.File
+	read  uint64
+}
+
+// readEPUB reads the chapters of an EPUB in the order of its spine. Chapter
+// titles come from the table of contents, or the first heading of a chapter
+// when it isn't listed. Pages without text like covers are skipped.
+func readEPUB(data []byte) (*Book, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidFile, err.Error())
+	}
+
+	files := &archive{files: map[string]*zip.File{}}
+	for _, f := range zr.File {
+		files.files[f.Name] = f
+	}
+
+	container := epub.Container{}
+	if err := readXML(files, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+
+	opfPath := container.Rootfile.Path
+	opf := epub.Opf{}
+	if err := readXML(files, opfPath, &opf); err != nil {
+		return nil, err
+	}
+
+	manifest := map[string]epub.Manifest{}
+	for _, item := range opf.Manifest {
+		manifest[item.ID] = item
+	}
+
+	labels := map[string]string{}
+	if toc, ok := manifest[opf.Spine.Toc]; ok {
+		ncxPath := resolve(opfPath, toc.Href)
+		ncx := epub.Ncx{}
+		if err := readXML(files, ncxPath, &ncx); err != nil {
+			return nil, err
+		}
+		addLabels(labels, ncxPath, ncx.Points)
+	}
+
+	book := &Book{
+		Title:  first(opf.Metadata.Title),
+		Author: authors(opf.Metadata.Creator),
+	}
+
+	for _, ref := range opf.Spine.Items {
+		item, ok := manifest[ref.IDref]
+		if !ok || !isHTML(item.MediaType) {
+			continue
+		}
+
+		name := resolve(opfPath, item.Href)
+		page, err := files.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		chapter, _, err := parseHTML(page)
+		if err != nil {
+			return nil, errors.Wrap(err, name)
+		}
+
+		if len(chapter.Lines) == 0 {
+			continue
+		}
+
+		if label := labels[name]; label != "" {
+			chapter.Title = label
+		}
+		if chapter.Title == "" {
+			chapter.Title = fmt.Sprintf("%s %d", book.Title, len(book.Chapters)+1)
+		}
+
+		book.Chapters = append(book.Chapters, chapter)
+	}
+
+	if len(book.Chapters) == 0 {
+		return nil, errors.Wrap(ErrInvalidFile, "book has no text")
+	}
+
+	return book, nil
+}
+
+// addLabels maps the files in the table of contents to their label, the
+// first entry of a file is used when a file contains several chapters.
+func addLabels(labels map[string]string, ncxPath string, points []epub.NavPoint) {
+	for _, p := range points {
+		name := resolve(ncxPath, p.Content.Src)
+		if _, ok := labels[name]; !ok {
+			labels[name] = collapse(p.Text)
+		}
+
+		addLabels(labels, ncxPath, p.Points)
+	}
+}
+
+// resolve returns the path in the archive of a link in a file, fragments are
+// removed.
+func resolve(from, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+
+	return path.Join(path.Dir(from), href)
+}
+
+func (a *archive) readFile(name string) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, errors.Wrapf(ErrInvalidFile, "%s is missing", name)
+	}
+
+	// The declared size can be checked without unpacking, but it can't be
+	// trusted so the reads are limited as well
+	if f.UncompressedSize64 > maxEPUBFileSize || a.read+f.UncompressedSize64 > maxEPUBTotalSize {
+		return nil, errors.Wrapf(ErrInvalidFile, "%s is too large", name)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open %s", name)
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxEPUBFileSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidFile, "could not read %s: %s", name, err)
+	}
+
+	a.read += uint64(len(data))
+	if len(data) > maxEPUBFileSize || a.read > maxEPUBTotalSize {
+		return nil, errors.Wrapf(ErrInvalidFile, "%s is too large", name)
+	}
+
+	return data, nil
+}
+
+func readXML(files *archive, name string, v interface{}) error {
+	data, err := files.readFile(name)
+	if err != nil {
+		return err
+	}
+
+	if err := xml.Unmarshal(data, v); err != nil {
+		return errors.Wrapf(ErrInvalidFile, "could not parse %s: %s", name, err)
+	}
+
+	return nil
+}
+
+func isHTML(mediaType string) bool {
+	return mediaType == "application/xhtml+xml" || mediaType == "text/html"
+}
+
+func first(values []string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+func authors(creators []epub.Author) string {
+	names := []string{}
+	for _, c := range creators {
+		if name := strings.TrimSpace(c.Data); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,326 @@
+package textfile
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+func readHTML(data []byte) (*Book, error) {
+	chapter, title, err := parseHTML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chapter.Lines) == 0 {
+		return nil, errors.Wrap(ErrInvalidFile, "document has no text")
+	}
+
+	if title == "" {
+		title = chapter.Title
+	}
+
+	return &Book{
+		Title:    title,
+		Chapters: []Chapter{chapter},
+	}, nil
+}
+
+// parseHTML converts an (X)HTML document to clean text. Block elements start
+// a new line and ruby annotations are taken out of the text. The chapter
+// title is the first heading, the title of the document is returned
+// separately.
+func parseHTML(data []byte) (Chapter, string, error) {
+	r, err := charset.NewReader(bytes.NewReader(data), "text/html")
+	if err != nil {
+		return Chapter{}, "", errors.Wrap(err, "could not detect encoding")
+	}
+
+	doc, err := html.Parse(r)
+	if err != nil {
+		return Chapter{}, "", errors.Wrap(ErrInvalidFile, err.Error())
+	}
+
+	conv := convert(doc)
+
+	return conv.chapter(), conv.title, nil
+}
+
+// FromNode converts an element of a parsed document to clean text, e.g. the
+// body of an article on a web page.
+func FromNode(n *html.Node) Chapter {
+	return convert(n).chapter()
+}
+
+// FromNodeWithBlankLines converts an element like FromNode, but line breaks
+// on empty lines are kept as blank lines. Web novels use them to separate
+// scenes.
+func FromNodeWithBlankLines(n *html.Node) Chapter {
+	conv := &converter{blankLines: true}
+	conv.walk(n)
+	conv.newLine()
+
+	// Trailing line breaks don't separate anything
+	for len(conv.lines) > 0 && conv.lines[len(conv.lines)-1] == "" {
+		conv.lines = conv.lines[:len(conv.lines)-1]
+	}
+
+	return conv.chapter()
+}
+
+func convert(n *html.Node) *converter {
+	conv := &converter{}
+	conv.walk(n)
+	conv.newLine()
+
+	return conv
+}
+
+type converter struct {
+	lines []string
+	ruby  []Ruby
+
+	// The line that is being written and its length in characters
+	line    strings.Builder
+	lineLen int
+	last    rune
+	space   bool
+
+	title   string
+	heading string
+
+	// Line breaks on empty lines are written as blank lines
+	blankLines bool
+}
+
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Rt:       true,
+	atom.Rp:       true,
+}
+
+var blockElements = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Blockquote: true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.Main:       true,
+	atom.Nav:        true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Td:         true,
+	atom.Th:         true,
+	atom.Tr:         true,
+	atom.Ul:         true,
+}
+
+func (c *converter) chapter() Chapter {
+	return Chapter{
+		Title: c.heading,
+		Lines: c.lines,
+		Ruby:  c.ruby,
+	}
+}
+
+func (c *converter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.write(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			c.walk(child)
+		}
+		return
+	}
+
+	switch {
+	case n.DataAtom == atom.Title:
+		if c.title == "" {
+			c.title = collapse(textContent(n))
+		}
+		return
+	case n.DataAtom == atom.Br:
+		if c.blankLines && c.lineLen == 0 {
+			c.lines = append(c.lines, "")
+		}
+		c.newLine()
+		return
+	case n.DataAtom == atom.Ruby:
+		c.writeRuby(n)
+		return
+	case skippedElements[n.DataAtom]:
+		return
+	}
+
+	block := blockElements[n.DataAtom]
+	if block {
+		c.newLine()
+	}
+
+	if c.heading == "" && isHeading(n.DataAtom) {
+		c.heading = collapse(textContent(n))
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+
+	if block {
+		c.newLine()
+	}
+}
+
+// writeRuby writes the base text of a ruby element and records its readings.
+// A ruby element can annotate several parts, e.g. <ruby>漢<rt>かん</rt>字<rt>じ</rt></ruby>.
+func (c *converter) writeRuby(n *html.Node) {
+	base := ""
+	offset := -1
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.DataAtom {
+		case atom.Rp:
+			continue
+		case atom.Rt:
+			reading := collapse(childText(child))
+			if base != "" && reading != "" {
+				c.ruby = append(c.ruby, Ruby{
+					Line:    len(c.lines),
+					Offset:  offset,
+					Base:    base,
+					Reading: reading,
+				})
+			}
+			base = ""
+			offset = -1
+			continue
+		}
+
+		text := textContent(child)
+		if offset < 0 && strings.TrimLeftFunc(text, isSpace) != "" {
+			c.writeSpace(text)
+			text = strings.TrimLeftFunc(text, isSpace)
+			offset = c.lineLen
+		}
+		c.write(text)
+		base += collapse(text)
+	}
+}
+
+// write appends text to the current line, whitespace is collapsed like a
+// browser would.
+func (c *converter) write(text string) {
+	for _, r := range text {
+		if isSpace(r) {
+			c.space = true
+			continue
+		}
+
+		if c.space && c.lineLen > 0 && !(isWide(c.last) && isWide(r)) {
+			c.line.WriteRune(' ')
+			c.lineLen++
+		}
+		c.space = false
+
+		c.line.WriteRune(r)
+		c.lineLen++
+		c.last = r
+	}
+}
+
+// isSpace reports whether a character is collapsed as whitespace in HTML,
+// ideographic spaces are kept like in browsers.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+// isWide reports whether a character belongs to a script that isn't written
+// with spaces, line breaks between these characters are dropped.
+func isWide(r rune) bool {
+	return r >= 0x2E80
+}
+
+// writeSpace writes the pending space before text so the offset of a ruby
+// base points at its first character.
+func (c *converter) writeSpace(text string) {
+	if r, _ := utf8.DecodeRuneInString(text); isSpace(r) {
+		c.space = true
+	}
+
+	next, _ := utf8.DecodeRuneInString(strings.TrimLeftFunc(text, isSpace))
+	if c.space && c.lineLen > 0 && !(isWide(c.last) && isWide(next)) {
+		c.line.WriteRune(' ')
+		c.lineLen++
+	}
+	c.space = false
+}
+
+func (c *converter) newLine() {
+	if c.lineLen > 0 {
+		c.lines = append(c.lines, c.line.String())
+	}
+
+	c.line.Reset()
+	c.lineLen = 0
+	c.last = 0
+	c.space = false
+}
+
+func isHeading(a atom.Atom) bool {
+	return a == atom.H1 || a == atom.H2 || a == atom.H3
+}
+
+// childText returns the text of the children of a node.
+func childText(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+
+	return sb.String()
+}
+
+// textContent returns the text of a node without ruby readings.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	if skippedElements[n.DataAtom] {
+		return ""
+	}
+
+	return childText(n)
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
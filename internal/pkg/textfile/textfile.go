@@ -0,0 +1,128 @@
+package textfile
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatEPUB = "epub"
+	FormatHTML = "html"
+	FormatText = "txt"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrInvalidFile       = errors.New("invalid text file")
+)
+
+// Book is an imported file, HTML and plain text files have a single chapter.
+type Book struct {
+	Title    string
+	Author   string
+	Chapters []Chapter
+}
+
+// Chapter is the clean text of a chapter, one paragraph per line.
+type Chapter struct {
+	Title string
+	Lines []string
+	Ruby  []Ruby
+}
+
+// Content returns the text of a chapter as it's stored for the reader.
+func (c Chapter) Content() string {
+	return strings.Join(c.Lines, "\n")
+}
+
+// Ruby is a reading annotation on a part of a line, e.g. furigana. The offset
+// is the position of the base text in the line, counted in characters.
+type Ruby struct {
+	Line    int    `json:"line"`
+	Offset  int    `json:"offset"`
+	Base    string `json:"base"`
+	Reading string `json:"reading"`
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatEPUB || format == FormatHTML || format == FormatText
+}
+
+// Detect guesses the format of a file from its content.
+func Detect(data []byte) string {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return FormatEPUB
+	}
+
+	head := strings.ToLower(string(data[:min(len(data), 512)]))
+	if strings.Contains(head, "<html") || strings.Contains(head, "<!doctype html") {
+		return FormatHTML
+	}
+
+	return FormatText
+}
+
+// Read parses a file in the given format. The title is used for files that
+// don't contain one, plain text files use their first line otherwise.
+func Read(data []byte, format string, title string) (*Book, error) {
+	var book *Book
+	var err error
+
+	switch format {
+	case FormatEPUB:
+		book, err = readEPUB(data)
+	case FormatHTML:
+		book, err = readHTML(data)
+	case FormatText:
+		book, err = readText(data)
+	default:
+		return nil, errors.Wrap(ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if title != "" {
+		book.Title = title
+	}
+
+	if book.Title == "" {
+		return nil, errors.Wrap(ErrInvalidFile, "file has no title")
+	}
+
+	for i := range book.Chapters {
+		if book.Chapters[i].Title == "" {
+			book.Chapters[i].Title = book.Title
+		}
+	}
+
+	return book, nil
+}
+
+func readText(data []byte) (*Book, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.Wrap(ErrInvalidFile, "text is not valid UTF-8")
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	chapter := Chapter{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			chapter.Lines = append(chapter.Lines, line)
+		}
+	}
+
+	if len(chapter.Lines) == 0 {
+		return nil, errors.Wrap(ErrInvalidFile, "text is empty")
+	}
+
+	return &Book{
+		Title:    chapter.Lines[0],
+		Chapters: []Chapter{chapter},
+	}, nil
+}
@@ -0,0 +1,198 @@
+package textfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// zipDir packs a folder in testdata as an EPUB, the mimetype comes first
+// and isn't compressed like the format requires.
+func zipDir(t *testing.T, dir string, extra map[string][]byte) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	z := zip.NewWriter(buf)
+
+	add := func(name string, data []byte, method uint16) {
+		w, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mimetype, err := os.ReadFile(filepath.Join(dir, "mimetype"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	add("mimetype", mimetype, zip.Store)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() == "mimetype" {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		add(filepath.ToSlash(name), data, zip.Deflate)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range extra {
+		add(name, data, zip.Deflate)
+	}
+
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestReadEPUB(t *testing.T) {
+	data := zipDir(t, "testdata/book", nil)
+
+	if format := Detect(data); format != FormatEPUB {
+		t.Fatalf("detected %s, want %s", format, FormatEPUB)
+	}
+
+	book, err := Read(data, FormatEPUB, "")
+	if err != nil {
+		t.Fatalf("could not read book: %v", err)
+	}
+
+	// The first title that isn't blank and every creator
+	if book.Title != "吾輩は猫である" {
+		t.Errorf("got title %q", book.Title)
+	}
+	if book.Author != "夏目 漱石, 中村 不折" {
+		t.Errorf("got author %q", book.Author)
+	}
+
+	want := []Chapter{
+		{
+			// Chapters are in spine order, the NCX links to an anchor
+			Title: "二",
+			Lines: []string{"吾輩は新年来多少有名になったので、猫ながらちょっと鼻が高く感ぜらるるのはありがたい。"},
+		},
+		{
+			// The first label of a file is used, the file name is escaped
+			// in the manifest and the NCX
+			Title: "一 吾輩は猫である",
+			Lines: []string{"第一章", "吾輩は猫である。名前はまだ無い。", "どこで生れたかとんと見当がつかぬ。"},
+			Ruby: []Ruby{
+				{Line: 1, Offset: 0, Base: "吾輩", Reading: "わがはい"},
+				{Line: 2, Offset: 10, Base: "見当", Reading: "けんとう"},
+			},
+		},
+		{
+			// Not in the table of contents
+			Title: "あとがき",
+			Lines: []string{"あとがき", "この本は青空文庫の底本をもとにしています。"},
+		},
+	}
+
+	if !reflect.DeepEqual(book.Chapters, want) {
+		t.Errorf("got chapters %+v, want %+v", book.Chapters, want)
+	}
+}
+
+func TestReadEPUBTooLarge(t *testing.T) {
+	// Zeros compress to a fraction of the upload limit
+	data := zipDir(t, "testdata/book", map[string][]byte{
+		"OEBPS/large.bin": make([]byte, maxEPUBFileSize+1),
+	})
+
+	files := &archive{files: map[string]*zip.File{}}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		files.files[f.Name] = f
+	}
+
+	if _, err := files.readFile("OEBPS/large.bin"); errors.Cause(err) != ErrInvalidFile {
+		t.Errorf("got error %v, want %v", err, ErrInvalidFile)
+	}
+
+	files.read = maxEPUBTotalSize
+	if _, err := files.readFile("OEBPS/content.opf"); errors.Cause(err) != ErrInvalidFile {
+		t.Errorf("expected the total size to be limited, got %v", err)
+	}
+}
+
+func TestReadHTML(t *testing.T) {
+	data, err := os.ReadFile("testdata/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if format := Detect(data); format != FormatHTML {
+		t.Fatalf("detected %s, want %s", format, FormatHTML)
+	}
+
+	book, err := Read(data, FormatHTML, "")
+	if err != nil {
+		t.Fatalf("could not read document: %v", err)
+	}
+
+	if book.Title != "ルビのテスト" {
+		t.Errorf("got title %q", book.Title)
+	}
+
+	want := []Chapter{{
+		Title: "見出し",
+		Lines: []string{
+			"見出し",
+			// Line breaks between Japanese characters aren't spaces
+			"今日は漢字と東京を勉強した。",
+			"Hello big world",
+			"二行目の仮名です。",
+			"項目",
+		},
+		Ruby: []Ruby{
+			{Line: 1, Offset: 3, Base: "漢", Reading: "かん"},
+			{Line: 1, Offset: 4, Base: "字", Reading: "じ"},
+			{Line: 1, Offset: 6, Base: "東京", Reading: "とうきょう"},
+		},
+	}}
+
+	if !reflect.DeepEqual(book.Chapters, want) {
+		t.Errorf("got chapters %+v, want %+v", book.Chapters, want)
+	}
+}
+
+func TestReadText(t *testing.T) {
+	book, err := Read([]byte("\ufeff題名\r\n\r\n  本文です。  \r\n"), FormatText, "")
+	if err != nil {
+		t.Fatalf("could not read text: %v", err)
+	}
+
+	if book.Title != "題名" || !reflect.DeepEqual(book.Chapters[0].Lines, []string{"題名", "本文です。"}) {
+		t.Errorf("got %+v", book)
+	}
+
+	if _, err := Read([]byte("\xff\xfe"), FormatText, ""); errors.Cause(err) != ErrInvalidFile {
+		t.Errorf("got error %v, want %v", err, ErrInvalidFile)
+	}
+}
@@ -0,0 +1,55 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+
+	// Supported input formats
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Thumbnails fit in a square of this size
+	DefaultSize = 320
+
+	quality = 80
+)
+
+// New returns a JPEG thumbnail that fits in a square of the given size. Images
+// that are already small enough are only re-encoded.
+func New(data []byte, size int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode image")
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > size || height > size {
+		if width > height {
+			width, height = size, height*size/width
+		} else {
+			width, height = width*size/height, size
+		}
+	}
+
+	// JPEG has no transparency, transparent pixels would turn black
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, dst, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, errors.Wrap(err, "could not encode thumbnail")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{name: "landscape", width: 800, height: 400, wantW: 320, wantH: 160},
+		{name: "portrait", width: 400, height: 1000, wantW: 128, wantH: 320},
+		{name: "small images keep their size", width: 100, height: 50, wantW: 100, wantH: 50},
+		{name: "thin images keep a pixel", width: 2000, height: 1, wantW: 320, wantH: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+
+			thumb, err := New(encodePNG(t, src), DefaultSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("could not decode thumbnail: %v", err)
+			}
+
+			if format != "jpeg" {
+				t.Errorf("got format %s, want jpeg", format)
+			}
+
+			if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("got %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestNewTransparent(t *testing.T) {
+	// A transparent image turns white instead of black
+	src := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+
+	thumb, err := New(encodePNG(t, src), DefaultSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, g, b, _ := img.At(5, 5).RGBA()
+	if c := color.Gray16Model.Convert(img.At(5, 5)).(color.Gray16); c.Y < 0xf000 {
+		t.Errorf("got color %d %d %d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if _, err := New([]byte("not an image"), DefaultSize); err == nil {
+		t.Error("expected an error")
+	}
+}
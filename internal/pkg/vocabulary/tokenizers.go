@@ -0,0 +1,154 @@
+package vocabulary
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/siongui/gojianfan"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/chinese/segmenter"
+	"github.com/antonve/language-learning-tools/internal/pkg/german/analyser"
+)
+
+// Words longer than this aren't looked up in a dictionary
+const maxWordLength = 12
+
+type chinese struct {
+	segmenter segmenter.Segmenter
+}
+
+// Chinese segments traditional Chinese text with jieba. Like the text
+// analyser it segments the simplified text and keeps the traditional words.
+func Chinese(seg segmenter.Segmenter) Tokenizer {
+	return &chinese{segmenter: seg}
+}
+
+func (t *chinese) Tokenize(text string) []Token {
+	tokens := []Token{}
+	offset := 0
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for _, w := range t.segmenter.Tokenize(gojianfan.T2S(line)) {
+			tokens = append(tokens, Token{
+				Word:  strings.TrimSpace(line[w.Start:w.End]),
+				Start: offset + w.Start,
+				End:   offset + w.End,
+			})
+		}
+
+		offset += len(line)
+	}
+
+	return tokens
+}
+
+type german struct {
+	analyser *analyser.Analyser
+}
+
+// German splits German text into words and uses their lemma.
+func German(a *analyser.Analyser) Tokenizer {
+	return &german{analyser: a}
+}
+
+func (t *german) Tokenize(text string) []Token {
+	tokens := []Token{}
+
+	for _, sentence := range t.analyser.Analyse(text) {
+		for _, token := range sentence.Tokens {
+			if token.POS == analyser.POSPunctuation || token.POS == analyser.POSNumber {
+				continue
+			}
+
+			word := token.Lemma
+			if word == "" {
+				word = token.Text
+			}
+
+			tokens = append(tokens, Token{
+				Word:  word,
+				Start: token.Start,
+				End:   token.End,
+			})
+		}
+	}
+
+	return tokens
+}
+
+type dictionary struct {
+	has func(word string) bool
+}
+
+// Dictionary splits text that isn't written with spaces, like Japanese, by
+// taking the longest word in the dictionary at every position. Runs of kanji
+// or katakana that aren't in the dictionary become a word, other characters
+// that don't start a word are skipped. Inflected words are split up, so the
+// result is only an approximation of a morphological analysis.
+func Dictionary(has func(word string) bool) Tokenizer {
+	return &dictionary{has: has}
+}
+
+func (t *dictionary) Tokenize(text string) []Token {
+	tokens := []Token{}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsLetter(r) {
+			i += size
+			continue
+		}
+
+		end := 0
+		for j, n := i, 0; j < len(text) && n < maxWordLength; n++ {
+			r, size := utf8.DecodeRuneInString(text[j:])
+			if !unicode.IsLetter(r) {
+				break
+			}
+			j += size
+
+			if t.has(text[i:j]) {
+				end = j
+			}
+		}
+
+		if end == 0 {
+			script := scriptOf(r)
+			if script == nil {
+				i += size
+				continue
+			}
+
+			for end = i; end < len(text); {
+				r, size := utf8.DecodeRuneInString(text[end:])
+				if scriptOf(r) != script {
+					break
+				}
+				end += size
+			}
+		}
+
+		tokens = append(tokens, Token{
+			Word:  text[i:end],
+			Start: i,
+			End:   end,
+		})
+		i = end
+	}
+
+	return tokens
+}
+
+// scriptOf returns the script of a character when runs of it are taken as a
+// word, the prolonged sound mark belongs to katakana.
+func scriptOf(r rune) *unicode.RangeTable {
+	switch {
+	case unicode.Is(unicode.Han, r):
+		return unicode.Han
+	case unicode.Is(unicode.Katakana, r), r == 'ー':
+		return unicode.Katakana
+	default:
+		return nil
+	}
+}
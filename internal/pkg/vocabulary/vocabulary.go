@@ -0,0 +1,164 @@
+package vocabulary
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	SortFirst     = "first"
+	SortCount     = "count"
+	SortFrequency = "frequency"
+)
+
+// Token is a word in a text. The word is the form that's looked up, e.g. the
+// lemma of a German verb, offsets are byte offsets into the text.
+type Token struct {
+	Word  string
+	Start int
+	End   int
+}
+
+// Tokenizer splits a text into words.
+type Tokenizer interface {
+	Tokenize(text string) []Token
+}
+
+// Word is a unique word in a text.
+type Word struct {
+	Word string
+	// The form of the first occurrence as it's written in the text
+	Form string
+	// How often the word occurs in the text
+	Count int
+	// Position of the first occurrence in characters, like the offsets of
+	// annotations
+	Offset int
+	// The sentence of the first occurrence
+	Sentence string
+	// Rank in the frequency list of the language, 0 when it's not listed
+	Rank int
+}
+
+// Extract returns the unique words of a text in the order they first occur.
+// Tokens without letters like numbers and punctuation are skipped.
+func Extract(text string, t Tokenizer) []Word {
+	words := []Word{}
+	index := map[string]int{}
+
+	// Offsets are converted to characters incrementally, tokens are in order
+	chars, bytes := 0, 0
+
+	for _, token := range t.Tokenize(text) {
+		if !hasLetter(token.Word) {
+			continue
+		}
+
+		if i, ok := index[token.Word]; ok {
+			words[i].Count++
+			continue
+		}
+
+		if token.Start >= bytes {
+			chars += utf8.RuneCountInString(text[bytes:token.Start])
+		} else {
+			chars = utf8.RuneCountInString(text[:token.Start])
+		}
+		bytes = token.Start
+
+		index[token.Word] = len(words)
+		words = append(words, Word{
+			Word:     token.Word,
+			Form:     text[token.Start:token.End],
+			Count:    1,
+			Offset:   chars,
+			Sentence: sentenceAt(text, token.Start),
+		})
+	}
+
+	return words
+}
+
+// Sort orders words by the given order, words are kept in the order they
+// occur when they're equal. Frequency puts common words first and words that
+// aren't in the frequency list last.
+func Sort(words []Word, order string) {
+	switch order {
+	case SortCount:
+		sort.SliceStable(words, func(i, j int) bool {
+			return words[i].Count > words[j].Count
+		})
+	case SortFrequency:
+		sort.SliceStable(words, func(i, j int) bool {
+			if words[i].Rank == 0 || words[j].Rank == 0 {
+				return words[j].Rank == 0 && words[i].Rank != 0
+			}
+
+			return words[i].Rank < words[j].Rank
+		})
+	}
+}
+
+func ValidSort(order string) bool {
+	return order == SortFirst || order == SortCount || order == SortFrequency
+}
+
+func hasLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sentenceAt returns the sentence around a byte offset. Sentences end at line
+// breaks and sentence-ending punctuation, closing quotes are kept with the
+// sentence they close.
+func sentenceAt(text string, offset int) string {
+	start := 0
+	for i := offset - 1; i >= 0; {
+		r, size := utf8.DecodeLastRuneInString(text[:i+1])
+		if r == '\n' || isSentenceEnd(r) {
+			start = i + 1
+			break
+		}
+		i -= size
+	}
+
+	end := len(text)
+	for i := offset; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '\n' {
+			end = i
+			break
+		}
+		i += size
+
+		if isSentenceEnd(r) {
+			for i < len(text) {
+				r, size := utf8.DecodeRuneInString(text[i:])
+				if !isClosingQuote(r) {
+					break
+				}
+				i += size
+			}
+
+			end = i
+			break
+		}
+	}
+
+	return strings.TrimSpace(strings.TrimLeftFunc(text[start:end], isClosingQuote))
+}
+
+func isSentenceEnd(r rune) bool {
+	return strings.ContainsRune("。！？!?.", r)
+}
+
+func isClosingQuote(r rune) bool {
+	return strings.ContainsRune("」』）)\"'”’", r)
+}
@@ -1,14 +1,17 @@
 package zdic
 
 import (
-	"fmt"
 	"net/url"
+	"strings"
+	"unicode"
 
 	"github.com/antchfx/htmlquery"
 	"github.com/pkg/errors"
 	"golang.org/x/net/html"
 )
 
+var ErrNotFound = errors.New("could not find definition or reading")
+
 type Zdic interface {
 	Search(word string) (*Result, error)
 }
@@ -21,29 +24,40 @@ func New() Zdic {
 }
 
 func (z *zdic) Search(word string) (*Result, error) {
-	doc, err := htmlquery.LoadURL("https://www.zdic.net/hans/" + url.QueryEscape(word))
+	doc, err := htmlquery.LoadURL(searchURL(word))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load")
 	}
 
+	return Parse(word, doc)
+}
+
+func searchURL(word string) string {
+	return "https://www.zdic.net/hans/" + url.QueryEscape(word)
+}
+
+// Parse extracts all readings and their senses from a zdic page.
+func Parse(word string, doc *html.Node) (*Result, error) {
 	res := &Result{
 		Word:       word,
-		Definition: z.getDefinition(doc),
+		Definition: getDefinition(doc),
+		Readings:   mergeReadings(getDefinitionReadings(doc), getHeaderReadings(doc)),
 	}
 
-	readings := z.getReadings(doc)
-	res.Pinyin = readings.Pinyin
-	res.Zhuyin = readings.Zhuyin
-	res.AudioURL = readings.Audio
+	if len(res.Readings) == 0 && res.Definition == "" {
+		return nil, ErrNotFound
+	}
 
-	if res.AudioURL == "" {
-		res.AudioURL = fmt.Sprintf("https://tts.baidu.com/text2audio?tex=%s&cuid=dict&lan=ZH&ctp=5&pdt=20&vol=9", word)
+	if len(res.Readings) > 0 {
+		res.Pinyin = res.Readings[0].Pinyin
+		res.Zhuyin = res.Readings[0].Zhuyin
+		res.AudioURL = res.Readings[0].AudioURL
 	}
 
 	return res, nil
 }
 
-func (z *zdic) getDefinition(doc *html.Node) string {
+func getDefinition(doc *html.Node) string {
 	def := htmlquery.FindOne(doc, "//*[@class=\"nr-box nr-box-shiyi jbjs\"][1]//*[contains(@class, \"jnr\")][1]")
 	if def == nil {
 		return ""
@@ -51,50 +65,187 @@ func (z *zdic) getDefinition(doc *html.Node) string {
 	return htmlquery.InnerText(def)
 }
 
-func (z *zdic) getReadings(doc *html.Node) *Readings {
-	res := &Readings{
-		Pinyin: "",
-		Zhuyin: "",
+// getHeaderReadings returns the readings listed at the top of the page.
+func getHeaderReadings(doc *html.Node) []Reading {
+	res := []Reading{}
+
+	// Pattern 1: single characters list every reading in the pinyin and zhuyin rows
+	pinyin := htmlquery.Find(doc, "//*[contains(@class, \"z_py\")][1]//*[contains(@class, \"z_d\")]")
+	zhuyin := htmlquery.Find(doc, "//*[contains(@class, \"z_zy\")][1]//*[contains(@class, \"z_d\")]")
+
+	for i, p := range pinyin {
+		r := Reading{
+			Pinyin:   cleanText(htmlquery.InnerText(p)),
+			AudioURL: getAudioURL(p),
+		}
+
+		if i < len(zhuyin) {
+			r.Zhuyin = cleanText(htmlquery.InnerText(zhuyin[i]))
+		}
+
+		res = append(res, r)
 	}
 
-	// Pattern 1: single characters
-	pinyin := htmlquery.FindOne(doc, "//*[contains(@class, \"z_py\")][1]//*[contains(@class, \"z_d\")][1]")
-	if pinyin != nil {
-		res.Pinyin = htmlquery.InnerText(pinyin)
+	if len(res) > 0 {
+		return res
 	}
 
-	zhuyin := htmlquery.FindOne(doc, "//*[contains(@class, \"z_zy\")][1]//*[contains(@class, \"z_d\")][1]")
-	if zhuyin != nil {
-		res.Zhuyin = htmlquery.InnerText(zhuyin)
+	// Pattern 2: compound words list pinyin and zhuyin pairs in the title
+	nodes := htmlquery.Find(doc, "//*[contains(@class, \"entry_title\")][1]//*[contains(@class, \"dicpy\")]")
+
+	for i := 0; i+1 < len(nodes); i += 2 {
+		res = append(res, Reading{
+			Pinyin:   cleanText(htmlquery.InnerText(nodes[i])),
+			Zhuyin:   cleanText(htmlquery.InnerText(nodes[i+1])),
+			AudioURL: getAudioURL(nodes[i]),
+		})
 	}
 
-	audio := htmlquery.FindOne(doc, "//*[@class=\"z_py\"]//*[contains(@class, \"audio_play_button\")][1]")
-	if audio != nil {
-		res.Audio = htmlquery.SelectAttr(audio, "data-src-mp3")
+	return res
+}
+
+// getDefinitionReadings walks the basic definition box. Every reading starts
+// with a `dicpy` element that is followed by the senses for that reading.
+func getDefinitionReadings(doc *html.Node) []Reading {
+	box := htmlquery.FindOne(doc, "//*[@class=\"nr-box nr-box-shiyi jbjs\"][1]")
+	if box == nil {
+		return nil
 	}
 
-	// Pattern 2: Compound words
-	nodes := htmlquery.Find(doc, "//*[contains(@class, \"entry_title\")][1]//*[contains(@class, \"dicpy\")]")
+	res := []Reading{}
 
-	if len(nodes) >= 2 && res.Pinyin == "" {
-		res.Pinyin = htmlquery.InnerText(nodes[0])
+	addSense := func(sense string) {
+		if sense == "" {
+			return
+		}
+
+		if len(res) == 0 {
+			res = append(res, Reading{})
+		}
+
+		last := &res[len(res)-1]
+		last.Senses = append(last.Senses, sense)
 	}
 
-	if len(nodes) >= 2 && res.Zhuyin == "" {
-		res.Zhuyin = htmlquery.InnerText(nodes[1])
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case hasClass(n, "dicpy"):
+				text := cleanText(htmlquery.InnerText(n))
+
+				// Zhuyin follows the pinyin of the same reading
+				if isZhuyin(text) {
+					if len(res) > 0 && res[len(res)-1].Zhuyin == "" {
+						res[len(res)-1].Zhuyin = text
+					}
+					return
+				}
+
+				res = append(res, Reading{
+					Pinyin:   text,
+					AudioURL: getAudioURL(n),
+				})
+				return
+			case n.Data == "li":
+				addSense(cleanText(htmlquery.InnerText(n)))
+				return
+			case n.Data == "p" && htmlquery.FindOne(n, ".//*[contains(@class, \"dicpy\")]") == nil:
+				addSense(cleanText(htmlquery.InnerText(n)))
+				return
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
 	}
+	walk(box)
 
 	return res
 }
 
-type Readings struct {
-	Pinyin string
-	Zhuyin string
-	Audio  string
+// mergeReadings fills in the zhuyin and audio of the definition readings with
+// the readings in the header of the page.
+func mergeReadings(definitions, header []Reading) []Reading {
+	if len(definitions) == 0 {
+		return header
+	}
+
+	for i := range definitions {
+		r := &definitions[i]
+
+		for _, h := range header {
+			if r.Pinyin != "" && r.Pinyin != h.Pinyin {
+				continue
+			}
+
+			if r.Pinyin == "" {
+				r.Pinyin = h.Pinyin
+			}
+			if r.Zhuyin == "" {
+				r.Zhuyin = h.Zhuyin
+			}
+			if r.AudioURL == "" {
+				r.AudioURL = h.AudioURL
+			}
+			break
+		}
+	}
+
+	return definitions
+}
+
+func getAudioURL(n *html.Node) string {
+	audio := htmlquery.FindOne(n, ".//*[contains(@class, \"audio_play_button\")]")
+	if audio == nil {
+		return ""
+	}
+
+	src := htmlquery.SelectAttr(audio, "data-src-mp3")
+	if strings.HasPrefix(src, "//") {
+		src = "https:" + src
+	}
+
+	return src
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(htmlquery.SelectAttr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isZhuyin(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Bopomofo, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type Reading struct {
+	Pinyin   string
+	Zhuyin   string
+	AudioURL string
+	Senses   []string
 }
 
 type Result struct {
-	Word       string
+	Word     string
+	Readings []Reading
+
+	// Pinyin, zhuyin and audio of the first reading
 	Pinyin     string
 	Zhuyin     string
 	AudioURL   string
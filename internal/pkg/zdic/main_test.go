@@ -0,0 +1,131 @@
+package zdic
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/antonve/language-learning-tools/internal/pkg/htmlfixture"
+)
+
+var update = flag.Bool("update", false, "record the fixtures from zdic.net")
+
+// loadFixture parses the recorded search results of a word, with `-update`
+// they're recorded again so changes to the markup of zdic show up here.
+func loadFixture(t *testing.T, word, name string) *html.Node {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := htmlfixture.Record(searchURL(word), path); err != nil {
+			t.Fatalf("could not record fixture: %v", err)
+		}
+	}
+
+	doc, err := htmlquery.LoadDoc(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		word     string
+		fixture  string
+		readings []Reading
+	}{
+		{
+			name:    "character with several readings",
+			word:    "行",
+			fixture: "xing.html",
+			readings: []Reading{
+				{
+					Pinyin:   "xíng",
+					Zhuyin:   "ㄒㄧㄥˊ",
+					AudioURL: "https://img.zdic.net/audio/zd/py/xíng.mp3",
+					Senses: []string{
+						"走：步～。～走。～进。～路。～程。～踪。～李。旅～。航～。",
+						"流通，传递：～销。发～。",
+						"能干：你真～。",
+					},
+				},
+				{
+					Pinyin:   "háng",
+					Zhuyin:   "ㄏㄤˊ",
+					AudioURL: "https://img.zdic.net/audio/zd/py/háng.mp3",
+					Senses: []string{
+						"行列，排：～伍。双～。",
+						"职业：～业。改～。",
+					},
+				},
+				{
+					// The zhuyin is missing in the definitions and taken from the header
+					Pinyin: "hàng",
+					Zhuyin: "ㄏㄤˋ",
+					Senses: []string{"〔树～子〕一行一行的树木。"},
+				},
+				{
+					Pinyin: "héng",
+					Zhuyin: "ㄏㄥˊ",
+					Senses: []string{"〔道～〕僧道修行的功夫。"},
+				},
+			},
+		},
+		{
+			name:    "compound word",
+			word:    "學生",
+			fixture: "xuesheng.html",
+			readings: []Reading{
+				{
+					Pinyin:   "xué shēng",
+					Zhuyin:   "ㄒㄩㄝˊ ㄕㄥ",
+					AudioURL: "https://img.zdic.net/audio/cd/xue2sheng1.mp3",
+					Senses: []string{
+						"在学校读书的人。",
+						"向老师或前辈学习的人。",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(tt.word, loadFixture(t, tt.word, tt.fixture))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(res.Readings, tt.readings) {
+				t.Errorf("Parse() readings = %#v, want %#v", res.Readings, tt.readings)
+			}
+
+			first := tt.readings[0]
+			if res.Pinyin != first.Pinyin || res.Zhuyin != first.Zhuyin || res.AudioURL != first.AudioURL {
+				t.Errorf("Parse() first reading = %q %q %q, want %q %q %q", res.Pinyin, res.Zhuyin, res.AudioURL, first.Pinyin, first.Zhuyin, first.AudioURL)
+			}
+
+			if !strings.Contains(res.Definition, first.Senses[0]) {
+				t.Errorf("Parse() definition = %q, want it to contain %q", res.Definition, first.Senses[0])
+			}
+		})
+	}
+}
+
+func TestParseNotFound(t *testing.T) {
+	word := "麤麤麤"
+
+	if _, err := Parse(word, loadFixture(t, word, "notfound.html")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrNotFound)
+	}
+}